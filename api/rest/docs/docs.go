@@ -16,6 +16,475 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/groups": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Portfolio groups consolidating accounts by alias",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/rest.GroupMembers"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/:name/data": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Group data: details of assets are summed across members",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/portfolio.Info"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/:name/history": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Group history downsampled by resolution: the last snapshot is taken for every resolution period",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start of time range (unix timestamp), default: to - 24h",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End of time range (unix timestamp), default: now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resolution as Go duration (ex. 5m, 1h), default: 1h",
+                        "name": "resolution",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/portfolio.Snapshot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/:name/trigger-events": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "History of executed group triggers, the latest events go first",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger ID",
+                        "name": "trigger_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start of time range (unix timestamp), default: 0",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End of time range (unix timestamp), default: now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max amount of events, default: 100, max: 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/portfolio.TriggerEvent"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/:name/triggers": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Group triggers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/portfolio.TriggerSettings"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Add trigger to group. PRICE_REACHED_LIMIT and INDICATOR types aren't supported",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Type-specific params (ex. limit, direction, low, high, percent, trailing_alert, asset, metric, targets, tolerance, window, operator, conditions, expression) are set along with type, currency, optional balance_basis (TOTAL, AVAILABLE, LOCKED), re-arm settings (cooldown_secs, hysteresis) supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert), schedule settings (active_from, expires_at, active_hours) and min_completeness of data quality",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/requests.AddTrigger"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/portfolio.TriggerSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request. Column is set if expression is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ExpressionError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/:name/triggers/:id": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Group trigger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/portfolio.TriggerSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Delete group trigger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Update group trigger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Type-specific trigger params (ex. limit, direction, low, high, percent, trailing_alert, asset, metric, targets, tolerance, window, operator, conditions, expression), re-arm settings (cooldown_secs, hysteresis) supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert), schedule settings (active_from, expires_at, active_hours) and min_completeness (null removes setting) to be changed",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/portfolio.TriggerSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request. Column is set if expression is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ExpressionError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/:name/triggers/:id/pause": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Pause group trigger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/portfolio.TriggerSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/:name/triggers/:id/resume": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Resume paused group trigger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/portfolio.TriggerSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
         "/portfolios/:name/data": {
             "get": {
                 "produces": [
@@ -24,7 +493,372 @@ const docTemplate = `{
                 "tags": [
                     "Portfolios"
                 ],
-                "summary": "Portfolio data",
+                "summary": "Portfolio data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Portfolio name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/portfolio.Info"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/portfolios/:name/history": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Portfolios"
+                ],
+                "summary": "Portfolio history downsampled by resolution: the last snapshot is taken for every resolution period",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Portfolio name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start of time range (unix timestamp), default: to - 24h",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End of time range (unix timestamp), default: now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resolution as Go duration (ex. 5m, 1h), default: 1h",
+                        "name": "resolution",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/portfolio.Snapshot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/portfolios/:name/trigger-events": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Portfolios"
+                ],
+                "summary": "History of executed portfolio triggers, the latest events go first",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Portfolio name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger ID",
+                        "name": "trigger_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start of time range (unix timestamp), default: 0",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End of time range (unix timestamp), default: now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max amount of events, default: 100, max: 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/portfolio.TriggerEvent"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/portfolios/:name/triggers": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Portfolios"
+                ],
+                "summary": "Portfolio triggers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Portfolio name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/portfolio.TriggerSettings"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Portfolios"
+                ],
+                "summary": "Add trigger to portfolio",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Portfolio name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Type-specific params (ex. limit, direction, low, high, percent, trailing_alert, asset, metric, symbol, side, indicator, targets, tolerance, window, operator, conditions, expression) are set along with type, currency, optional balance_basis (TOTAL, AVAILABLE, LOCKED), re-arm settings (cooldown_secs, hysteresis) supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert), schedule settings (active_from, expires_at, active_hours) and min_completeness of data quality",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/requests.AddTrigger"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/portfolio.TriggerSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request. Column is set if expression is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ExpressionError"
+                        }
+                    }
+                }
+            }
+        },
+        "/portfolios/:name/triggers/:id": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Portfolios"
+                ],
+                "summary": "Portfolio trigger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Portfolio name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/portfolio.TriggerSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Portfolios"
+                ],
+                "summary": "Delete portfolio trigger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Portfolio name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/echo.HTTPError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Portfolios"
+                ],
+                "summary": "Update portfolio trigger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Portfolio name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Type-specific trigger params (ex. limit, direction, low, high, percent, trailing_alert, asset, metric, symbol, side, indicator, targets, tolerance, window, operator, conditions, expression), re-arm settings (cooldown_secs, hysteresis) supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert), schedule settings (active_from, expires_at, active_hours) and min_completeness (null removes setting) to be changed",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/portfolio.TriggerSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request. Column is set if expression is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ExpressionError"
+                        }
+                    }
+                }
+            }
+        },
+        "/portfolios/:name/triggers/:id/pause": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Portfolios"
+                ],
+                "summary": "Pause portfolio trigger",
                 "parameters": [
                     {
                         "type": "string",
@@ -32,13 +866,20 @@ const docTemplate = `{
                         "name": "name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trigger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/portfolio.Info"
+                            "$ref": "#/definitions/portfolio.TriggerSettings"
                         }
                     },
                     "400": {
@@ -50,18 +891,15 @@ const docTemplate = `{
                 }
             }
         },
-        "/portfolios/:name/triggers": {
+        "/portfolios/:name/triggers/:id/resume": {
             "post": {
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Portfolios"
                 ],
-                "summary": "Add trigger to portfolio",
+                "summary": "Resume paused portfolio trigger",
                 "parameters": [
                     {
                         "type": "string",
@@ -71,45 +909,11 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": " ",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "required": [
-                                    "currency",
-                                    "type"
-                                ],
-                                "properties": {
-                                    "currency": {
-                                        "type": "string",
-                                        "enum": [
-                                            "USDT",
-                                            "BTC"
-                                        ]
-                                    },
-                                    "limit": {
-                                        "type": "number"
-                                    },
-                                    "percent": {
-                                        "type": "number"
-                                    },
-                                    "trailing_alert": {
-                                        "type": "boolean"
-                                    },
-                                    "type": {
-                                        "type": "string",
-                                        "enum": [
-                                            "COST_REACHED_LIMIT",
-                                            "COST_CHANGED_BY_PERCENT"
-                                        ]
-                                    }
-                                }
-                            }
-                        }
+                        "type": "string",
+                        "description": "Trigger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -136,6 +940,111 @@ const docTemplate = `{
                 "message": {}
             }
         },
+        "portfolio.ActiveHours": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "MON",
+                            "TUE",
+                            "WED",
+                            "THU",
+                            "FRI",
+                            "SAT",
+                            "SUN"
+                        ]
+                    }
+                },
+                "from": {
+                    "type": "string",
+                    "example": "09:00"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "17:30"
+                }
+            }
+        },
+        "portfolio.AssetBalance": {
+            "type": "object",
+            "required": [
+                "available",
+                "locked",
+                "total"
+            ],
+            "properties": {
+                "available": {
+                    "$ref": "#/definitions/portfolio.BalanceValue"
+                },
+                "locked": {
+                    "$ref": "#/definitions/portfolio.BalanceValue"
+                },
+                "total": {
+                    "$ref": "#/definitions/portfolio.BalanceValue"
+                }
+            }
+        },
+        "portfolio.BalanceValue": {
+            "type": "object",
+            "required": [
+                "quantity",
+                "value"
+            ],
+            "properties": {
+                "quantity": {
+                    "type": "number"
+                },
+                "value": {
+                    "$ref": "#/definitions/portfolio.ConvertedTo"
+                }
+            }
+        },
+        "portfolio.Balances": {
+            "type": "object",
+            "required": [
+                "available",
+                "details",
+                "locked",
+                "total"
+            ],
+            "properties": {
+                "available": {
+                    "$ref": "#/definitions/portfolio.ConvertedTo"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/portfolio.AssetBalance"
+                    }
+                },
+                "locked": {
+                    "$ref": "#/definitions/portfolio.ConvertedTo"
+                },
+                "total": {
+                    "$ref": "#/definitions/portfolio.ConvertedTo"
+                }
+            }
+        },
+        "portfolio.ConversionStep": {
+            "type": "object",
+            "required": [
+                "symbol"
+            ],
+            "properties": {
+                "fx": {
+                    "type": "boolean"
+                },
+                "inverse": {
+                    "type": "boolean"
+                },
+                "symbol": {
+                    "type": "string"
+                }
+            }
+        },
         "portfolio.ConvertedTo": {
             "type": "object",
             "additionalProperties": {
@@ -145,24 +1054,23 @@ const docTemplate = `{
         "portfolio.Data": {
             "type": "object",
             "required": [
+                "price_info",
                 "prices"
             ],
             "properties": {
                 "balance": {
+                    "$ref": "#/definitions/portfolio.Balances"
+                },
+                "fx": {
+                    "$ref": "#/definitions/portfolio.FXState"
+                },
+                "price_info": {
+                    "description": "PriceInfo describes sources and statuses of Prices",
                     "type": "object",
-                    "required": [
-                        "details",
-                        "total"
-                    ],
-                    "properties": {
-                        "details": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "$ref": "#/definitions/portfolio.ConvertedTo"
-                            }
-                        },
-                        "total": {
-                            "$ref": "#/definitions/portfolio.ConvertedTo"
+                    "additionalProperties": {
+                        "type": "object",
+                        "additionalProperties": {
+                            "$ref": "#/definitions/portfolio.PriceInfo"
                         }
                     }
                 },
@@ -171,6 +1079,37 @@ const docTemplate = `{
                     "additionalProperties": {
                         "$ref": "#/definitions/portfolio.ConvertedTo"
                     }
+                },
+                "pricing": {
+                    "description": "Pricing is a policy prices are calculated by",
+                    "type": "string",
+                    "enum": [
+                        "ASK",
+                        "BID",
+                        "MID",
+                        "LIQUIDATION"
+                    ]
+                },
+                "quality": {
+                    "$ref": "#/definitions/portfolio.Quality"
+                }
+            }
+        },
+        "portfolio.FXState": {
+            "type": "object",
+            "required": [
+                "rates"
+            ],
+            "properties": {
+                "rates": {
+                    "$ref": "#/definitions/portfolio.ConvertedTo"
+                },
+                "stale": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "integer",
+                    "format": "timestamp"
                 }
             }
         },
@@ -192,6 +1131,121 @@ const docTemplate = `{
                 }
             }
         },
+        "portfolio.PriceInfo": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "age_secs": {
+                    "type": "integer"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/portfolio.ConversionStep"
+                    }
+                },
+                "source": {
+                    "type": "string",
+                    "example": "binance"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "OK",
+                        "STALE",
+                        "UNPRICED"
+                    ]
+                },
+                "updated_at": {
+                    "type": "integer",
+                    "format": "timestamp"
+                }
+            }
+        },
+        "portfolio.Quality": {
+            "type": "object",
+            "required": [
+                "completeness"
+            ],
+            "properties": {
+                "completeness": {
+                    "type": "number",
+                    "example": 100
+                },
+                "ok": {
+                    "type": "integer"
+                },
+                "stale": {
+                    "type": "integer"
+                },
+                "unpriced": {
+                    "type": "integer"
+                }
+            }
+        },
+        "portfolio.Snapshot": {
+            "type": "object",
+            "required": [
+                "details",
+                "timestamp",
+                "total"
+            ],
+            "properties": {
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/portfolio.ConvertedTo"
+                    }
+                },
+                "timestamp": {
+                    "type": "integer",
+                    "format": "timestamp"
+                },
+                "total": {
+                    "$ref": "#/definitions/portfolio.ConvertedTo"
+                }
+            }
+        },
+        "portfolio.TriggerEvent": {
+            "type": "object",
+            "properties": {
+                "current_value": {
+                    "type": "number"
+                },
+                "details": {
+                    "type": "object"
+                },
+                "done": {
+                    "type": "boolean"
+                },
+                "group": {
+                    "description": "Portfolio is a name of Group",
+                    "type": "boolean"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "EXECUTED",
+                        "EXPIRED"
+                    ]
+                },
+                "portfolio": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "integer",
+                    "format": "timestamp"
+                },
+                "total": {
+                    "$ref": "#/definitions/portfolio.ConvertedTo"
+                },
+                "trigger_settings": {
+                    "$ref": "#/definitions/portfolio.TriggerSettings"
+                }
+            }
+        },
         "portfolio.TriggerSettings": {
             "type": "object",
             "required": [
@@ -201,41 +1255,151 @@ const docTemplate = `{
                 "type"
             ],
             "properties": {
-                "created_at": {
+                "active_from": {
                     "type": "integer",
                     "format": "timestamp",
                     "example": 1654586492
                 },
-                "currency": {
+                "active_hours": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/portfolio.ActiveHours"
+                    }
+                },
+                "balance_basis": {
                     "type": "string",
                     "enum": [
-                        "USDT",
-                        "BTC"
+                        "TOTAL",
+                        "AVAILABLE",
+                        "LOCKED"
                     ]
                 },
-                "id": {
-                    "type": "string",
-                    "format": "UUID",
-                    "example": "e1c6c253-00cd-4562-ae5c-ce065f8530c6"
+                "cooldown_secs": {
+                    "description": "CooldownSecs is supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert)",
+                    "type": "integer",
+                    "example": 900
                 },
-                "limit": {
-                    "type": "number"
+                "created_at": {
+                    "type": "integer",
+                    "format": "timestamp",
+                    "example": 1654586492
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "integer",
+                    "format": "timestamp",
+                    "example": 1657178492
                 },
-                "percent": {
+                "fired_at": {
+                    "type": "integer",
+                    "format": "timestamp",
+                    "example": 1654586492
+                },
+                "fired_value": {
                     "type": "number"
                 },
-                "start_total_cost": {
+                "hysteresis": {
+                    "description": "Hysteresis is supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert)",
                     "type": "number"
                 },
-                "trailing_alert": {
+                "id": {
+                    "type": "string",
+                    "format": "UUID",
+                    "example": "e1c6c253-00cd-4562-ae5c-ce065f8530c6"
+                },
+                "min_completeness": {
+                    "type": "number",
+                    "example": 95
+                },
+                "paused": {
                     "type": "boolean"
                 },
                 "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "requests.AddTrigger": {
+            "type": "object",
+            "required": [
+                "currency",
+                "type"
+            ],
+            "properties": {
+                "active_from": {
+                    "type": "integer",
+                    "format": "timestamp",
+                    "example": 1654586492
+                },
+                "active_hours": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/portfolio.ActiveHours"
+                    }
+                },
+                "balance_basis": {
+                    "description": "Basis is a part of balances trigger evaluates, TOTAL by default",
                     "type": "string",
                     "enum": [
-                        "COST_REACHED_LIMIT",
-                        "COST_CHANGED_BY_PERCENT"
+                        "TOTAL",
+                        "AVAILABLE",
+                        "LOCKED"
                     ]
+                },
+                "cooldown_secs": {
+                    "description": "CooldownSecs is supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert)",
+                    "type": "integer",
+                    "example": 900
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "integer",
+                    "format": "timestamp",
+                    "example": 1657178492
+                },
+                "hysteresis": {
+                    "description": "Hysteresis is supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert)",
+                    "type": "number"
+                },
+                "min_completeness": {
+                    "type": "number",
+                    "example": 95
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.ExpressionError": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.GroupMembers": {
+            "type": "object",
+            "required": [
+                "members",
+                "name"
+            ],
+            "properties": {
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
                 }
             }
         }
@@ -0,0 +1,184 @@
+package rest
+
+import (
+	"github.com/egsam98/portfolio/domain/portfolio"
+	"github.com/labstack/echo/v4"
+)
+
+// GroupMembers is a portfolio group with names of member portfolios
+type GroupMembers struct {
+	Name    string   `json:"name" validate:"required"`
+	Members []string `json:"members" validate:"required"`
+}
+
+// groupsController serves portfolio groups by handlers of portfoliosController
+type groupsController struct {
+	*portfoliosController
+	pm *portfolio.Manager
+}
+
+func newGroupsController(pm *portfolio.Manager) *groupsController {
+	return &groupsController{
+		portfoliosController: &portfoliosController{
+			portfolio: func(name string) (*portfolio.Portfolio, error) {
+				group, err := pm.Group(name)
+				if err != nil {
+					return nil, err
+				}
+				return group.Portfolio, nil
+			},
+		},
+		pm: pm,
+	}
+}
+
+// getGroups godoc
+// @Router /groups [get]
+// @Summary Portfolio groups consolidating accounts by alias
+// @Tags Groups
+// @Produce json
+// @Success	200 {array} rest.GroupMembers
+func (g *groupsController) getGroups(ctx echo.Context) error {
+	groups := g.pm.Groups()
+	res := make([]GroupMembers, len(groups))
+	for i, group := range groups {
+		res[i] = GroupMembers{Name: group.Name(), Members: group.Members()}
+	}
+	return ctx.JSON(200, res)
+}
+
+// getData godoc
+// @Router /groups/:name/data [get]
+// @Summary Group data: details of assets are summed across members
+// @Tags Groups
+// @Param name path string true "Group name"
+// @Produce json
+// @Success	200 {object} portfolio.Info
+// @Failure 400 {object} echo.HTTPError
+func (g *groupsController) getData(ctx echo.Context) error {
+	return g.portfoliosController.getData(ctx)
+}
+
+// getHistory godoc
+// @Router /groups/:name/history [get]
+// @Summary Group history downsampled by resolution: the last snapshot is taken for every resolution period
+// @Tags Groups
+// @Param name path string true "Group name"
+// @Param from query int false "Start of time range (unix timestamp), default: to - 24h"
+// @Param to query int false "End of time range (unix timestamp), default: now"
+// @Param resolution query string false "Resolution as Go duration (ex. 5m, 1h), default: 1h"
+// @Produce json
+// @Success	200 {array} portfolio.Snapshot
+// @Failure 400 {object} echo.HTTPError
+func (g *groupsController) getHistory(ctx echo.Context) error {
+	return g.portfoliosController.getHistory(ctx)
+}
+
+// addTriggers godoc
+// @Router /groups/:name/triggers [post]
+// @Summary Add trigger to group. PRICE_REACHED_LIMIT and INDICATOR types aren't supported
+// @Tags Groups
+// @Param name path string true "Group name"
+// @Param body body requests.AddTriggers true "Type-specific params (ex. limit, direction, low, high, percent, trailing_alert, asset, metric, targets, tolerance, window, operator, conditions, expression) are set along with type, currency, optional balance_basis (TOTAL, AVAILABLE, LOCKED), re-arm settings (cooldown_secs, hysteresis) supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert), schedule settings (active_from, expires_at, active_hours) and min_completeness of data quality"
+// @Accept json
+// @Produce json
+// @Success	200 {object} portfolio.TriggerSettings
+// @Failure 400 {object} rest.ExpressionError "Invalid request. Column is set if expression is invalid"
+func (g *groupsController) addTriggers(ctx echo.Context) error {
+	return g.portfoliosController.addTriggers(ctx)
+}
+
+// getTriggers godoc
+// @Router /groups/:name/triggers [get]
+// @Summary Group triggers
+// @Tags Groups
+// @Param name path string true "Group name"
+// @Produce json
+// @Success	200 {array} portfolio.TriggerSettings
+// @Failure 400 {object} echo.HTTPError
+func (g *groupsController) getTriggers(ctx echo.Context) error {
+	return g.portfoliosController.getTriggers(ctx)
+}
+
+// getTrigger godoc
+// @Router /groups/:name/triggers/:id [get]
+// @Summary Group trigger
+// @Tags Groups
+// @Param name path string true "Group name"
+// @Param id path string true "Trigger ID"
+// @Produce json
+// @Success	200 {object} portfolio.TriggerSettings
+// @Failure 400 {object} echo.HTTPError
+func (g *groupsController) getTrigger(ctx echo.Context) error {
+	return g.portfoliosController.getTrigger(ctx)
+}
+
+// updateTrigger godoc
+// @Router /groups/:name/triggers/:id [patch]
+// @Summary Update group trigger
+// @Tags Groups
+// @Param name path string true "Group name"
+// @Param id path string true "Trigger ID"
+// @Param body body object true "Type-specific trigger params (ex. limit, direction, low, high, percent, trailing_alert, asset, metric, targets, tolerance, window, operator, conditions, expression), re-arm settings (cooldown_secs, hysteresis) supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert), schedule settings (active_from, expires_at, active_hours) and min_completeness (null removes setting) to be changed"
+// @Accept json
+// @Produce json
+// @Success	200 {object} portfolio.TriggerSettings
+// @Failure 400 {object} rest.ExpressionError "Invalid request. Column is set if expression is invalid"
+func (g *groupsController) updateTrigger(ctx echo.Context) error {
+	return g.portfoliosController.updateTrigger(ctx)
+}
+
+// deleteTrigger godoc
+// @Router /groups/:name/triggers/:id [delete]
+// @Summary Delete group trigger
+// @Tags Groups
+// @Param name path string true "Group name"
+// @Param id path string true "Trigger ID"
+// @Success	204
+// @Failure 400 {object} echo.HTTPError
+func (g *groupsController) deleteTrigger(ctx echo.Context) error {
+	return g.portfoliosController.deleteTrigger(ctx)
+}
+
+// pauseTrigger godoc
+// @Router /groups/:name/triggers/:id/pause [post]
+// @Summary Pause group trigger
+// @Tags Groups
+// @Param name path string true "Group name"
+// @Param id path string true "Trigger ID"
+// @Produce json
+// @Success	200 {object} portfolio.TriggerSettings
+// @Failure 400 {object} echo.HTTPError
+func (g *groupsController) pauseTrigger(ctx echo.Context) error {
+	return g.portfoliosController.pauseTrigger(ctx)
+}
+
+// resumeTrigger godoc
+// @Router /groups/:name/triggers/:id/resume [post]
+// @Summary Resume paused group trigger
+// @Tags Groups
+// @Param name path string true "Group name"
+// @Param id path string true "Trigger ID"
+// @Produce json
+// @Success	200 {object} portfolio.TriggerSettings
+// @Failure 400 {object} echo.HTTPError
+func (g *groupsController) resumeTrigger(ctx echo.Context) error {
+	return g.portfoliosController.resumeTrigger(ctx)
+}
+
+// getTriggerEvents godoc
+// @Router /groups/:name/trigger-events [get]
+// @Summary History of executed group triggers, the latest events go first
+// @Tags Groups
+// @Param name path string true "Group name"
+// @Param trigger_id query string false "Trigger ID"
+// @Param type query string false "Trigger type"
+// @Param from query int false "Start of time range (unix timestamp), default: 0"
+// @Param to query int false "End of time range (unix timestamp), default: now"
+// @Param limit query int false "Max amount of events, default: 100, max: 1000"
+// @Produce json
+// @Success	200 {array} portfolio.TriggerEvent
+// @Failure 400 {object} echo.HTTPError
+func (g *groupsController) getTriggerEvents(ctx echo.Context) error {
+	return g.portfoliosController.getTriggerEvents(ctx)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/egsam98/portfolio/pg"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
 	echoSwagger "github.com/swaggo/echo-swagger"
 	"gitlab.com/moderntoken/gateways/core"
 )
@@ -19,7 +20,7 @@ const (
 )
 
 // InitHandler setups REST API routes
-func InitHandler(secret []byte, pm *portfolio.Manager) http.Handler {
+func InitHandler(secret []byte, pm *portfolio.Manager) (http.Handler, error) {
 	r := echo.New()
 	r.Binder = &binder{}
 	r.HTTPErrorHandler = httpErrorHandler(r)
@@ -33,15 +34,39 @@ func InitHandler(secret []byte, pm *portfolio.Manager) http.Handler {
 	priv := r.Group("", middleware.JWT(secret))
 	ctrl := newPortfoliosController(pm)
 	priv.GET("/portfolios/:name/data", ctrl.getData)
+	priv.GET("/portfolios/:name/history", ctrl.getHistory)
 	priv.POST("/portfolios/:name/triggers", ctrl.addTriggers)
+	priv.GET("/portfolios/:name/triggers", ctrl.getTriggers)
+	priv.GET("/portfolios/:name/triggers/:id", ctrl.getTrigger)
+	priv.PATCH("/portfolios/:name/triggers/:id", ctrl.updateTrigger)
+	priv.DELETE("/portfolios/:name/triggers/:id", ctrl.deleteTrigger)
+	priv.POST("/portfolios/:name/triggers/:id/pause", ctrl.pauseTrigger)
+	priv.POST("/portfolios/:name/triggers/:id/resume", ctrl.resumeTrigger)
+	priv.GET("/portfolios/:name/trigger-events", ctrl.getTriggerEvents)
+
+	groups := newGroupsController(pm)
+	priv.GET("/groups", groups.getGroups)
+	priv.GET("/groups/:name/data", groups.getData)
+	priv.GET("/groups/:name/history", groups.getHistory)
+	priv.POST("/groups/:name/triggers", groups.addTriggers)
+	priv.GET("/groups/:name/triggers", groups.getTriggers)
+	priv.GET("/groups/:name/triggers/:id", groups.getTrigger)
+	priv.PATCH("/groups/:name/triggers/:id", groups.updateTrigger)
+	priv.DELETE("/groups/:name/triggers/:id", groups.deleteTrigger)
+	priv.POST("/groups/:name/triggers/:id/pause", groups.pauseTrigger)
+	priv.POST("/groups/:name/triggers/:id/resume", groups.resumeTrigger)
+	priv.GET("/groups/:name/trigger-events", groups.getTriggerEvents)
 
 	// API docs
-	r.GET("/swagger/*", echoSwagger.WrapHandler)
+	if err := registerSwagger(pm.Currencies()); err != nil {
+		return nil, errors.Wrap(err, "failed to register API docs")
+	}
+	r.GET("/swagger/*", echoSwagger.EchoWrapHandler(echoSwagger.InstanceName(swaggerInstanceName)))
 	r.GET("/docs", func(ctx echo.Context) error {
 		return ctx.Redirect(301, "/swagger/index.html")
 	})
 
-	return r
+	return r, nil
 }
 
 // InitHealthHandler setups health check /ready and /live http routes on returned http.Handler
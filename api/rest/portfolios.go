@@ -1,17 +1,20 @@
 package rest
 
 import (
+	"time"
+
 	"github.com/egsam98/portfolio/api/rest/requests"
 	"github.com/egsam98/portfolio/domain/portfolio"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 type portfoliosController struct {
-	pm *portfolio.Manager
+	portfolio func(name string) (*portfolio.Portfolio, error) // looks portfolio up by name from path
 }
 
 func newPortfoliosController(pm *portfolio.Manager) *portfoliosController {
-	return &portfoliosController{pm: pm}
+	return &portfoliosController{portfolio: pm.Portfolio}
 }
 
 // getData godoc
@@ -24,7 +27,7 @@ func newPortfoliosController(pm *portfolio.Manager) *portfoliosController {
 // @Failure 400 {object} echo.HTTPError
 func (p *portfoliosController) getData(ctx echo.Context) error {
 	name := ctx.Param("name")
-	portf, err := p.pm.Portfolio(name)
+	portf, err := p.portfolio(name)
 	if err != nil {
 		return err
 	}
@@ -36,16 +39,55 @@ func (p *portfoliosController) getData(ctx echo.Context) error {
 	return ctx.JSON(200, info)
 }
 
+// getHistory godoc
+// @Router /portfolios/:name/history [get]
+// @Summary Portfolio history downsampled by resolution: the last snapshot is taken for every resolution period
+// @Tags Portfolios
+// @Param name path string true "Portfolio name"
+// @Param from query int false "Start of time range (unix timestamp), default: to - 24h"
+// @Param to query int false "End of time range (unix timestamp), default: now"
+// @Param resolution query string false "Resolution as Go duration (ex. 5m, 1h), default: 1h"
+// @Produce json
+// @Success	200 {array} portfolio.Snapshot
+// @Failure 400 {object} echo.HTTPError
+func (p *portfoliosController) getHistory(ctx echo.Context) error {
+	now := time.Now()
+	req := requests.GetHistory{
+		From:       now.Add(-24 * time.Hour).Unix(),
+		To:         now.Unix(),
+		Resolution: "1h",
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+
+	portf, err := p.portfolio(ctx.Param("name"))
+	if err != nil {
+		return err
+	}
+
+	history, err := portf.History(
+		ctx.Request().Context(),
+		time.Unix(req.From, 0),
+		time.Unix(req.To, 0),
+		req.ResolutionDuration(),
+	)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(200, history)
+}
+
 // addTriggers godoc
 // @Router /portfolios/:name/triggers [post]
 // @Summary Add trigger to portfolio
 // @Tags Portfolios
 // @Param name path string true "Portfolio name"
-// @Param body body requests.AddTriggers true " "
+// @Param body body requests.AddTriggers true "Type-specific params (ex. limit, direction, low, high, percent, trailing_alert, asset, metric, symbol, side, indicator, targets, tolerance, window, operator, conditions, expression) are set along with type, currency, optional balance_basis (TOTAL, AVAILABLE, LOCKED), re-arm settings (cooldown_secs, hysteresis) supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert), schedule settings (active_from, expires_at, active_hours) and min_completeness of data quality"
 // @Accept json
 // @Produce json
 // @Success	200 {object} portfolio.TriggerSettings
-// @Failure 400 {object} echo.HTTPError
+// @Failure 400 {object} rest.ExpressionError "Invalid request. Column is set if expression is invalid"
 func (p *portfoliosController) addTriggers(ctx echo.Context) error {
 	name := ctx.Param("name")
 
@@ -54,23 +96,16 @@ func (p *portfoliosController) addTriggers(ctx echo.Context) error {
 		return err
 	}
 
-	portf, err := p.pm.Portfolio(name)
+	portf, err := p.portfolio(name)
 	if err != nil {
 		return err
 	}
 
-	triggers := make([]portfolio.Trigger, 0, len(req))
-	for _, elem := range req {
-		var trigger portfolio.Trigger
-		switch elem.Type {
-		case portfolio.CCBP:
-			trigger = portfolio.NewCostChangedByPercent(portf, elem.Currency, *elem.Percent, elem.TrailingAlert)
-		case portfolio.CRL:
-			trigger = portfolio.NewCostReachedLimit(portf, elem.Currency, *elem.Limit)
-		default:
-			continue
+	triggers := make([]portfolio.Trigger, len(req))
+	for i, elem := range req {
+		if triggers[i], err = portfolio.NewTrigger(portf, elem.Type, elem.Currency, elem.Params); err != nil {
+			return badRequest(err)
 		}
-		triggers = append(triggers, trigger)
 	}
 
 	settings, err := portf.AddTriggers(ctx.Request().Context(), triggers)
@@ -79,3 +114,192 @@ func (p *portfoliosController) addTriggers(ctx echo.Context) error {
 	}
 	return ctx.JSON(200, settings)
 }
+
+// getTriggers godoc
+// @Router /portfolios/:name/triggers [get]
+// @Summary Portfolio triggers
+// @Tags Portfolios
+// @Param name path string true "Portfolio name"
+// @Produce json
+// @Success	200 {array} portfolio.TriggerSettings
+// @Failure 400 {object} echo.HTTPError
+func (p *portfoliosController) getTriggers(ctx echo.Context) error {
+	portf, err := p.portfolio(ctx.Param("name"))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(200, portf.Triggers())
+}
+
+// getTrigger godoc
+// @Router /portfolios/:name/triggers/:id [get]
+// @Summary Portfolio trigger
+// @Tags Portfolios
+// @Param name path string true "Portfolio name"
+// @Param id path string true "Trigger ID"
+// @Produce json
+// @Success	200 {object} portfolio.TriggerSettings
+// @Failure 400 {object} echo.HTTPError
+func (p *portfoliosController) getTrigger(ctx echo.Context) error {
+	id, err := triggerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	portf, err := p.portfolio(ctx.Param("name"))
+	if err != nil {
+		return err
+	}
+
+	settings, err := portf.Trigger(id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(200, settings)
+}
+
+// updateTrigger godoc
+// @Router /portfolios/:name/triggers/:id [patch]
+// @Summary Update portfolio trigger
+// @Tags Portfolios
+// @Param name path string true "Portfolio name"
+// @Param id path string true "Trigger ID"
+// @Param body body object true "Type-specific trigger params (ex. limit, direction, low, high, percent, trailing_alert, asset, metric, symbol, side, indicator, targets, tolerance, window, operator, conditions, expression), re-arm settings (cooldown_secs, hysteresis) supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert), schedule settings (active_from, expires_at, active_hours) and min_completeness (null removes setting) to be changed"
+// @Accept json
+// @Produce json
+// @Success	200 {object} portfolio.TriggerSettings
+// @Failure 400 {object} rest.ExpressionError "Invalid request. Column is set if expression is invalid"
+func (p *portfoliosController) updateTrigger(ctx echo.Context) error {
+	id, err := triggerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req requests.UpdateTrigger
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+
+	portf, err := p.portfolio(ctx.Param("name"))
+	if err != nil {
+		return err
+	}
+
+	settings, err := portf.UpdateTrigger(ctx.Request().Context(), id, req.Params)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(200, settings)
+}
+
+// deleteTrigger godoc
+// @Router /portfolios/:name/triggers/:id [delete]
+// @Summary Delete portfolio trigger
+// @Tags Portfolios
+// @Param name path string true "Portfolio name"
+// @Param id path string true "Trigger ID"
+// @Success	204
+// @Failure 400 {object} echo.HTTPError
+func (p *portfoliosController) deleteTrigger(ctx echo.Context) error {
+	id, err := triggerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	portf, err := p.portfolio(ctx.Param("name"))
+	if err != nil {
+		return err
+	}
+
+	if err := portf.DeleteTrigger(ctx.Request().Context(), id); err != nil {
+		return err
+	}
+	return ctx.NoContent(204)
+}
+
+// pauseTrigger godoc
+// @Router /portfolios/:name/triggers/:id/pause [post]
+// @Summary Pause portfolio trigger
+// @Tags Portfolios
+// @Param name path string true "Portfolio name"
+// @Param id path string true "Trigger ID"
+// @Produce json
+// @Success	200 {object} portfolio.TriggerSettings
+// @Failure 400 {object} echo.HTTPError
+func (p *portfoliosController) pauseTrigger(ctx echo.Context) error {
+	return p.setTriggerPaused(ctx, true)
+}
+
+// resumeTrigger godoc
+// @Router /portfolios/:name/triggers/:id/resume [post]
+// @Summary Resume paused portfolio trigger
+// @Tags Portfolios
+// @Param name path string true "Portfolio name"
+// @Param id path string true "Trigger ID"
+// @Produce json
+// @Success	200 {object} portfolio.TriggerSettings
+// @Failure 400 {object} echo.HTTPError
+func (p *portfoliosController) resumeTrigger(ctx echo.Context) error {
+	return p.setTriggerPaused(ctx, false)
+}
+
+// getTriggerEvents godoc
+// @Router /portfolios/:name/trigger-events [get]
+// @Summary History of executed portfolio triggers, the latest events go first
+// @Tags Portfolios
+// @Param name path string true "Portfolio name"
+// @Param trigger_id query string false "Trigger ID"
+// @Param type query string false "Trigger type"
+// @Param from query int false "Start of time range (unix timestamp), default: 0"
+// @Param to query int false "End of time range (unix timestamp), default: now"
+// @Param limit query int false "Max amount of events, default: 100, max: 1000"
+// @Produce json
+// @Success	200 {array} portfolio.TriggerEvent
+// @Failure 400 {object} echo.HTTPError
+func (p *portfoliosController) getTriggerEvents(ctx echo.Context) error {
+	req := requests.GetTriggerEvents{
+		To:    time.Now().Unix(),
+		Limit: 100,
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return err
+	}
+
+	portf, err := p.portfolio(ctx.Param("name"))
+	if err != nil {
+		return err
+	}
+
+	events, err := portf.TriggerEvents(ctx.Request().Context(), req.Filter())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(200, events)
+}
+
+func (p *portfoliosController) setTriggerPaused(ctx echo.Context, paused bool) error {
+	id, err := triggerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	portf, err := p.portfolio(ctx.Param("name"))
+	if err != nil {
+		return err
+	}
+
+	settings, err := portf.SetTriggerPaused(ctx.Request().Context(), id, paused)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(200, settings)
+}
+
+// triggerID parses trigger ID from path
+func triggerID(ctx echo.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, echo.NewHTTPError(400, "invalid trigger ID: "+err.Error())
+	}
+	return id, nil
+}
@@ -1,38 +1,163 @@
 package requests
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/egsam98/portfolio/domain/portfolio"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
-	"gitlab.com/moderntoken/gateways/decimal"
 )
 
-type AddTriggers []struct {
-	Type          portfolio.TriggerType `json:"type" validate:"required" swaggertype:"string" enums:"COST_REACHED_LIMIT,COST_CHANGED_BY_PERCENT"`
-	Currency      portfolio.Currency    `json:"currency" validate:"required" swaggertype:"string" enums:"USDT,BTC"`
-	TrailingAlert bool                  `json:"trailing_alert"`
-	Limit         *decimal.Decimal      `json:"limit"`
-	Percent       *decimal.Decimal      `json:"percent"`
-}
+type AddTriggers []AddTrigger
 
 func (a AddTriggers) Validate() error {
 	for _, t := range a {
-		switch t.Type {
-		case 0:
-			return errors.New("trigger type is required")
-		case portfolio.CRL:
-			if t.Limit == nil || t.Limit.IsZero() {
-				return errors.Errorf("limit is required for %q trigger type", t.Type)
-			}
-		case portfolio.CCBP:
-			if t.Percent == nil || t.Percent.IsZero() {
-				return errors.Errorf("percent is required for %q trigger type", t.Type)
-			}
+		if err := t.Validate(); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		if t.Currency == 0 {
-			return errors.New("currency is required")
-		}
+// AddTrigger holds trigger type, currency, balance basis, re-arm, schedule and quality gate settings and type-specific params (ex. limit, percent)
+// within the same JSON object
+type AddTrigger struct {
+	portfolio.Rearm
+	portfolio.Schedule
+	portfolio.QualityGate
+	Type     portfolio.TriggerType `json:"type" validate:"required" swaggertype:"string"`
+	Currency portfolio.Currency    `json:"currency" validate:"required" swaggertype:"string"`
+	// Basis is a part of balances trigger evaluates, TOTAL by default
+	Basis  portfolio.BalanceBasis `json:"balance_basis,omitempty" swaggertype:"string" enums:"TOTAL,AVAILABLE,LOCKED"`
+	Params json.RawMessage        `json:"-"`
+}
+
+func (a *AddTrigger) UnmarshalJSON(data []byte) error {
+	type addTrigger AddTrigger
+	if err := json.Unmarshal(data, (*addTrigger)(a)); err != nil {
+		return err
 	}
+	a.Params = append(a.Params[:0], data...)
+	return nil
+}
 
+func (a AddTrigger) Validate() error {
+	if a.Type == 0 {
+		return errors.New("trigger type is required")
+	}
+	if a.Currency == "" {
+		return errors.New("currency is required")
+	}
+	if err := a.Rearm.Validate(); err != nil {
+		return err
+	}
+	if err := a.Schedule.Validate(); err != nil {
+		return err
+	}
+	if err := a.QualityGate.Validate(); err != nil {
+		return err
+	}
+	if a.ExpiresAt != nil && *a.ExpiresAt <= time.Now().Unix() {
+		return errors.New("expires_at must be in the future")
+	}
+	_, err := portfolio.DecodeTriggerParams(a.Type, a.Params)
+	return err
+}
+
+// UpdateTrigger holds JSON object of trigger's params to be changed. Absent params are left as is
+type UpdateTrigger struct {
+	Params json.RawMessage
+}
+
+func (u *UpdateTrigger) UnmarshalJSON(data []byte) error {
+	u.Params = append(u.Params[:0], data...)
 	return nil
 }
+
+func (u UpdateTrigger) Validate() error {
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(u.Params, &params); err != nil {
+		return errors.Wrap(err, "params must be JSON object")
+	}
+	if len(params) == 0 {
+		return errors.New("at least one param is required")
+	}
+	return nil
+}
+
+// MaxHistoryPoints limits amount of points returned by GetHistory query
+const MaxHistoryPoints = 10000
+
+type GetHistory struct {
+	From       int64  `query:"from"`
+	To         int64  `query:"to"`
+	Resolution string `query:"resolution"`
+}
+
+func (g GetHistory) Validate() error {
+	if g.From <= 0 || g.To <= 0 {
+		return errors.New("from and to must be positive timestamps")
+	}
+	if g.From >= g.To {
+		return errors.New("from must be less than to")
+	}
+
+	resolution, err := time.ParseDuration(g.Resolution)
+	if err != nil {
+		return errors.Wrapf(err, "invalid resolution %q", g.Resolution)
+	}
+	if resolution < time.Second {
+		return errors.New("resolution must be at least 1s")
+	}
+	if points := (g.To - g.From) / int64(resolution/time.Second); points > MaxHistoryPoints {
+		return errors.Errorf("too many points requested: %d, max: %d", points, MaxHistoryPoints)
+	}
+	return nil
+}
+
+// ResolutionDuration returns parsed resolution. It's supposed to be called after successful validation
+func (g GetHistory) ResolutionDuration() time.Duration {
+	resolution, _ := time.ParseDuration(g.Resolution)
+	return resolution
+}
+
+// MaxTriggerEventsLimit limits amount of events returned by GetTriggerEvents query
+const MaxTriggerEventsLimit = 1000
+
+type GetTriggerEvents struct {
+	TriggerID uuid.UUID             `query:"trigger_id"`
+	Type      portfolio.TriggerType `query:"type"`
+	From      int64                 `query:"from"`
+	To        int64                 `query:"to"`
+	Limit     int                   `query:"limit"`
+}
+
+func (g GetTriggerEvents) Validate() error {
+	if g.From < 0 || g.To <= 0 {
+		return errors.New("from and to must be positive timestamps")
+	}
+	if g.From >= g.To {
+		return errors.New("from must be less than to")
+	}
+	if g.Limit < 1 || g.Limit > MaxTriggerEventsLimit {
+		return errors.Errorf("limit must be within [1, %d]", MaxTriggerEventsLimit)
+	}
+	return nil
+}
+
+// Filter converts query into portfolio.TriggerEventsFilter
+func (g GetTriggerEvents) Filter() portfolio.TriggerEventsFilter {
+	filter := portfolio.TriggerEventsFilter{
+		From:  time.Unix(g.From, 0),
+		To:    time.Unix(g.To, 0),
+		Limit: g.Limit,
+	}
+	if g.TriggerID != uuid.Nil {
+		filter.TriggerID = &g.TriggerID
+	}
+	if g.Type != 0 {
+		filter.Type = &g.Type
+	}
+	return filter
+}
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"github.com/egsam98/portfolio/domain"
+	"github.com/egsam98/portfolio/domain/portfolio/expr"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 )
@@ -15,13 +16,20 @@ type validatable interface {
 	Validate() error
 }
 
+// ExpressionError is a body of 400 response if request contains invalid expression.
+// Column is a 1-based position of error in expression
+type ExpressionError struct {
+	Message string `json:"message"`
+	Column  int    `json:"column"`
+}
+
 func (b *binder) Bind(i interface{}, ctx echo.Context) error {
 	if err := b.DefaultBinder.Bind(i, ctx); err != nil {
 		return err
 	}
 	if v, ok := i.(validatable); ok {
 		if err := v.Validate(); err != nil {
-			return echo.NewHTTPError(400, err.Error())
+			return badRequest(err)
 		}
 	}
 	return nil
@@ -30,8 +38,17 @@ func (b *binder) Bind(i interface{}, ctx echo.Context) error {
 func httpErrorHandler(e *echo.Echo) echo.HTTPErrorHandler {
 	return func(err error, ctx echo.Context) {
 		if errors.As(err, new(domain.Error)) {
-			err = echo.NewHTTPError(400, err.Error())
+			err = badRequest(err)
 		}
 		e.DefaultHTTPErrorHandler(err, ctx)
 	}
 }
+
+// badRequest returns 400 error. Its body is ExpressionError if err is caused by invalid expression
+func badRequest(err error) *echo.HTTPError {
+	var exprErr *expr.Error
+	if errors.As(err, &exprErr) {
+		return echo.NewHTTPError(400, ExpressionError{Message: err.Error(), Column: exprErr.Pos + 1})
+	}
+	return echo.NewHTTPError(400, err.Error())
+}
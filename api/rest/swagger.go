@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/swaggo/swag"
+
+	"github.com/egsam98/portfolio/api/rest/docs"
+	"github.com/egsam98/portfolio/domain/portfolio"
+)
+
+// swaggerInstanceName is a name of API docs with configured currencies
+const swaggerInstanceName = "portfolio"
+
+// currencyDocs are generated API docs where enums of currency fields are replaced by configured currencies.
+// Enums of trigger types are replaced by registered ones, so types plugged into registry are documented also
+type currencyDocs struct {
+	doc string
+}
+
+// registerSwagger registers currencyDocs as swaggerInstanceName. Docs are built once since config isn't changed at runtime
+func registerSwagger(currencies []portfolio.Currency) error {
+	var spec map[string]interface{}
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &spec); err != nil {
+		return err
+	}
+	enum := make([]interface{}, len(currencies))
+	for i, cur := range currencies {
+		enum[i] = cur.String()
+	}
+	replaceCurrencyEnums(spec, enum)
+
+	var types, groupTypes []interface{}
+	for _, typ := range portfolio.TriggerTypes() {
+		types = append(types, typ.String())
+		if !typ.Gateway() {
+			groupTypes = append(groupTypes, typ.String())
+		}
+	}
+	replaceTriggerTypeEnums(spec, types)
+	// Groups don't support trigger types evaluating gateway's instruments
+	if paths, ok := spec["paths"].(map[string]interface{}); ok {
+		for path, value := range paths {
+			if strings.HasPrefix(path, "/groups/") {
+				replaceTriggerTypeEnums(value, groupTypes)
+			}
+		}
+	}
+
+	doc, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	swag.Register(swaggerInstanceName, &currencyDocs{doc: string(doc)})
+	return nil
+}
+
+func (c *currencyDocs) ReadDoc() string {
+	return c.doc
+}
+
+// replaceCurrencyEnums walks through JSON node and sets enum of every "currency" property.
+// Annotations don't list currencies, so config is the only source of them
+func replaceCurrencyEnums(node interface{}, enum []interface{}) {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		for key, value := range node {
+			if schema, ok := value.(map[string]interface{}); ok && key == "currency" {
+				schema["enum"] = enum
+			}
+			replaceCurrencyEnums(value, enum)
+		}
+	case []interface{}:
+		for _, value := range node {
+			replaceCurrencyEnums(value, enum)
+		}
+	}
+}
+
+// replaceTriggerTypeEnums walks through JSON node and sets enum of every "type" property and "type" query param.
+// Annotations don't list trigger types, so registry is the only source of them
+func replaceTriggerTypeEnums(node interface{}, enum []interface{}) {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		if node["name"] == "type" && node["in"] == "query" {
+			node["enum"] = enum
+		}
+		for key, value := range node {
+			// "type" keyword of JSON schema is a string, so only "type" properties are objects
+			if schema, ok := value.(map[string]interface{}); ok && key == "type" {
+				schema["enum"] = enum
+			}
+			replaceTriggerTypeEnums(value, enum)
+		}
+	case []interface{}:
+		for _, value := range node {
+			replaceTriggerTypeEnums(value, enum)
+		}
+	}
+}
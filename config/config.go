@@ -11,8 +11,10 @@ import (
 )
 
 const (
-	DefaultLogLevel      = "debug"
-	DefaultJWTSecretPath = "secret.pem"
+	DefaultLogLevel          = "debug"
+	DefaultJWTSecretPath     = "secret.pem"
+	DefaultPriceDebounceSecs = 3
+	DefaultPriceMaxAgeSecs   = 300
 )
 
 // Config holds parsed config params from YAML by Viper
@@ -32,18 +34,8 @@ type Config struct {
 			WSHost   string `yaml:"ws_host"`
 		} `yaml:"binance"`
 	} `yaml:"paper"`
-	Fees struct {
-		Binance        Fees `yaml:"binance"`
-		BinanceFutures Fees `yaml:"binance_futures"`
-		Kraken         Fees `yaml:"kraken"`
-		BybitSpot      Fees `yaml:"bybit_spot"`
-		BybitUSDTPerp  Fees `yaml:"bybit_usdt_perp"`
-		Coinbase       Fees `yaml:"coinbase"`
-		Huobi          Fees `yaml:"huobi"`
-		Okex           Fees `yaml:"okex"`
-		HitBTC         Fees `yaml:"hitbtc"`
-		BinanceUS      Fees `yaml:"binance_us"`
-	} `yaml:"fees"`
+	// Fees are commissions of exchanges by names of gateways
+	Fees  map[string]Fees `yaml:"fees"`
 	Proxy []struct {
 		Auth  string   `yaml:"auth"`
 		Hosts []string `yaml:"hosts"`
@@ -64,6 +56,44 @@ type Config struct {
 		Password string `yaml:"password"`
 		DB       int    `yaml:"db"`
 	} `yaml:"redis"`
+	Portfolio struct {
+		PriceDebounceSecs int `yaml:"price_debounce_secs"`
+		// PriceMaxAgeSecs is a period price stays OK within since the last update of its instruments, it's STALE afterwards
+		PriceMaxAgeSecs int `yaml:"price_max_age_secs"`
+		History         struct {
+			Enabled      bool `yaml:"enabled"`
+			IntervalSecs int  `yaml:"interval_secs"`
+		} `yaml:"history"`
+		// Currencies are quote currencies all portfolios are converted to (USDT, BTC by default)
+		Currencies []string `yaml:"currencies"`
+		// Pricing is a policy prices are calculated by: ASK (default), BID, MID or LIQUIDATION (BID after taker Fees)
+		Pricing string `yaml:"pricing"`
+		// FX converts USDT values into Fiat currencies listed in Currencies (Portfolios[].Currencies).
+		// Rates are fetched from URL (HTTP JSON) or File if URL is empty
+		FX struct {
+			Fiat         []string `yaml:"fiat"`
+			URL          string   `yaml:"url"`
+			File         string   `yaml:"file"`
+			IntervalSecs int      `yaml:"interval_secs"`
+			MaxAgeSecs   int      `yaml:"max_age_secs"`
+		} `yaml:"fx"`
+		// Normalization maps names of currencies of Gateway onto canonical ones: Aliases (ex. XBT, XXBT onto BTC) and
+		// Prefixes of currencies counted as underlying ones (ex. LD-prefixed earn tokens)
+		Normalization []struct {
+			Gateway string `yaml:"gateway"`
+			Aliases []struct {
+				From []string `yaml:"from"`
+				To   string   `yaml:"to"`
+			} `yaml:"aliases"`
+			Prefixes []string `yaml:"prefixes"`
+		} `yaml:"normalization"`
+		// Portfolios override settings of certain portfolios by account names
+		Portfolios []struct {
+			Name       string   `yaml:"name"`
+			Currencies []string `yaml:"currencies"`
+			Pricing    string   `yaml:"pricing"`
+		} `yaml:"portfolios"`
+	} `yaml:"portfolio"`
 	JWTSecretPath string `yaml:"jwt_secret_path"`
 }
 
@@ -83,6 +113,8 @@ func Load(configPath string) (*Config, error) {
 	viper.SetDefault("bolt.live", true)
 	viper.SetDefault("db.live", true)
 	viper.SetDefault("jwt_secret_path", DefaultJWTSecretPath)
+	viper.SetDefault("portfolio.price_debounce_secs", DefaultPriceDebounceSecs)
+	viper.SetDefault("portfolio.price_max_age_secs", DefaultPriceMaxAgeSecs)
 
 	if configPath != "" {
 		viper.SetConfigFile(configPath)
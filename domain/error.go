@@ -6,3 +6,34 @@ type Error string
 func (e Error) Error() string {
 	return string(e)
 }
+
+// Wrap classifies err as client-side error e keeping err in chain, so both e and err are matched by errors.Is/As
+// (ex. position of invalid expression)
+func (e Error) Wrap(err error) error {
+	return &wrappedError{kind: e, err: err}
+}
+
+type wrappedError struct {
+	kind Error
+	err  error
+}
+
+func (w *wrappedError) Error() string {
+	return string(w.kind) + ": " + w.err.Error()
+}
+
+func (w *wrappedError) Unwrap() error {
+	return w.err
+}
+
+func (w *wrappedError) Is(target error) bool {
+	return target == w.kind
+}
+
+func (w *wrappedError) As(target interface{}) bool {
+	if kind, ok := target.(*Error); ok {
+		*kind = w.kind
+		return true
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package fx
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// FileProvider reads Rates from JSON file of the same format as HTTPProvider does.
+// File is read on every fetch, so it may be edited to stub rates locally. Modification time is used if timestamp is absent
+type FileProvider struct {
+	path string
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+func (f *FileProvider) Rates(context.Context) (Rates, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return Rates{}, errors.Wrapf(err, "failed to read rates from %s", f.path)
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return Rates{}, errors.Wrapf(err, "failed to read rates from %s", f.path)
+	}
+	return decodeRates(data, info.ModTime())
+}
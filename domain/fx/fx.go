@@ -0,0 +1,124 @@
+// Package fx provides rates of fiat currencies used to convert portfolio's USDT values into fiat
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+const (
+	// DefaultPollInterval is a default period rates are fetched from Provider within
+	DefaultPollInterval = time.Minute
+	// DefaultMaxAge is a default age rates are considered stale after
+	DefaultMaxAge = 10 * time.Minute
+)
+
+// Rates are amounts of fiat currencies per 1 USDT at UpdatedAt time, ex. EUR: 0.92
+type Rates struct {
+	Values    map[string]decimal.Decimal
+	UpdatedAt time.Time
+}
+
+// Provider fetches the latest Rates
+type Provider interface {
+	Rates(ctx context.Context) (Rates, error)
+}
+
+// ratesJSON is a JSON presentation of Rates shared by HTTPProvider and FileProvider:
+// {"timestamp": 1654586492, "rates": {"EUR": 0.92, "USD": 1}}. Fetch time is used if timestamp is absent
+type ratesJSON struct {
+	Timestamp int64                      `json:"timestamp"`
+	Rates     map[string]decimal.Decimal `json:"rates"`
+}
+
+func decodeRates(data []byte, fetchedAt time.Time) (Rates, error) {
+	var raw ratesJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Rates{}, errors.Wrapf(err, "failed to unmarshal %s into %T", string(data), raw)
+	}
+	if len(raw.Rates) == 0 {
+		return Rates{}, errors.New("rates are empty")
+	}
+	rates := Rates{Values: raw.Rates, UpdatedAt: fetchedAt}
+	if raw.Timestamp > 0 {
+		rates.UpdatedAt = time.Unix(raw.Timestamp, 0)
+	}
+	return rates, nil
+}
+
+// Poller fetches Rates from Provider periodically and keeps the latest ones
+type Poller struct {
+	provider Provider
+	interval time.Duration
+	maxAge   time.Duration
+	now      func() time.Time // clock, it's replaced in tests
+	mu       sync.RWMutex
+	rates    Rates
+	err      error // error of the last fetch
+	logger   zerolog.Logger
+}
+
+// NewPoller creates Poller. DefaultPollInterval and DefaultMaxAge are used if interval and maxAge are zero
+func NewPoller(provider Provider, interval, maxAge time.Duration) *Poller {
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
+	}
+	return &Poller{
+		provider: provider,
+		interval: interval,
+		maxAge:   maxAge,
+		now:      time.Now,
+		logger: log.Logger.With().
+			Str("namespace", "fx_poller").
+			Logger(),
+	}
+}
+
+// Start fetches rates immediately and then periodically in goroutine until ctx is done
+func (p *Poller) Start(ctx context.Context) {
+	p.poll(ctx)
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.poll(ctx)
+			}
+		}
+	}()
+}
+
+// Latest returns the latest Rates. Stale is true if rates are older than max age or the last fetch failed
+func (p *Poller) Latest() (rates Rates, stale bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	stale = p.err != nil || p.rates.Values == nil || p.now().Sub(p.rates.UpdatedAt) > p.maxAge
+	return p.rates, stale
+}
+
+func (p *Poller) poll(ctx context.Context) {
+	rates, err := p.provider.Rates(ctx)
+	if err != nil {
+		p.logger.Error().Stack().Err(err).Msg("Failed to fetch FX rates")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+	if err == nil {
+		p.rates = rates
+	}
+}
@@ -0,0 +1,86 @@
+package fx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+func TestHTTPProvider_Rates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rates" {
+			w.WriteHeader(404)
+			return
+		}
+		_, _ = w.Write([]byte(`{"timestamp": 1654586492, "rates": {"EUR": 0.92, "USD": "1"}}`))
+	}))
+	defer srv.Close()
+
+	rates, err := NewHTTPProvider(srv.URL + "/rates").Rates(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Unix(1654586492, 0), rates.UpdatedAt)
+		assert.True(t, decimal.NewDecimal(92, 2).Eq(rates.Values["EUR"]))
+		assert.True(t, decimal.NewDecimal(1, 0).Eq(rates.Values["USD"]))
+	}
+
+	_, err = NewHTTPProvider(srv.URL + "/unknown").Rates(context.Background())
+	assert.Error(t, err)
+}
+
+func TestFileProvider_Rates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fx.json")
+	if err := os.WriteFile(path, []byte(`{"rates": {"EUR": 0.92}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+
+	rates, err := NewFileProvider(path).Rates(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, info.ModTime(), rates.UpdatedAt)
+		assert.True(t, decimal.NewDecimal(92, 2).Eq(rates.Values["EUR"]))
+	}
+
+	_, err = NewFileProvider(filepath.Join(t.TempDir(), "unknown.json")).Rates(context.Background())
+	assert.Error(t, err)
+}
+
+type providerFunc func() (Rates, error)
+
+func (f providerFunc) Rates(context.Context) (Rates, error) {
+	return f()
+}
+
+func TestPoller_Latest(t *testing.T) {
+	now := time.Unix(1654586492, 0)
+	var fetchErr error
+	poller := NewPoller(providerFunc(func() (Rates, error) {
+		return Rates{Values: map[string]decimal.Decimal{"EUR": decimal.NewDecimal(92, 2)}, UpdatedAt: now}, fetchErr
+	}), time.Hour, time.Minute)
+	poller.now = func() time.Time { return now }
+
+	_, stale := poller.Latest()
+	assert.True(t, stale, "before first fetch")
+
+	poller.poll(context.Background())
+	rates, stale := poller.Latest()
+	assert.False(t, stale)
+	assert.Contains(t, rates.Values, "EUR")
+
+	now = now.Add(2 * time.Minute)
+	_, stale = poller.Latest()
+	assert.True(t, stale, "when rates are too old")
+
+	fetchErr = errors.New("test")
+	poller.poll(context.Background())
+	rates, stale = poller.Latest()
+	assert.True(t, stale, "when fetch failed")
+	assert.Contains(t, rates.Values, "EUR", "the latest rates are kept")
+}
@@ -0,0 +1,48 @@
+package fx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultHTTPTimeout limits a duration of rates request
+const DefaultHTTPTimeout = 10 * time.Second
+
+// HTTPProvider fetches Rates by GET request to URL responding JSON object:
+// {"timestamp": 1654586492, "rates": {"EUR": 0.92, "USD": 1}}
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPProvider(url string) *HTTPProvider {
+	return &HTTPProvider{
+		url:    url,
+		client: &http.Client{Timeout: DefaultHTTPTimeout},
+	}
+}
+
+func (h *HTTPProvider) Rates(ctx context.Context) (Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return Rates{}, errors.WithStack(err)
+	}
+	res, err := h.client.Do(req)
+	if err != nil {
+		return Rates{}, errors.Wrapf(err, "failed to request rates from %s", h.url)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return Rates{}, errors.Wrapf(err, "failed to read rates from %s", h.url)
+	}
+	if res.StatusCode != http.StatusOK {
+		return Rates{}, errors.Errorf("failed to request rates from %s: status %d: %s", h.url, res.StatusCode, string(data))
+	}
+	return decodeRates(data, time.Now())
+}
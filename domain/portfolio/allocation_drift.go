@@ -0,0 +1,146 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+var AD = RegisterTriggerType(TriggerKind{
+	Name: "ALLOCATION_DRIFT",
+	Decode: func(data []byte) (TriggerParams, error) {
+		params := new(AllocationDriftParams)
+		err := json.Unmarshal(data, params)
+		return params, errors.WithStack(err)
+	},
+	New: func(portf *Portfolio, currency Currency, basis BalanceBasis, params TriggerParams) (Trigger, error) {
+		return &AllocationDrift{
+			triggerBase: newTriggerBase(portf, currency, basis),
+			params:      *params.(*AllocationDriftParams),
+		}, nil
+	},
+	Restore: func(portf *Portfolio, state TriggerState, params TriggerParams) (Trigger, error) {
+		return &AllocationDrift{
+			triggerBase: triggerBase{portf: portf, state: state},
+			params:      *params.(*AllocationDriftParams),
+		}, nil
+	},
+})
+
+// AllocationDriftParams
+// Targets are target weights of assets in % of portfolio's total cost, their sum must be 100. Held assets absent
+// in Targets have zero target weight.
+// Tolerance is a max allowed deviation of asset's weight from its target in percentage points
+type AllocationDriftParams struct {
+	Targets   map[core.Currency]decimal.Decimal `json:"targets"`
+	Tolerance decimal.Decimal                   `json:"tolerance"`
+}
+
+func (a *AllocationDriftParams) Validate() error {
+	if len(a.Targets) == 0 {
+		return errors.New("targets are required")
+	}
+
+	var sum decimal.Decimal
+	for cur, target := range a.Targets {
+		if target.LessThan(decimal.Decimal{}) || decimal.NewDecimal(100, 0).LessThan(target) {
+			return errors.Errorf("target of %s must be within [0, 100]", cur)
+		}
+		sum = sum.Add(target)
+	}
+	if !sum.Eq(decimal.NewDecimal(100, 0)) {
+		return errors.Errorf("sum of targets must be 100, got %s", sum)
+	}
+
+	if !decimal.NewDecimal(0, 0).LessThan(a.Tolerance) {
+		return errors.New("tolerance must be positive")
+	}
+	return nil
+}
+
+// AssetWeight is a current weight of asset in % of portfolio's total cost along with its target
+type AssetWeight struct {
+	Asset  core.Currency   `json:"asset"`
+	Weight decimal.Decimal `json:"weight"`
+	Target decimal.Decimal `json:"target"`
+}
+
+// AllocationDriftDetails are reported in TriggerEvent of AllocationDrift
+type AllocationDriftDetails struct {
+	Assets []AssetWeight `json:"assets"`
+}
+
+// AllocationDrift is a trigger executing when any asset's weight in portfolio's total cost deviates from target weight
+// more than tolerance. Weights are calculated of values converted to trigger's currency
+type AllocationDrift struct {
+	triggerBase
+	params AllocationDriftParams
+}
+
+// TryExecute returns non-empty ExecutionStatus if trigger is executed.
+// ExecutionStatus.CurrentValue is a max deviation in percentage points, ExecutionStatus.Details are offending assets.
+// ExecutionStatus.Done is always equal to ExecutionStatus.Ok for this type of trigger
+func (a *AllocationDrift) TryExecute() (*ExecutionStatus, error) {
+	total := a.totalBalance()
+	if total.IsZero() {
+		return &ExecutionStatus{}, nil
+	}
+
+	weights := make(map[core.Currency]decimal.Decimal)
+	for cur := range a.params.Targets {
+		weights[cur] = decimal.Decimal{}
+	}
+	for cur, asset := range a.portf.dataHolder.Assets() {
+		weights[cur] = asset.basis(a.state.Basis).Value[a.state.Currency].Div(total).MulFloat(100)
+	}
+
+	var maxDeviation decimal.Decimal
+	var offending []AssetWeight
+	for cur, weight := range weights {
+		target := a.params.Targets[cur]
+		deviation := weight.Sub(target).Abs()
+		if maxDeviation.LessThan(deviation) {
+			maxDeviation = deviation
+		}
+		if a.params.Tolerance.LessThan(deviation) {
+			offending = append(offending, AssetWeight{
+				Asset:  cur,
+				Weight: weight,
+				Target: target,
+			})
+		}
+	}
+	sort.Slice(offending, func(i, j int) bool {
+		return offending[i].Asset < offending[j].Asset
+	})
+
+	ok := len(offending) > 0
+	status := &ExecutionStatus{
+		Ok:           ok,
+		Done:         ok,
+		CurrentValue: maxDeviation,
+	}
+	if ok {
+		status.Details = AllocationDriftDetails{Assets: offending}
+	}
+	return status, nil
+}
+
+func (a *AllocationDrift) Settings() TriggerSettings {
+	params := a.params
+	return a.settings(AD, &params)
+}
+
+// Update returns copy of trigger with patched params. Patched targets are merged with existing ones
+func (a *AllocationDrift) Update(patch json.RawMessage) (Trigger, error) {
+	params, err := patchTriggerParams(AD, &a.params, patch)
+	if err != nil {
+		return nil, err
+	}
+	updated := *a
+	updated.params = *params.(*AllocationDriftParams)
+	return &updated, nil
+}
@@ -0,0 +1,48 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+func TestAllocationDrift_TryExecute(t *testing.T) {
+	portf := NewPortfolio(0, "", nil, nil, nil, nil, Config{})
+	setAssets := func(values map[core.Currency]int64) {
+		var total int64
+		for cur, value := range values {
+			portf.dataHolder.assets[cur] = Asset{AssetBalance: AssetBalance{Total: BalanceValue{Value: ConvertedTo{USDT: decimal.NewDecimal(value, 0)}}}}
+			total += value
+		}
+		portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(total, 0)
+	}
+
+	trigger, err := NewTrigger(portf, AD, USDT, []byte(`{"targets":{"BTC":"60","ETH":"40"},"tolerance":"5"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	setAssets(map[core.Currency]int64{"BTC": 6200, "ETH": 3800})
+	status, err := trigger.TryExecute()
+	assert.NoError(t, err)
+	assert.False(t, status.Ok)
+
+	setAssets(map[core.Currency]int64{"BTC": 5800, "ETH": 2200, "SOL": 2000})
+	status, err = trigger.TryExecute()
+	assert.NoError(t, err)
+	assert.True(t, status.Ok)
+	assert.True(t, decimal.NewDecimal(20, 0).Eq(status.CurrentValue))
+	if details, ok := status.Details.(AllocationDriftDetails); assert.True(t, ok) && assert.Len(t, details.Assets, 2) {
+		assert.Equal(t, core.Currency("ETH"), details.Assets[0].Asset)
+		assert.True(t, decimal.NewDecimal(22, 0).Eq(details.Assets[0].Weight))
+		assert.Equal(t, core.Currency("SOL"), details.Assets[1].Asset)
+		assert.True(t, decimal.NewDecimal(20, 0).Eq(details.Assets[1].Weight))
+	}
+
+	t.Run("when targets don't sum up to 100", func(t *testing.T) {
+		_, err := NewTrigger(portf, AD, USDT, []byte(`{"targets":{"BTC":"60"},"tolerance":"5"}`))
+		assert.Error(t, err)
+	})
+}
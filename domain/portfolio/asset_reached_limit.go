@@ -0,0 +1,130 @@
+package portfolio
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+var ARL = RegisterTriggerType(TriggerKind{
+	Name: "ASSET_REACHED_LIMIT",
+	Decode: func(data []byte) (TriggerParams, error) {
+		params := new(AssetReachedLimitParams)
+		err := json.Unmarshal(data, params)
+		return params, errors.WithStack(err)
+	},
+	New: func(portf *Portfolio, currency Currency, basis BalanceBasis, params TriggerParams) (Trigger, error) {
+		a := &AssetReachedLimit{
+			triggerBase: newTriggerBase(portf, currency, basis),
+			params:      *params.(*AssetReachedLimitParams),
+		}
+		value := a.value()
+		a.lastValue = &value
+		return a, nil
+	},
+	Restore: func(portf *Portfolio, state TriggerState, params TriggerParams) (Trigger, error) {
+		return &AssetReachedLimit{
+			triggerBase: triggerBase{portf: portf, state: state},
+			params:      *params.(*AssetReachedLimitParams),
+		}, nil
+	},
+})
+
+type AssetMetric uint8
+
+const (
+	AssetValue AssetMetric = iota + 1
+	AssetQuantity
+)
+
+var (
+	assetMetricKeyValues = map[AssetMetric]string{
+		AssetValue:    "VALUE",
+		AssetQuantity: "QUANTITY",
+	}
+	assetMetricValueKeys = map[string]AssetMetric{
+		"VALUE":    AssetValue,
+		"QUANTITY": AssetQuantity,
+	}
+)
+
+func (a AssetMetric) String() string {
+	return assetMetricKeyValues[a]
+}
+
+func (a AssetMetric) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *AssetMetric) UnmarshalText(text []byte) error {
+	txt := string(text)
+	if metric, ok := assetMetricValueKeys[txt]; ok {
+		*a = metric
+		return nil
+	}
+	return errors.Errorf("invalid asset metric: %s", txt)
+}
+
+// AssetReachedLimitParams are LimitCondition applied to single asset's metric:
+// VALUE (default) - asset's balance converted to trigger's currency, QUANTITY - asset's raw quantity. Both are taken by trigger's balance basis
+type AssetReachedLimitParams struct {
+	Asset  core.Currency `json:"asset"`
+	Metric AssetMetric   `json:"metric,omitempty"`
+	LimitCondition
+}
+
+func (a *AssetReachedLimitParams) Validate() error {
+	if a.Asset == "" {
+		return errors.New("asset is required")
+	}
+	return a.LimitCondition.Validate()
+}
+
+// AssetReachedLimit is a trigger executing when single asset's value or quantity reaches certain limit
+// or leaves band of values
+type AssetReachedLimit struct {
+	triggerBase
+	params    AssetReachedLimitParams
+	lastValue *decimal.Decimal // value of previous check, nil if trigger has just been restored
+}
+
+// TryExecute returns non-empty ExecutionStatus if trigger is executed.
+// ExecutionStatus.Done is always equal to ExecutionStatus.Ok for this type of trigger
+func (a *AssetReachedLimit) TryExecute() (*ExecutionStatus, error) {
+	value := a.value()
+	lastValue := a.lastValue
+	a.lastValue = &value
+
+	ok := a.params.Check(value, lastValue)
+	return &ExecutionStatus{
+		Ok:           ok,
+		Done:         ok,
+		CurrentValue: value,
+	}, nil
+}
+
+func (a *AssetReachedLimit) Settings() TriggerSettings {
+	params := a.params
+	return a.settings(ARL, &params)
+}
+
+// Update returns copy of trigger with patched params
+func (a *AssetReachedLimit) Update(patch json.RawMessage) (Trigger, error) {
+	params, err := patchTriggerParams(ARL, &a.params, patch)
+	if err != nil {
+		return nil, err
+	}
+	updated := *a
+	updated.params = *params.(*AssetReachedLimitParams)
+	return &updated, nil
+}
+
+func (a *AssetReachedLimit) value() decimal.Decimal {
+	value := a.portf.dataHolder.Asset(a.params.Asset).basis(a.state.Basis)
+	if a.params.Metric == AssetQuantity {
+		return value.Quantity
+	}
+	return value.Value[a.state.Currency]
+}
@@ -0,0 +1,88 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+func TestAssetReachedLimit_TryExecute(t *testing.T) {
+	portf := NewPortfolio(0, "", nil, nil, nil, nil, Config{})
+	setAsset := func(cur core.Currency, qty, valueUSDT int64) {
+		bal := core.Balance{Available: decimal.NewDecimal(qty, 0)}
+		portf.dataHolder.assets[cur] = Asset{
+			Balance:      bal,
+			AssetBalance: newAssetBalance(bal, ConvertedTo{USDT: decimal.NewDecimal(valueUSDT, 0).Div(bal.Available)}),
+		}
+	}
+
+	t.Run("value", func(t *testing.T) {
+		trigger, err := NewTrigger(portf, ARL, USDT, []byte(`{"asset":"ETH","limit":"50000"}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		setAsset("ETH", 10, 49000)
+		status, err := trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.False(t, status.Ok)
+
+		setAsset("ETH", 10, 51000)
+		status, err = trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.True(t, status.Ok)
+		assert.True(t, decimal.NewDecimal(51000, 0).Eq(status.CurrentValue))
+	})
+
+	t.Run("quantity", func(t *testing.T) {
+		trigger, err := NewTrigger(portf, ARL, USDT,
+			[]byte(`{"asset":"SOL","metric":"QUANTITY","limit":"100","direction":"BELOW"}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		setAsset("SOL", 150, 10)
+		status, err := trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.False(t, status.Ok)
+
+		setAsset("SOL", 99, 1000000)
+		status, err = trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.True(t, status.Ok)
+		assert.True(t, decimal.NewDecimal(99, 0).Eq(status.CurrentValue))
+	})
+
+	t.Run("balance basis", func(t *testing.T) {
+		params := `{"asset":"SOL","metric":"QUANTITY","limit":"100","direction":"BELOW"}`
+		total, err := NewTrigger(portf, ARL, USDT, []byte(params))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, BasisTotal, total.Settings().Basis)
+		available, err := NewTrigger(portf, ARL, USDT, []byte(params[:len(params)-1]+`,"balance_basis":"AVAILABLE"}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// Placing an order doesn't change total quantity
+		bal := core.Balance{Available: decimal.NewDecimal(50, 0), Locked: decimal.NewDecimal(100, 0)}
+		portf.dataHolder.assets["SOL"] = Asset{Balance: bal, AssetBalance: newAssetBalance(bal, nil)}
+		status, err := total.TryExecute()
+		assert.NoError(t, err)
+		assert.False(t, status.Ok)
+		assert.True(t, decimal.NewDecimal(150, 0).Eq(status.CurrentValue))
+
+		status, err = available.TryExecute()
+		assert.NoError(t, err)
+		assert.True(t, status.Ok)
+		assert.True(t, decimal.NewDecimal(50, 0).Eq(status.CurrentValue))
+	})
+
+	t.Run("when asset is required", func(t *testing.T) {
+		_, err := NewTrigger(portf, ARL, USDT, []byte(`{"limit":"100"}`))
+		assert.Error(t, err)
+	})
+}
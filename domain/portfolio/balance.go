@@ -0,0 +1,124 @@
+package portfolio
+
+import (
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+// BalanceBasis is a part of balances trigger evaluates (portfolio's total cost, asset's value and quantity):
+// TOTAL (default) - available + locked in open orders, AVAILABLE - free for trading, LOCKED - locked in open orders
+type BalanceBasis uint8
+
+const (
+	BasisTotal BalanceBasis = iota + 1
+	BasisAvailable
+	BasisLocked
+)
+
+var (
+	balanceBasisKeyValues = map[BalanceBasis]string{
+		BasisTotal:     "TOTAL",
+		BasisAvailable: "AVAILABLE",
+		BasisLocked:    "LOCKED",
+	}
+	balanceBasisValueKeys = map[string]BalanceBasis{
+		"TOTAL":     BasisTotal,
+		"AVAILABLE": BasisAvailable,
+		"LOCKED":    BasisLocked,
+	}
+)
+
+func (b BalanceBasis) String() string {
+	return balanceBasisKeyValues[b]
+}
+
+func (b BalanceBasis) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *BalanceBasis) UnmarshalText(text []byte) error {
+	txt := string(text)
+	if basis, ok := balanceBasisValueKeys[txt]; ok {
+		*b = basis
+		return nil
+	}
+	return errors.Errorf("invalid balance basis: %s", txt)
+}
+
+type (
+	// Balances are portfolio's values converted to Currency types by BalanceBasis + Details of every held asset
+	Balances struct {
+		Total     ConvertedTo                    `json:"total" validate:"required"`
+		Available ConvertedTo                    `json:"available" validate:"required"`
+		Locked    ConvertedTo                    `json:"locked" validate:"required"`
+		Details   map[core.Currency]AssetBalance `json:"details" validate:"required"`
+	}
+	// AssetBalance is a balance of single asset by BalanceBasis
+	AssetBalance struct {
+		Total     BalanceValue `json:"total" validate:"required"`
+		Available BalanceValue `json:"available" validate:"required"`
+		Locked    BalanceValue `json:"locked" validate:"required"`
+	}
+	// BalanceValue is a quantity of asset and its value converted to Currency types
+	BalanceValue struct {
+		Quantity decimal.Decimal `json:"quantity" validate:"required"`
+		Value    ConvertedTo     `json:"value" validate:"required"`
+	}
+)
+
+// basis returns portfolio's values by BalanceBasis
+func (b Balances) basis(basis BalanceBasis) ConvertedTo {
+	switch basis {
+	case BasisAvailable:
+		return b.Available
+	case BasisLocked:
+		return b.Locked
+	default:
+		return b.Total
+	}
+}
+
+// basis returns BalanceValue by BalanceBasis
+func (a AssetBalance) basis(basis BalanceBasis) BalanceValue {
+	switch basis {
+	case BasisAvailable:
+		return a.Available
+	case BasisLocked:
+		return a.Locked
+	default:
+		return a.Total
+	}
+}
+
+// newAssetBalance converts quantities of raw balance by prices
+func newAssetBalance(bal core.Balance, prices ConvertedTo) AssetBalance {
+	value := func(quantity decimal.Decimal) BalanceValue {
+		converted := make(ConvertedTo, len(prices))
+		for cur, price := range prices {
+			converted[cur] = quantity.Mul(price)
+		}
+		return BalanceValue{Quantity: quantity, Value: converted}
+	}
+	return AssetBalance{
+		Total:     value(bal.Available.Add(bal.Locked)),
+		Available: value(bal.Available),
+		Locked:    value(bal.Locked),
+	}
+}
+
+// add sums quantities and values in currencies of two balances of the same asset
+func (a AssetBalance) add(b AssetBalance, currencies []Currency) AssetBalance {
+	sum := func(x, y BalanceValue) BalanceValue {
+		value := make(ConvertedTo, len(currencies))
+		for _, cur := range currencies {
+			value[cur] = x.Value[cur].Add(y.Value[cur])
+		}
+		return BalanceValue{Quantity: x.Quantity.Add(y.Quantity), Value: value}
+	}
+	return AssetBalance{
+		Total:     sum(a.Total, b.Total),
+		Available: sum(a.Available, b.Available),
+		Locked:    sum(a.Locked, b.Locked),
+	}
+}
@@ -0,0 +1,316 @@
+package portfolio
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+// MaxCompositeDepth is a max nesting level of composite triggers including the root one
+const MaxCompositeDepth = 3
+
+var COMPOSITE = RegisterTriggerType(TriggerKind{
+	Name: "COMPOSITE",
+	Decode: func(data []byte) (TriggerParams, error) {
+		params := new(CompositeParams)
+		err := json.Unmarshal(data, params)
+		return params, errors.WithStack(err)
+	},
+	New: func(portf *Portfolio, currency Currency, basis BalanceBasis, params TriggerParams) (Trigger, error) {
+		c := &Composite{triggerBase: newTriggerBase(portf, currency, basis)}
+		if err := c.setConditions(params.(*CompositeParams), false); err != nil {
+			return nil, err
+		}
+		return c, nil
+	},
+	Restore: func(portf *Portfolio, state TriggerState, params TriggerParams) (Trigger, error) {
+		c := &Composite{triggerBase: triggerBase{portf: portf, state: state}}
+		if err := c.setConditions(params.(*CompositeParams), true); err != nil {
+			return nil, err
+		}
+		return c, nil
+	},
+})
+
+type LogicalOperator uint8
+
+const (
+	OperatorAnd LogicalOperator = iota + 1
+	OperatorOr
+)
+
+var (
+	logicalOperatorKeyValues = map[LogicalOperator]string{
+		OperatorAnd: "AND",
+		OperatorOr:  "OR",
+	}
+	logicalOperatorValueKeys = map[string]LogicalOperator{
+		"AND": OperatorAnd,
+		"OR":  OperatorOr,
+	}
+)
+
+func (l LogicalOperator) String() string {
+	return logicalOperatorKeyValues[l]
+}
+
+func (l LogicalOperator) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *LogicalOperator) UnmarshalText(text []byte) error {
+	txt := string(text)
+	if operator, ok := logicalOperatorValueKeys[txt]; ok {
+		*l = operator
+		return nil
+	}
+	return errors.Errorf("invalid logical operator: %s", txt)
+}
+
+// CompositeParams combine Conditions with logical Operator.
+// Condition may be a composite itself, so params are persisted as a tree
+type CompositeParams struct {
+	Operator   LogicalOperator `json:"operator"`
+	Conditions []Condition     `json:"conditions"`
+}
+
+func (c *CompositeParams) Validate() error {
+	if c.Operator == 0 {
+		return errors.New("operator is required")
+	}
+	if len(c.Conditions) < 2 {
+		return errors.New("at least 2 conditions are required")
+	}
+	if c.depth() > MaxCompositeDepth {
+		return errors.Errorf("max depth of composite trigger is %d", MaxCompositeDepth)
+	}
+	for i, cond := range c.Conditions {
+		if cond.Params == nil {
+			return errors.Errorf("params of condition #%d are required", i)
+		}
+		if err := cond.Params.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid condition #%d", i)
+		}
+	}
+	return nil
+}
+
+func (c *CompositeParams) depth() int {
+	depth := 1
+	for _, cond := range c.Conditions {
+		if nested, ok := cond.Params.(*CompositeParams); ok && nested.depth()+1 > depth {
+			depth = nested.depth() + 1
+		}
+	}
+	return depth
+}
+
+// Condition is a trigger type + type-specific Params inlined into JSON presentation like in TriggerSettings.
+// Condition is evaluated in currency of composite trigger
+type Condition struct {
+	Type   TriggerType   `json:"type" swaggertype:"string"`
+	Params TriggerParams `json:"-"`
+}
+
+func (c Condition) MarshalJSON() ([]byte, error) {
+	type condition Condition
+	data, err := json.Marshal(condition(c))
+	if err != nil {
+		return nil, err
+	}
+	return inlineParams(data, c.Params)
+}
+
+func (c *Condition) UnmarshalJSON(data []byte) error {
+	type condition Condition
+	if err := json.Unmarshal(data, (*condition)(c)); err != nil {
+		return err
+	}
+	if c.Type == 0 {
+		return errors.New("condition type is required")
+	}
+	kind, err := c.Type.kind()
+	if err != nil {
+		return err
+	}
+	c.Params, err = kind.Decode(data)
+	return errors.Wrapf(err, "failed to decode params of %q condition type", c.Type)
+}
+
+// ConditionStatus is a result of condition's check reported in CompositeDetails
+type ConditionStatus struct {
+	Type         TriggerType     `json:"type"`
+	Ok           bool            `json:"ok"`
+	CurrentValue decimal.Decimal `json:"current_value"`
+	Details      interface{}     `json:"details,omitempty"`
+}
+
+// CompositeDetails list statuses of all conditions in order. Status of nested composite has its own CompositeDetails
+type CompositeDetails struct {
+	Conditions []ConditionStatus `json:"conditions"`
+}
+
+// Composite is a trigger executing when its conditions are met: all of them for AND, any for OR.
+// Conditions are triggers of other types (including composite) sharing portfolio and currency of composite trigger.
+// All conditions are checked every time to keep their state up-to-date and report matched ones in CompositeDetails.
+// Conditions aren't executed on their own: state of stateful conditions (see statefulTrigger) changes
+// along with accepted evaluation of composite trigger only, and their matches are ignored unless composite is executed
+type Composite struct {
+	triggerBase
+	operator   LogicalOperator
+	conditions []Trigger
+	statuses   []*ExecutionStatus // statuses of conditions by the last TryExecute
+}
+
+// setConditions creates (or restores) triggers of conditions attached to composite trigger
+func (c *Composite) setConditions(params *CompositeParams, restore bool) error {
+	c.operator = params.Operator
+	c.conditions = make([]Trigger, len(params.Conditions))
+	for i, cond := range params.Conditions {
+		kind, err := cond.Type.kind()
+		if err != nil {
+			return err
+		}
+
+		var t Trigger
+		if restore {
+			t, err = kind.restore(c.portf, TriggerState{
+				ID:        uuid.New(),
+				Currency:  c.state.Currency,
+				Basis:     c.state.Basis,
+				CreatedAt: c.state.CreatedAt,
+			}, cond.Params)
+		} else {
+			t, err = kind.new(c.portf, c.state.Currency, c.state.Basis, cond.Params)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to create condition #%d of %q type", i, cond.Type)
+		}
+		c.conditions[i] = t
+	}
+	return nil
+}
+
+// TryExecute returns non-empty ExecutionStatus if trigger is executed.
+// ExecutionStatus.CurrentValue is a number of matched conditions, ExecutionStatus.Details are CompositeDetails.
+// ExecutionStatus.Done is always equal to ExecutionStatus.Ok for this type of trigger
+func (c *Composite) TryExecute() (*ExecutionStatus, error) {
+	details := CompositeDetails{Conditions: make([]ConditionStatus, len(c.conditions))}
+	statuses := make([]*ExecutionStatus, len(c.conditions))
+	var matched int64
+	for i, t := range c.conditions {
+		status, err := t.TryExecute()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to execute condition #%d", i)
+		}
+		statuses[i] = status
+		details.Conditions[i] = ConditionStatus{
+			Type:         t.Settings().Type,
+			Ok:           status.Ok,
+			CurrentValue: status.CurrentValue,
+			Details:      status.Details,
+		}
+		if status.Ok {
+			matched++
+		}
+	}
+	c.statuses = statuses
+
+	var ok bool
+	switch c.operator {
+	case OperatorAnd:
+		ok = matched == int64(len(c.conditions))
+	case OperatorOr:
+		ok = matched > 0
+	}
+	return &ExecutionStatus{
+		Ok:           ok,
+		Done:         ok,
+		CurrentValue: decimal.NewDecimal(matched, 0),
+		Details:      details,
+	}, nil
+}
+
+// next returns params tree with changed params of stateful conditions. Executed composite trigger is done,
+// otherwise conditions are considered as non-executed even if they're matched
+func (c *Composite) next(status *ExecutionStatus) TriggerParams {
+	if status.Done || len(c.statuses) != len(c.conditions) {
+		return nil
+	}
+	overrides := make(map[uuid.UUID]TriggerParams)
+	for i, t := range c.conditions {
+		stateful, ok := t.(statefulTrigger)
+		if !ok {
+			continue
+		}
+		status := *c.statuses[i]
+		status.Ok, status.Done = false, false
+		if params := stateful.next(&status); params != nil {
+			overrides[t.ID()] = params
+		}
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+	return c.params(overrides)
+}
+
+// setParams applies params of stateful conditions
+func (c *Composite) setParams(params TriggerParams) {
+	for i, cond := range params.(*CompositeParams).Conditions {
+		if stateful, ok := c.conditions[i].(statefulTrigger); ok {
+			stateful.setParams(cond.Params)
+		}
+	}
+}
+
+// track forwards tracking to conditions
+func (c *Composite) track() {
+	for _, t := range c.conditions {
+		if tracking, ok := t.(trackingTrigger); ok {
+			tracking.track()
+		}
+	}
+}
+
+func (c *Composite) Settings() TriggerSettings {
+	return c.settings(COMPOSITE, c.params(nil))
+}
+
+// Update returns copy of trigger with patched operator and/or conditions.
+// Conditions are replaced entirely and created from scratch if they're present in patch
+func (c *Composite) Update(patch json.RawMessage) (Trigger, error) {
+	params, err := patchTriggerParams(COMPOSITE, c.params(nil), patch)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		return nil, errors.Wrap(ErrInvalidTriggerUpdate, err.Error())
+	}
+	_, replaced := fields["conditions"]
+
+	updated := &Composite{triggerBase: c.triggerBase}
+	if err := updated.setConditions(params.(*CompositeParams), !replaced); err != nil {
+		return nil, ErrInvalidTriggerUpdate.Wrap(err)
+	}
+	return updated, nil
+}
+
+// params builds params tree from current params of conditions. Params of conditions are replaced by overrides by IDs
+func (c *Composite) params(overrides map[uuid.UUID]TriggerParams) *CompositeParams {
+	params := &CompositeParams{
+		Operator:   c.operator,
+		Conditions: make([]Condition, len(c.conditions)),
+	}
+	for i, t := range c.conditions {
+		settings := t.Settings()
+		if override, ok := overrides[settings.ID]; ok {
+			settings.Params = override
+		}
+		params.Conditions[i] = Condition{Type: settings.Type, Params: settings.Params}
+	}
+	return params
+}
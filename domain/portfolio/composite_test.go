@@ -0,0 +1,149 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/pg"
+	"github.com/egsam98/portfolio/pg/repo"
+	"github.com/egsam98/portfolio/test/mocks"
+)
+
+func TestComposite_TryExecute(t *testing.T) {
+	const conditions = `[
+		{"type":"COST_REACHED_LIMIT","limit":"80000","direction":"BELOW"},
+		{"type":"ASSET_REACHED_LIMIT","asset":"BTC","metric":"QUANTITY","limit":"2"}
+	]`
+
+	for _, tt := range []struct {
+		operator string
+		total    int64
+		btc      int64
+		ok       bool
+		matched  []bool
+	}{
+		{operator: "AND", total: 70000, btc: 3, ok: true, matched: []bool{true, true}},
+		{operator: "AND", total: 70000, btc: 1, matched: []bool{true, false}},
+		{operator: "OR", total: 90000, btc: 3, ok: true, matched: []bool{false, true}},
+		{operator: "OR", total: 90000, btc: 1, matched: []bool{false, false}},
+	} {
+		portf := NewPortfolio(0, "", nil, nil, nil, nil, Config{})
+		trigger, err := NewTrigger(portf, COMPOSITE, USDT,
+			[]byte(`{"operator":"`+tt.operator+`","conditions":`+conditions+`}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(tt.total, 0)
+		bal := core.Balance{Available: decimal.NewDecimal(tt.btc, 0)}
+		portf.dataHolder.assets["BTC"] = Asset{Balance: bal, AssetBalance: newAssetBalance(bal, nil)}
+		status, err := trigger.TryExecute()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, tt.ok, status.Ok)
+		assert.Equal(t, tt.ok, status.Done)
+		if details, ok := status.Details.(CompositeDetails); assert.True(t, ok) {
+			matched := make([]bool, len(details.Conditions))
+			for i, cond := range details.Conditions {
+				matched[i] = cond.Ok
+			}
+			assert.Equal(t, tt.matched, matched)
+			assert.Equal(t, CRL, details.Conditions[0].Type)
+			assert.Equal(t, ARL, details.Conditions[1].Type)
+		}
+	}
+}
+
+func TestComposite_next(t *testing.T) {
+	qMock := mocks.NewQuerier(t)
+	db := &pg.DB{Queries: qMock}
+	portf := NewPortfolio(0, "", db, nil, nil, nil, Config{})
+
+	trigger, err := restoreTrigger(portf, COMPOSITE, TriggerState{ID: uuid.New(), Currency: USDT}, json.RawMessage(`{
+		"operator":"OR",
+		"conditions":[
+			{"type":"COST_REACHED_LIMIT","limit":"5000"},
+			{"type":"COMPOSITE","operator":"AND","conditions":[
+				{"type":"MAX_DRAWDOWN","percent":"10","peak":"1000"},
+				{"type":"COST_REACHED_LIMIT","limit":"100","direction":"BELOW"}
+			]}
+		]
+	}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	qMock.
+		On("PortfolioTriggers_Update", context.Background(), mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			params := args.Get(1).(repo.PortfolioTriggers_UpdateParams)
+			assert.Equal(t, trigger.ID(), params.ID)
+			assert.JSONEq(t, `{
+				"operator":"OR",
+				"conditions":[
+					{"type":"COST_REACHED_LIMIT","limit":"5000"},
+					{"type":"COMPOSITE","operator":"AND","conditions":[
+						{"type":"MAX_DRAWDOWN","percent":"10","peak":"2000"},
+						{"type":"COST_REACHED_LIMIT","limit":"100","direction":"BELOW"}
+					]}
+				]
+			}`, string(params.Params))
+		}).
+		Once()
+
+	portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(2000, 0)
+	status, err := trigger.TryExecute()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, status.Ok)
+
+	stateful := trigger.(statefulTrigger)
+	params := stateful.next(status)
+	if !assert.NotNil(t, params) {
+		return
+	}
+	assert.NoError(t, trigger.base().saveParams(qMock, params))
+	stateful.setParams(params)
+
+	// Updated trigger keeps state of conditions if they aren't replaced
+	updated, err := trigger.Update(json.RawMessage(`{"operator":"AND"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	updatedParams := updated.Settings().Params.(*CompositeParams)
+	assert.Equal(t, OperatorAnd, updatedParams.Operator)
+	nested := updatedParams.Conditions[1].Params.(*CompositeParams)
+	assert.True(t, decimal.NewDecimal(2000, 0).Eq(*nested.Conditions[0].Params.(*MaxDrawdownParams).Peak))
+}
+
+func TestCompositeParams_Validate(t *testing.T) {
+	for _, data := range []string{
+		`{"conditions":[{"type":"COST_REACHED_LIMIT","limit":"1"},{"type":"COST_REACHED_LIMIT","limit":"2"}]}`,
+		`{"operator":"AND","conditions":[{"type":"COST_REACHED_LIMIT","limit":"1"}]}`,
+		`{"operator":"AND","conditions":[{"type":"COST_REACHED_LIMIT","limit":"1"},{"type":"COST_REACHED_LIMIT"}]}`,
+		`{"operator":"AND","conditions":[{"type":"COST_REACHED_LIMIT","limit":"1"},{"limit":"2"}]}`,
+		`{"operator":"AND","conditions":[{"type":"COST_REACHED_LIMIT","limit":"1"},{"type":"UNKNOWN"}]}`,
+		`{"operator":"AND","conditions":[{"type":"COST_REACHED_LIMIT","limit":"1"},
+			{"type":"COMPOSITE","operator":"OR","conditions":[{"type":"COST_REACHED_LIMIT","limit":"1"},
+				{"type":"COMPOSITE","operator":"OR","conditions":[{"type":"COST_REACHED_LIMIT","limit":"1"},
+					{"type":"COMPOSITE","operator":"OR","conditions":[
+						{"type":"COST_REACHED_LIMIT","limit":"1"},{"type":"COST_REACHED_LIMIT","limit":"2"}
+					]}
+				]}
+			]}
+		]}`,
+	} {
+		_, err := DecodeTriggerParams(COMPOSITE, []byte(data))
+		assert.Error(t, err, data)
+	}
+}
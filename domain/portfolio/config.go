@@ -0,0 +1,84 @@
+package portfolio
+
+import (
+	"sort"
+	"time"
+
+	"github.com/egsam98/portfolio/domain/fx"
+)
+
+// Config holds settings shared by all portfolios
+type Config struct {
+	// PriceDebounce is a period price updates are coalesced within before portfolio revaluation.
+	// DefaultPriceDebounce is used if zero
+	PriceDebounce time.Duration
+	// PriceMaxAge is a period price stays OK within since the last update of its instruments (see PriceStatus).
+	// DefaultPriceMaxAge is used if zero
+	PriceMaxAge time.Duration
+	// HistoryEnabled enables saving of portfolio's Snapshot-s on revaluation
+	HistoryEnabled bool
+	// HistoryInterval is a minimal period between two Snapshot-s. Every revaluation is saved if zero
+	HistoryInterval time.Duration
+	// Currencies are quote currencies prices and balances are converted to. DefaultCurrencies are used if empty
+	Currencies []Currency
+	// PortfolioCurrencies override Currencies of certain portfolios (or groups) by their names
+	PortfolioCurrencies map[string][]Currency
+	// Pricing is a policy prices are calculated by. DefaultPricing is used if zero
+	Pricing PricingPolicy
+	// PortfolioPricing overrides Pricing of certain portfolios (or groups) by their names
+	PortfolioPricing map[string]PricingPolicy
+	// Normalization maps currencies of gateways onto canonical ones by gateway names
+	Normalization map[string]CurrencyNormalization
+	// TakerFees are fractions of taker fee (ex. 0.001) by gateway names, they're used by PricingLiquidation
+	TakerFees map[string]float64
+	// Fiat are currencies of Currencies (PortfolioCurrencies) converted from USDT by FX rates
+	Fiat []Currency
+	// FX provides rates of Fiat currencies. Fiat values are zero and stale if it's nil
+	FX FXRates
+}
+
+// FXRates provide the latest fiat rates (see fx.Poller). Stale is true if rates are outdated
+type FXRates interface {
+	Latest() (rates fx.Rates, stale bool)
+}
+
+// currencies returns quote currencies of portfolio
+func (c Config) currencies(portfolioName string) []Currency {
+	if currencies, ok := c.PortfolioCurrencies[portfolioName]; ok && len(currencies) > 0 {
+		return currencies
+	}
+	if len(c.Currencies) > 0 {
+		return c.Currencies
+	}
+	return DefaultCurrencies
+}
+
+// pricing returns PricingPolicy of portfolio
+func (c Config) pricing(portfolioName string) PricingPolicy {
+	if pricing := c.PortfolioPricing[portfolioName]; pricing != 0 {
+		return pricing
+	}
+	if c.Pricing != 0 {
+		return c.Pricing
+	}
+	return DefaultPricing
+}
+
+// AllCurrencies returns all configured quote currencies without duplicates: global ones go first
+func (c Config) AllCurrencies() []Currency {
+	names := make([]string, 0, len(c.PortfolioCurrencies))
+	for name := range c.PortfolioCurrencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	all := append([]Currency(nil), c.currencies("")...)
+	for _, name := range names {
+		for _, cur := range c.PortfolioCurrencies[name] {
+			if !containsCurrency(all, cur) {
+				all = append(all, cur)
+			}
+		}
+	}
+	return all
+}
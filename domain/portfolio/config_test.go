@@ -0,0 +1,43 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_currencies(t *testing.T) {
+	assert.Equal(t, DefaultCurrencies, Config{}.currencies("test"))
+
+	cfg := Config{
+		Currencies: []Currency{USDT, "ETH"},
+		PortfolioCurrencies: map[string][]Currency{
+			"b": {"EUR", USDT},
+			"a": {"USDC"},
+		},
+	}
+	assert.Equal(t, []Currency{USDT, "ETH"}, cfg.currencies("test"))
+	assert.Equal(t, []Currency{"EUR", USDT}, cfg.currencies("b"))
+	assert.Equal(t, []Currency{USDT, "ETH", "USDC", "EUR"}, cfg.AllCurrencies())
+}
+
+func TestConfig_pricing(t *testing.T) {
+	assert.Equal(t, DefaultPricing, Config{}.pricing("test"))
+
+	cfg := Config{
+		Pricing:          PricingMid,
+		PortfolioPricing: map[string]PricingPolicy{"a": PricingLiquidation},
+	}
+	assert.Equal(t, PricingMid, cfg.pricing("test"))
+	assert.Equal(t, PricingLiquidation, cfg.pricing("a"))
+}
+
+func TestCurrency_UnmarshalText(t *testing.T) {
+	var cur Currency
+	assert.NoError(t, cur.UnmarshalText([]byte("USDC")))
+	assert.Equal(t, Currency("USDC"), cur)
+
+	for _, txt := range []string{"", "usdt", "US-D"} {
+		assert.Error(t, cur.UnmarshalText([]byte(txt)), txt)
+	}
+}
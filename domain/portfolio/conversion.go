@@ -0,0 +1,216 @@
+package portfolio
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+const (
+	// ConversionRefreshInterval is a period gateway's symbols are checked for changes within to rebuild ConversionGraph
+	ConversionRefreshInterval = 5 * time.Minute
+	// MaxConversionSteps limits a length of ConversionPath
+	MaxConversionSteps = 6
+)
+
+type (
+	// ConversionStep is a conversion by price of symbol. Inverse is true if symbol's quote is converted to its base.
+	// FX is true if it's a conversion of USDT into fiat currency by FX rate
+	ConversionStep struct {
+		Symbol  string `json:"symbol" validate:"required"`
+		Inverse bool   `json:"inverse,omitempty"`
+		FX      bool   `json:"fx,omitempty"`
+	}
+	// ConversionPath is a sequence of conversions the price of one currency in another is derived by
+	ConversionPath []ConversionStep
+)
+
+// ConversionGraph holds currencies of gateway linked by its symbols. Shortest conversion paths are searched there.
+// Among paths of the same length one passing through the most liquid currencies (that have the most symbols) is preferred.
+// Currencies of graph are canonical (see CurrencyNormalization), steps of paths keep gateway's names of symbols.
+// Graph is shared by portfolios of the same gateway, it's rebuilt lazily if gateway's symbols change
+type ConversionGraph struct {
+	gw      core.Gateway
+	norm    CurrencyNormalization
+	now     func() time.Time // clock, it's replaced in tests
+	mu      sync.Mutex
+	checked time.Time // time symbols were checked for changes at
+	symbols map[core.Symbol]struct{}
+	listed  map[core.Currency]struct{} // gateway's names of currencies
+	edges   map[core.Currency][]conversionEdge
+	paths   map[[2]core.Currency]ConversionPath // found paths, nil path means currencies aren't linked
+}
+
+type conversionEdge struct {
+	to   core.Currency
+	step ConversionStep
+}
+
+func NewConversionGraph(gw core.Gateway, norm CurrencyNormalization) *ConversionGraph {
+	return &ConversionGraph{
+		gw:   gw,
+		norm: norm,
+		now:  time.Now,
+	}
+}
+
+// Canonical returns canonical name of gateway's currency
+func (g *ConversionGraph) Canonical(cur core.Currency) core.Currency {
+	if len(g.norm.Aliases) == 0 && len(g.norm.Prefixes) == 0 {
+		return cur
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.refresh()
+	return g.norm.canonical(cur, g.listed)
+}
+
+// Path returns the shortest conversion path of base currency into quote. False is returned if there is no path
+func (g *ConversionGraph) Path(base, quote core.Currency) (ConversionPath, bool) {
+	if base == quote {
+		return ConversionPath{}, true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.refresh()
+
+	key := [2]core.Currency{base, quote}
+	path, ok := g.paths[key]
+	if !ok {
+		path = g.search(base, quote)
+		g.paths[key] = path
+	}
+	return path, path != nil
+}
+
+// refresh rebuilds graph if ConversionRefreshInterval has passed since the last check and gateway's symbols have changed
+func (g *ConversionGraph) refresh() {
+	now := g.now()
+	if g.edges != nil && now.Sub(g.checked) < ConversionRefreshInterval {
+		return
+	}
+	g.checked = now
+
+	allSymbols := g.gw.AllSymbols()
+	symbols := make(map[core.Symbol]struct{}, len(allSymbols))
+	for _, symbol := range allSymbols {
+		symbols[symbol] = struct{}{}
+	}
+	if g.edges != nil && sameSymbols(g.symbols, symbols) {
+		return
+	}
+
+	listed := make(map[core.Currency]struct{})
+	for symbol := range symbols {
+		listed[symbol.Base] = struct{}{}
+		listed[symbol.Quote] = struct{}{}
+	}
+	edges := make(map[core.Currency][]conversionEdge)
+	for symbol := range symbols {
+		base, quote := g.norm.canonical(symbol.Base, listed), g.norm.canonical(symbol.Quote, listed)
+		if base == quote {
+			continue
+		}
+		edges[base] = append(edges[base], conversionEdge{
+			to:   quote,
+			step: ConversionStep{Symbol: symbol.String()},
+		})
+		edges[quote] = append(edges[quote], conversionEdge{
+			to:   base,
+			step: ConversionStep{Symbol: symbol.String(), Inverse: true},
+		})
+	}
+	// The order of edges makes search independent of the order of gateway's symbols
+	for _, curEdges := range edges {
+		sort.Slice(curEdges, func(i, j int) bool {
+			a, b := curEdges[i], curEdges[j]
+			if len(edges[a.to]) != len(edges[b.to]) {
+				return len(edges[a.to]) > len(edges[b.to])
+			}
+			if a.to != b.to {
+				return a.to < b.to
+			}
+			return a.step.Symbol < b.step.Symbol
+		})
+	}
+
+	g.symbols = symbols
+	g.listed = listed
+	g.edges = edges
+	g.paths = make(map[[2]core.Currency]ConversionPath)
+}
+
+// search finds the shortest path from base to quote by breadth-first search
+func (g *ConversionGraph) search(base, quote core.Currency) ConversionPath {
+	prev := map[core.Currency]conversionEdge{base: {}}
+	queue := []core.Currency{base}
+	for depth := 0; depth < MaxConversionSteps && len(queue) > 0; depth++ {
+		var next []core.Currency
+		for _, cur := range queue {
+			for _, edge := range g.edges[cur] {
+				if _, ok := prev[edge.to]; ok {
+					continue
+				}
+				prev[edge.to] = conversionEdge{to: cur, step: edge.step}
+				if edge.to == quote {
+					return g.backtrack(prev, base, quote)
+				}
+				next = append(next, edge.to)
+			}
+		}
+		queue = next
+	}
+	return nil
+}
+
+// backtrack restores path to quote from edges leading back to base
+func (g *ConversionGraph) backtrack(prev map[core.Currency]conversionEdge, base, quote core.Currency) ConversionPath {
+	var path ConversionPath
+	for cur := quote; cur != base; cur = prev[cur].to {
+		path = append(path, prev[cur].step)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+func sameSymbols(a, b map[core.Symbol]struct{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for symbol := range a {
+		if _, ok := b[symbol]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// price converts 1 unit of base currency by conversion path according to pricing policy.
+// Instruments of symbols are got by instrument func. Zero is returned if some price is unknown
+func (path ConversionPath) price(
+	instrument func(symbol string) (core.Instrument, error),
+	policy PricingPolicy,
+	takerFee decimal.Decimal,
+) decimal.Decimal {
+	price := decimal.NewDecimal(1, 0)
+	for _, step := range path {
+		inst, err := instrument(step.Symbol)
+		if err != nil {
+			return decimal.Decimal{}
+		}
+		bid, ask := inst.Price()
+		rate := policy.rate(bid, ask, step.Inverse, takerFee)
+		if rate.IsZero() {
+			return decimal.Decimal{}
+		}
+		price = price.Mul(rate)
+	}
+	return price
+}
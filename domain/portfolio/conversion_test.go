@@ -0,0 +1,78 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/core"
+
+	"github.com/egsam98/portfolio/test/mocks"
+)
+
+func TestConversionGraph_Path(t *testing.T) {
+	symbols := []core.Symbol{
+		{Base: "ETH", Quote: "BUSD"},
+		{Base: "XRP", Quote: "BUSD"},
+		{Base: "ETH", Quote: "BTC"},
+		{Base: "XRP", Quote: "BTC"},
+		{Base: "BTC", Quote: "USDT"},
+		{Base: "DOGE", Quote: "USDT"},
+		{Base: "SOL", Quote: "BTC"},
+	}
+	reversed := make([]core.Symbol, len(symbols))
+	for i, symbol := range symbols {
+		reversed[len(symbols)-1-i] = symbol
+	}
+
+	for name, symbols := range map[string][]core.Symbol{"when ordered": symbols, "when reversed": reversed} {
+		t.Run(name, func(t *testing.T) {
+			gwMock := mocks.NewGateway(t)
+			gwMock.
+				On("AllSymbols").
+				Return(symbols).
+				Once()
+			graph := NewConversionGraph(gwMock, CurrencyNormalization{})
+
+			path, ok := graph.Path("ETH", "XRP")
+			assert.True(t, ok)
+			// BTC has more symbols than BUSD
+			assert.Equal(t, ConversionPath{{Symbol: "ETHBTC"}, {Symbol: "XRPBTC", Inverse: true}}, path)
+
+			path, ok = graph.Path("ETH", "DOGE")
+			assert.True(t, ok)
+			assert.Equal(t, ConversionPath{{Symbol: "ETHBTC"}, {Symbol: "BTCUSDT"}, {Symbol: "DOGEUSDT", Inverse: true}}, path)
+
+			_, ok = graph.Path("ETH", "TRX")
+			assert.False(t, ok)
+		})
+	}
+
+	t.Run("when symbols change", func(t *testing.T) {
+		now := time.Unix(1654586492, 0)
+		gwMock := mocks.NewGateway(t)
+		gwMock.
+			On("AllSymbols").
+			Return(symbols).
+			Once()
+		graph := NewConversionGraph(gwMock, CurrencyNormalization{})
+		graph.now = func() time.Time { return now }
+
+		_, ok := graph.Path("ETH", "TRX")
+		assert.False(t, ok)
+
+		// Symbols aren't checked before refresh interval passes
+		now = now.Add(ConversionRefreshInterval / 2)
+		_, ok = graph.Path("ETH", "TRX")
+		assert.False(t, ok)
+
+		gwMock.
+			On("AllSymbols").
+			Return(append(symbols, core.Symbol{Base: "TRX", Quote: "USDT"})).
+			Once()
+		now = now.Add(ConversionRefreshInterval)
+		path, ok := graph.Path("ETH", "TRX")
+		assert.True(t, ok)
+		assert.Equal(t, ConversionPath{{Symbol: "ETHBTC"}, {Symbol: "BTCUSDT"}, {Symbol: "TRXUSDT", Inverse: true}}, path)
+	})
+}
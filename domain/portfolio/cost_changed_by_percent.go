@@ -1,98 +1,135 @@
 package portfolio
 
 import (
-	"context"
-	"time"
+	"encoding/json"
 
-	"github.com/egsam98/portfolio/pg/repo"
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"gitlab.com/moderntoken/gateways/decimal"
 )
 
+var CCBP = RegisterTriggerType(TriggerKind{
+	Name: "COST_CHANGED_BY_PERCENT",
+	Decode: func(data []byte) (TriggerParams, error) {
+		params := new(CostChangedByPercentParams)
+		err := json.Unmarshal(data, params)
+		return params, errors.WithStack(err)
+	},
+	New: func(portf *Portfolio, currency Currency, basis BalanceBasis, params TriggerParams) (Trigger, error) {
+		p := params.(*CostChangedByPercentParams)
+		return newCostChangedByPercent(portf, currency, basis, p.Percent, p.TrailingAlert), nil
+	},
+	Restore: func(portf *Portfolio, state TriggerState, params TriggerParams) (Trigger, error) {
+		p := params.(*CostChangedByPercentParams)
+		if p.StartTotalCost == nil {
+			return nil, errors.New("start total cost is required")
+		}
+		return &CostChangedByPercent{
+			triggerBase: triggerBase{portf: portf, state: state},
+			params:      *p,
+		}, nil
+	},
+})
+
+// CostChangedByPercentParams
+// StartTotalCost is a total cost deviation is calculated from. It's set by trigger itself
+type CostChangedByPercentParams struct {
+	Percent        decimal.Decimal  `json:"percent"`
+	TrailingAlert  bool             `json:"trailing_alert"`
+	StartTotalCost *decimal.Decimal `json:"start_total_cost,omitempty"`
+}
+
+func (c *CostChangedByPercentParams) Validate() error {
+	if c.Percent.IsZero() {
+		return errors.New("percent is required")
+	}
+	return nil
+}
+
 // CostChangedByPercent is a trigger executing when portfolio's total cost is changed by certain % of start value.
 // If trailingAlert is true trigger becomes non-removable: executed total cost value becomes a start value for next iteration
 type CostChangedByPercent struct {
-	trailingAlert  bool
-	portf          *Portfolio
-	id             uuid.UUID
-	currency       Currency
-	startTotalCost decimal.Decimal
-	percent        decimal.Decimal
-	createdAt      time.Time
+	triggerBase
+	params CostChangedByPercentParams
 }
 
+// NewCostChangedByPercent creates trigger of portfolio's total cost by BasisTotal
 func NewCostChangedByPercent(portf *Portfolio, currency Currency, percent decimal.Decimal, trailingAlert bool) *CostChangedByPercent {
-	cost := portf.dataHolder.TotalBalance(currency)
-	return &CostChangedByPercent{
-		id:             uuid.New(),
-		trailingAlert:  trailingAlert,
-		portf:          portf,
-		currency:       currency,
-		percent:        percent,
-		startTotalCost: cost,
-		createdAt:      time.Now().UTC(),
-	}
+	return newCostChangedByPercent(portf, currency, BasisTotal, percent, trailingAlert)
 }
 
-func (c *CostChangedByPercent) ID() uuid.UUID {
-	return c.id
+func newCostChangedByPercent(
+	portf *Portfolio,
+	currency Currency,
+	basis BalanceBasis,
+	percent decimal.Decimal,
+	trailingAlert bool,
+) *CostChangedByPercent {
+	c := &CostChangedByPercent{
+		triggerBase: newTriggerBase(portf, currency, basis),
+		params: CostChangedByPercentParams{
+			Percent:       percent,
+			TrailingAlert: trailingAlert,
+		},
+	}
+	cost := c.totalBalance()
+	c.params.StartTotalCost = &cost
+	return c
 }
 
 // TryExecute returns non-empty ExecutionStatus if trigger is executed
 func (c *CostChangedByPercent) TryExecute() (*ExecutionStatus, error) {
-	totalCost := c.portf.dataHolder.TotalBalance(c.currency)
-	devPercent := totalCost.Sub(c.startTotalCost).Abs().Div(c.startTotalCost).MulFloat(100)
-	ok := !devPercent.LessThan(c.percent)
-
-	if ok && c.trailingAlert {
-		if err := c.portf.db.Queries.PortfolioTriggers_UpdateStartTotalCost(
-			context.Background(),
-			repo.PortfolioTriggers_UpdateStartTotalCostParams{
-				StartTotalCost: &c.startTotalCost,
-				ID:             c.id,
-			},
-		); err != nil {
-			return nil, errors.Wrapf(err, "failed to update start total cost of portfolio trigger %q", c.id)
-		}
-
-		c.startTotalCost = totalCost
+	startTotalCost := *c.params.StartTotalCost
+	// Deviation from zero is undefined (ex. nothing is locked yet for BasisLocked), start total cost is re-seeded
+	if startTotalCost.IsZero() {
+		return &ExecutionStatus{}, nil
 	}
-
+	totalCost := c.totalBalance()
+	devPercent := totalCost.Sub(startTotalCost).Abs().Div(startTotalCost).MulFloat(100)
+	ok := !devPercent.LessThan(c.params.Percent)
 	return &ExecutionStatus{
 		Ok:           ok,
-		Done:         ok && !c.trailingAlert,
+		Done:         ok && !c.params.TrailingAlert,
 		CurrentValue: devPercent,
 	}, nil
 }
 
-func (c *CostChangedByPercent) Settings() TriggerSettings {
-	return TriggerSettings{
-		ID:             c.id,
-		Currency:       c.currency,
-		CreatedAt:      c.createdAt.Unix(),
-		TrailingAlert:  c.trailingAlert,
-		Type:           CCBP,
-		Percent:        &c.percent,
-		StartTotalCost: &c.startTotalCost,
+// repeatable is true for trailing alert, which stays after execution
+func (c *CostChangedByPercent) repeatable() bool {
+	return c.params.TrailingAlert
+}
+
+// next moves start total cost to executed total cost if trailing alert is set.
+// Zero start total cost is re-seeded by the first non-zero total cost
+func (c *CostChangedByPercent) next(status *ExecutionStatus) TriggerParams {
+	totalCost := c.totalBalance()
+	trailed := status.Ok && c.params.TrailingAlert
+	reseeded := c.params.StartTotalCost.IsZero() && !totalCost.IsZero()
+	if !trailed && !reseeded {
+		return nil
 	}
+	params := c.params
+	params.StartTotalCost = &totalCost
+	return &params
 }
 
-// Restore trigger state from external source (ex. database)
-func (c *CostChangedByPercent) Restore(
-	portf *Portfolio,
-	id uuid.UUID,
-	currency Currency,
-	percent decimal.Decimal,
-	startTotalCost decimal.Decimal,
-	trailingAlert bool,
-	createdAt time.Time,
-) {
-	c.portf = portf
-	c.id = id
-	c.currency = currency
-	c.percent = percent
-	c.trailingAlert = trailingAlert
-	c.createdAt = createdAt
-	c.startTotalCost = startTotalCost
+func (c *CostChangedByPercent) setParams(params TriggerParams) {
+	c.params = *params.(*CostChangedByPercentParams)
+}
+
+func (c *CostChangedByPercent) Settings() TriggerSettings {
+	params := c.params
+	return c.settings(CCBP, &params)
+}
+
+// Update returns copy of trigger with patched percent and/or trailing alert flag.
+// Start total cost can't be patched as it's set by trigger itself
+func (c *CostChangedByPercent) Update(patch json.RawMessage) (Trigger, error) {
+	params, err := patchTriggerParams(CCBP, &c.params, patch)
+	if err != nil {
+		return nil, err
+	}
+	updated := *c
+	updated.params = *params.(*CostChangedByPercentParams)
+	updated.params.StartTotalCost = c.params.StartTotalCost
+	return &updated, nil
 }
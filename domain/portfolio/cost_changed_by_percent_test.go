@@ -0,0 +1,64 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+func TestCostChangedByPercent_TryExecute(t *testing.T) {
+	portf := NewPortfolio(0, "", nil, nil, nil, nil, Config{})
+
+	trigger, err := restoreTrigger(portf, CCBP, TriggerState{ID: uuid.New(), Currency: USDT},
+		json.RawMessage(`{"percent":"10","trailing_alert":true,"start_total_cost":"0"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	stateful, ok := trigger.(statefulTrigger)
+	if !assert.True(t, ok) {
+		return
+	}
+	startTotalCost := func() decimal.Decimal {
+		return *trigger.Settings().Params.(*CostChangedByPercentParams).StartTotalCost
+	}
+
+	// Zero start total cost is re-seeded by non-zero one without execution
+	status, err := trigger.TryExecute()
+	if assert.NoError(t, err) {
+		assert.False(t, status.Ok)
+		assert.Nil(t, stateful.next(status))
+	}
+	portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(1000, 0)
+	status, err = trigger.TryExecute()
+	if assert.NoError(t, err) {
+		assert.False(t, status.Ok)
+		if params := stateful.next(status); assert.NotNil(t, params) {
+			stateful.setParams(params)
+		}
+	}
+	assert.True(t, decimal.NewDecimal(1000, 0).Eq(startTotalCost()))
+
+	portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(1050, 0)
+	status, err = trigger.TryExecute()
+	if assert.NoError(t, err) {
+		assert.False(t, status.Ok)
+		assert.Nil(t, stateful.next(status))
+	}
+
+	// Trailing start total cost isn't moved until params are applied
+	portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(1100, 0)
+	status, err = trigger.TryExecute()
+	if assert.NoError(t, err) {
+		assert.True(t, status.Ok)
+		assert.False(t, status.Done)
+		params := stateful.next(status)
+		assert.True(t, decimal.NewDecimal(1000, 0).Eq(startTotalCost()))
+		if assert.NotNil(t, params) {
+			stateful.setParams(params)
+		}
+	}
+	assert.True(t, decimal.NewDecimal(1100, 0).Eq(startTotalCost()))
+}
@@ -1,40 +1,65 @@
 package portfolio
 
 import (
-	"time"
+	"encoding/json"
 
-	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"gitlab.com/moderntoken/gateways/decimal"
 )
 
-// CostReachedLimit is a trigger executing when portfolio's total cost reaches certain value (more than one)
+var CRL = RegisterTriggerType(TriggerKind{
+	Name: "COST_REACHED_LIMIT",
+	Decode: func(data []byte) (TriggerParams, error) {
+		params := new(CostReachedLimitParams)
+		err := json.Unmarshal(data, params)
+		return params, errors.WithStack(err)
+	},
+	New: func(portf *Portfolio, currency Currency, basis BalanceBasis, params TriggerParams) (Trigger, error) {
+		c := &CostReachedLimit{
+			triggerBase: newTriggerBase(portf, currency, basis),
+			params:      *params.(*CostReachedLimitParams),
+		}
+		totalCost := c.totalBalance()
+		c.lastTotalCost = &totalCost
+		return c, nil
+	},
+	Restore: func(portf *Portfolio, state TriggerState, params TriggerParams) (Trigger, error) {
+		return &CostReachedLimit{
+			triggerBase: triggerBase{portf: portf, state: state},
+			params:      *params.(*CostReachedLimitParams),
+		}, nil
+	},
+})
+
+// CostReachedLimitParams are LimitCondition applied to portfolio's total cost
+type CostReachedLimitParams struct {
+	LimitCondition
+}
+
+// CostReachedLimit is a trigger executing when portfolio's total cost reaches certain value in direction
+// or leaves band of values
 type CostReachedLimit struct {
-	id        uuid.UUID
-	limit     decimal.Decimal
-	currency  Currency
-	portf     *Portfolio
-	createdAt time.Time
+	triggerBase
+	params        CostReachedLimitParams
+	lastTotalCost *decimal.Decimal // total cost of previous check, nil if trigger has just been restored
 }
 
+// NewCostReachedLimit creates trigger executing when portfolio's total cost (by BasisTotal) becomes more than or equal to limit
 func NewCostReachedLimit(portf *Portfolio, currency Currency, limit decimal.Decimal) *CostReachedLimit {
 	return &CostReachedLimit{
-		id:        uuid.New(),
-		portf:     portf,
-		currency:  currency,
-		limit:     limit,
-		createdAt: time.Now().UTC(),
+		triggerBase: newTriggerBase(portf, currency, BasisTotal),
+		params:      CostReachedLimitParams{LimitCondition{Limit: &limit}},
 	}
 }
 
-func (c *CostReachedLimit) ID() uuid.UUID {
-	return c.id
-}
-
 // TryExecute returns non-empty ExecutionStatus if trigger is executed.
 // ExecutionStatus.Done is always equal to ExecutionStatus.Ok for this type of trigger
 func (c *CostReachedLimit) TryExecute() (*ExecutionStatus, error) {
-	totalCost := c.portf.dataHolder.TotalBalance(c.currency)
-	ok := !totalCost.LessThan(c.limit)
+	totalCost := c.totalBalance()
+	lastTotalCost := c.lastTotalCost
+	c.lastTotalCost = &totalCost
+
+	ok := c.params.Check(totalCost, lastTotalCost)
 	return &ExecutionStatus{
 		Ok:           ok,
 		Done:         ok,
@@ -43,26 +68,17 @@ func (c *CostReachedLimit) TryExecute() (*ExecutionStatus, error) {
 }
 
 func (c *CostReachedLimit) Settings() TriggerSettings {
-	return TriggerSettings{
-		ID:        c.id,
-		Currency:  c.currency,
-		CreatedAt: c.createdAt.Unix(),
-		Type:      CRL,
-		Limit:     &c.limit,
-	}
+	params := c.params
+	return c.settings(CRL, &params)
 }
 
-// Restore trigger state from external source (ex. database)
-func (c *CostReachedLimit) Restore(
-	portf *Portfolio,
-	id uuid.UUID,
-	currency Currency,
-	limit decimal.Decimal,
-	createdAt time.Time,
-) {
-	c.portf = portf
-	c.id = id
-	c.currency = currency
-	c.limit = limit
-	c.createdAt = createdAt
+// Update returns copy of trigger with patched params. Limit and band can be switched by nullifying previous params
+func (c *CostReachedLimit) Update(patch json.RawMessage) (Trigger, error) {
+	params, err := patchTriggerParams(CRL, &c.params, patch)
+	if err != nil {
+		return nil, err
+	}
+	updated := *c
+	updated.params = *params.(*CostReachedLimitParams)
+	return &updated, nil
 }
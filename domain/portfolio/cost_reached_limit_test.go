@@ -0,0 +1,61 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+func TestCostReachedLimit_TryExecute(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		costs  []int64
+		exp    []bool
+	}{
+		{
+			name:   "above",
+			params: `{"limit":"100"}`,
+			costs:  []int64{90, 100, 110},
+			exp:    []bool{false, true, true},
+		},
+		{
+			name:   "below",
+			params: `{"limit":"100","direction":"BELOW"}`,
+			costs:  []int64{110, 100, 90},
+			exp:    []bool{false, false, true},
+		},
+		{
+			name:   "crosses",
+			params: `{"limit":"100","direction":"CROSSES"}`,
+			costs:  []int64{90, 95, 105, 110, 95},
+			exp:    []bool{false, false, true, false, true},
+		},
+		{
+			name:   "band",
+			params: `{"low":"90","high":"110"}`,
+			costs:  []int64{90, 110, 111, 100, 89},
+			exp:    []bool{false, false, true, false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			portf := NewPortfolio(0, "", nil, nil, nil, nil, Config{})
+			portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(tt.costs[0], 0)
+			trigger, err := NewTrigger(portf, CRL, USDT, []byte(tt.params))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for i, cost := range tt.costs {
+				portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(cost, 0)
+				status, err := trigger.TryExecute()
+				if assert.NoError(t, err) {
+					assert.Equal(t, tt.exp[i], status.Ok, "cost: %d", cost)
+				}
+			}
+		})
+	}
+}
@@ -4,48 +4,53 @@ import (
 	"github.com/pkg/errors"
 )
 
-type Currency uint8
+// Currency is a quote currency portfolio's prices and balances are converted to (see Config.Currencies).
+// It's a ticker of uppercase letters and digits, ex. USDT
+type Currency string
 
 const (
-	USDT Currency = iota + 1
-	BTC
+	USDT Currency = "USDT"
+	BTC  Currency = "BTC"
 )
 
-var (
-	currencyKeyValues = map[Currency]string{
-		USDT: "USDT",
-		BTC:  "BTC",
-	}
-	currencyValueKeys = map[string]Currency{
-		"USDT": USDT,
-		"BTC":  BTC,
-	}
-)
+// DefaultCurrencies are used if Config.Currencies are empty
+var DefaultCurrencies = []Currency{USDT, BTC}
 
 func (c Currency) String() string {
-	return currencyKeyValues[c]
+	return string(c)
 }
 
 func (c *Currency) Set(txt string) error {
 	if c == nil {
 		return errors.New("currency type is nil")
 	}
-	if val, ok := currencyValueKeys[txt]; ok {
-		*c = val
-		return nil
-	}
-	return errors.Errorf("invalid currency: %s", txt)
+	return c.UnmarshalText([]byte(txt))
 }
 
 func (c Currency) MarshalText() ([]byte, error) {
-	return []byte(c.String()), nil
+	return []byte(c), nil
 }
 
 func (c *Currency) UnmarshalText(text []byte) error {
 	txt := string(text)
-	if currency, ok := currencyValueKeys[txt]; ok {
-		*c = currency
-		return nil
+	if txt == "" {
+		return errors.New("invalid currency: empty")
+	}
+	for _, r := range txt {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return errors.Errorf("invalid currency: %s", txt)
+		}
+	}
+	*c = Currency(txt)
+	return nil
+}
+
+// containsCurrency returns true if currencies contain c
+func containsCurrency(currencies []Currency, c Currency) bool {
+	for _, cur := range currencies {
+		if cur == c {
+			return true
+		}
 	}
-	return errors.Errorf("invalid currency: %s", txt)
+	return false
 }
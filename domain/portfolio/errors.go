@@ -10,7 +10,15 @@ const (
 	ErrAccountNotFound = domain.Error("account isn't found")
 	ErrNotFound        = domain.Error("portfolio isn't found")
 	ErrExist           = domain.Error("portfolio already exists")
+	ErrGroupNotFound   = domain.Error("portfolio group isn't found")
 	ErrGateway         = domain.Error("gateway error")
+
+	ErrUnsupportedCurrency  = domain.Error("currency isn't supported by portfolio")
+	ErrTriggerNotFound      = domain.Error("trigger isn't found")
+	ErrUnsupportedTrigger   = domain.Error("trigger type isn't supported by portfolio group")
+	ErrUnsupportedRearm     = domain.Error("re-arm settings are supported only by triggers staying after execution")
+	ErrInvalidTrigger       = domain.Error("invalid trigger")
+	ErrInvalidTriggerUpdate = domain.Error("invalid trigger update")
 )
 
 var ErrGatewayNotFound = errors.New("gateway isn't found")
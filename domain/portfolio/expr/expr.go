@@ -0,0 +1,245 @@
+// Package expr implements small expression language evaluated to boolean, ex.:
+//
+//	balance.total.USDT < 10000 || prices.ETH.USDT > 4000
+//
+// Expression consists of number and boolean literals, fields (dot-separated paths resolved by Schema and Env),
+// arithmetic (+ - * /), comparison (== != < <= > >=) and logical (&& || !) operators and parentheses.
+// Expression is type-checked on compilation, so evaluation fails only on data errors (ex. division by zero)
+package expr
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+// MaxLength is a max length of expression's source
+const MaxLength = 1024
+
+type Type uint8
+
+const (
+	Number Type = iota + 1
+	Bool
+)
+
+var typeKeyValues = map[Type]string{
+	Number: "number",
+	Bool:   "bool",
+}
+
+func (t Type) String() string {
+	return typeKeyValues[t]
+}
+
+// Value is a typed value of field or expression's node
+type Value struct {
+	Type   Type
+	Number decimal.Decimal
+	Bool   bool
+}
+
+func NumberValue(number decimal.Decimal) Value {
+	return Value{Type: Number, Number: number}
+}
+
+func BoolValue(b bool) Value {
+	return Value{Type: Bool, Bool: b}
+}
+
+func (v Value) String() string {
+	if v.Type == Bool {
+		return fmt.Sprint(v.Bool)
+	}
+	return v.Number.String()
+}
+
+// Schema returns type of field by its path. Error is reported with position of field in expression
+type Schema func(path []string) (Type, error)
+
+// Env returns value of field by its path. Value must be of type returned by Schema for the same path
+type Env func(path []string) (Value, error)
+
+// Error is a compilation or evaluation error at position (byte offset) in expression's source
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos+1, e.Msg)
+}
+
+// Expr is a compiled expression
+type Expr struct {
+	src  string
+	root node
+}
+
+// Compile parses and type-checks boolean expression against schema
+func Compile(src string, schema Schema) (*Expr, error) {
+	if len(src) > MaxLength {
+		return nil, &Error{Pos: MaxLength, Msg: fmt.Sprintf("expression is longer than %d characters", MaxLength)}
+	}
+	root, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	c := &checker{schema: schema}
+	typ, err := c.check(root)
+	if err != nil {
+		return nil, err
+	}
+	if typ != Bool {
+		return nil, &Error{Pos: root.position(), Msg: fmt.Sprintf("expression must be bool, got %s", typ)}
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates expression with fields' values provided by env
+func (e *Expr) Eval(env Env) (bool, error) {
+	v, err := eval(e.root, env)
+	if err != nil {
+		return false, err
+	}
+	return v.Bool, nil
+}
+
+// checker infers types of nodes
+type checker struct {
+	schema Schema
+}
+
+func (c *checker) check(n node) (Type, error) {
+	switch n := n.(type) {
+	case *numberLit:
+		return Number, nil
+	case *boolLit:
+		return Bool, nil
+	case *field:
+		typ, err := c.schema(n.path)
+		if err != nil {
+			return 0, &Error{Pos: n.pos, Msg: err.Error()}
+		}
+		return typ, nil
+	case *unary:
+		x, err := c.check(n.x)
+		if err != nil {
+			return 0, err
+		}
+		want := Number
+		if n.op == tokenNot {
+			want = Bool
+		}
+		if x != want {
+			return 0, &Error{Pos: n.pos, Msg: fmt.Sprintf("operator %q requires %s operand, got %s", n.op, want, x)}
+		}
+		return x, nil
+	case *binary:
+		x, err := c.check(n.x)
+		if err != nil {
+			return 0, err
+		}
+		y, err := c.check(n.y)
+		if err != nil {
+			return 0, err
+		}
+		switch n.op {
+		case tokenEq, tokenNe:
+			if x != y {
+				return 0, &Error{Pos: n.pos, Msg: fmt.Sprintf("operator %q requires operands of the same type, got %s and %s", n.op, x, y)}
+			}
+			return Bool, nil
+		case tokenAnd, tokenOr:
+			if x != Bool || y != Bool {
+				return 0, &Error{Pos: n.pos, Msg: fmt.Sprintf("operator %q requires bool operands, got %s and %s", n.op, x, y)}
+			}
+			return Bool, nil
+		default:
+			if x != Number || y != Number {
+				return 0, &Error{Pos: n.pos, Msg: fmt.Sprintf("operator %q requires number operands, got %s and %s", n.op, x, y)}
+			}
+			if containsKind(comparisons, n.op) {
+				return Bool, nil
+			}
+			return Number, nil
+		}
+	default:
+		return 0, errors.Errorf("unknown node %T", n)
+	}
+}
+
+// eval evaluates type-checked node
+func eval(n node, env Env) (Value, error) {
+	switch n := n.(type) {
+	case *numberLit:
+		return NumberValue(n.value), nil
+	case *boolLit:
+		return BoolValue(n.value), nil
+	case *field:
+		v, err := env(n.path)
+		if err != nil {
+			return Value{}, &Error{Pos: n.pos, Msg: err.Error()}
+		}
+		return v, nil
+	case *unary:
+		x, err := eval(n.x, env)
+		if err != nil {
+			return Value{}, err
+		}
+		if n.op == tokenNot {
+			return BoolValue(!x.Bool), nil
+		}
+		return NumberValue(x.Number.MulFloat(-1)), nil
+	case *binary:
+		x, err := eval(n.x, env)
+		if err != nil {
+			return Value{}, err
+		}
+		// Short-circuit evaluation
+		switch {
+		case n.op == tokenAnd && !x.Bool:
+			return BoolValue(false), nil
+		case n.op == tokenOr && x.Bool:
+			return BoolValue(true), nil
+		}
+		y, err := eval(n.y, env)
+		if err != nil {
+			return Value{}, err
+		}
+
+		switch n.op {
+		case tokenAnd, tokenOr:
+			return BoolValue(y.Bool), nil
+		case tokenEq:
+			return BoolValue(x.Type == y.Type && x.Bool == y.Bool && x.Number.Eq(y.Number)), nil
+		case tokenNe:
+			return BoolValue(x.Type != y.Type || x.Bool != y.Bool || !x.Number.Eq(y.Number)), nil
+		case tokenLt:
+			return BoolValue(x.Number.LessThan(y.Number)), nil
+		case tokenLe:
+			return BoolValue(!y.Number.LessThan(x.Number)), nil
+		case tokenGt:
+			return BoolValue(y.Number.LessThan(x.Number)), nil
+		case tokenGe:
+			return BoolValue(!x.Number.LessThan(y.Number)), nil
+		case tokenPlus:
+			return NumberValue(x.Number.Add(y.Number)), nil
+		case tokenMinus:
+			return NumberValue(x.Number.Sub(y.Number)), nil
+		case tokenMul:
+			return NumberValue(x.Number.Mul(y.Number)), nil
+		case tokenDiv:
+			if y.Number.IsZero() {
+				return Value{}, &Error{Pos: n.pos, Msg: "division by zero"}
+			}
+			return NumberValue(x.Number.Div(y.Number)), nil
+		}
+	}
+	return Value{}, errors.Errorf("unknown node %T", n)
+}
@@ -0,0 +1,99 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+var testFields = map[string]Value{
+	"total":    NumberValue(decimal.NewDecimal(10000, 0)),
+	"eth.usdt": NumberValue(decimal.NewDecimal(4000, 0)),
+	"a.1INCH":  NumberValue(decimal.NewDecimal(2, 0)),
+	"zero":     NumberValue(decimal.NewDecimal(0, 0)),
+	"flag":     BoolValue(true),
+}
+
+func testSchema(path []string) (Type, error) {
+	v, ok := testFields[strings.Join(path, ".")]
+	if !ok {
+		return 0, errors.Errorf("unknown field %s", strings.Join(path, "."))
+	}
+	return v.Type, nil
+}
+
+func testEnv(path []string) (Value, error) {
+	return testFields[strings.Join(path, ".")], nil
+}
+
+func TestCompile(t *testing.T) {
+	for _, tt := range []struct {
+		src string
+		pos int
+	}{
+		{src: "total <", pos: 7},
+		{src: "total < 1 < 2", pos: 10},
+		{src: "(total < 1", pos: 10},
+		{src: "total < 1)", pos: 9},
+		{src: "total < 1.", pos: 9},
+		{src: "total < 1x", pos: 9},
+		{src: "total # 1", pos: 6},
+		{src: "eth. < 1", pos: 5},
+		{src: "unknown > 1", pos: 0},
+		{src: "total + 1", pos: 6},
+		{src: "total && flag", pos: 6},
+		{src: "!total", pos: 0},
+		{src: "-flag", pos: 0},
+		{src: "flag == 1", pos: 5},
+		{src: "flag < true", pos: 5},
+	} {
+		_, err := Compile(tt.src, testSchema)
+		var exprErr *Error
+		if assert.True(t, errors.As(err, &exprErr), tt.src) {
+			assert.Equal(t, tt.pos, exprErr.Pos, "%s: %s", tt.src, err)
+		}
+	}
+}
+
+func TestExpr_Eval(t *testing.T) {
+	for _, tt := range []struct {
+		src string
+		ok  bool
+	}{
+		{src: "total < 10000 || eth.usdt > 3999.5", ok: true},
+		{src: "total < 10000 && eth.usdt > 3999.5"},
+		{src: "total >= 10000 && eth.usdt <= 4000", ok: true},
+		{src: "total - eth.usdt * 2 == 2000", ok: true},
+		{src: "(total - eth.usdt) * 2 != 12000"},
+		{src: "total / 4 == 2500 && -total < 0", ok: true},
+		{src: "!(total > 1) || flag == false"},
+		{src: "a.1INCH + 0.5 > 2", ok: true},
+		{src: "true", ok: true},
+		// Right operand isn't evaluated due to short circuit
+		{src: "flag || total / zero > 1", ok: true},
+	} {
+		e, err := Compile(tt.src, testSchema)
+		if !assert.NoError(t, err, tt.src) {
+			continue
+		}
+		ok, err := e.Eval(testEnv)
+		if assert.NoError(t, err, tt.src) {
+			assert.Equal(t, tt.ok, ok, tt.src)
+		}
+	}
+
+	t.Run("division by zero", func(t *testing.T) {
+		e, err := Compile("total / zero > 1", testSchema)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = e.Eval(testEnv)
+		var exprErr *Error
+		if assert.True(t, errors.As(err, &exprErr)) {
+			assert.Equal(t, 6, exprErr.Pos)
+		}
+	})
+}
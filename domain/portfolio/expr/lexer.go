@@ -0,0 +1,158 @@
+package expr
+
+import (
+	"fmt"
+	"unicode"
+)
+
+type tokenKind uint8
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenDot
+	tokenLParen
+	tokenRParen
+	tokenOr
+	tokenAnd
+	tokenNot
+	tokenEq
+	tokenNe
+	tokenLt
+	tokenLe
+	tokenGt
+	tokenGe
+	tokenPlus
+	tokenMinus
+	tokenMul
+	tokenDiv
+)
+
+var tokenKindKeyValues = map[tokenKind]string{
+	tokenEOF:    "end of expression",
+	tokenNumber: "number",
+	tokenIdent:  "identifier",
+	tokenDot:    ".",
+	tokenLParen: "(",
+	tokenRParen: ")",
+	tokenOr:     "||",
+	tokenAnd:    "&&",
+	tokenNot:    "!",
+	tokenEq:     "==",
+	tokenNe:     "!=",
+	tokenLt:     "<",
+	tokenLe:     "<=",
+	tokenGt:     ">",
+	tokenGe:     ">=",
+	tokenPlus:   "+",
+	tokenMinus:  "-",
+	tokenMul:    "*",
+	tokenDiv:    "/",
+}
+
+func (t tokenKind) String() string {
+	return tokenKindKeyValues[t]
+}
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // offset in source
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenNumber, tokenIdent:
+		return fmt.Sprintf("%s %q", t.kind, t.text)
+	default:
+		return fmt.Sprintf("%q", t.kind.String())
+	}
+}
+
+// operators are sorted so that two-char operators are matched first
+var operators = []struct {
+	text string
+	kind tokenKind
+}{
+	{"||", tokenOr},
+	{"&&", tokenAnd},
+	{"==", tokenEq},
+	{"!=", tokenNe},
+	{"<=", tokenLe},
+	{">=", tokenGe},
+	{"!", tokenNot},
+	{"<", tokenLt},
+	{">", tokenGt},
+	{"+", tokenPlus},
+	{"-", tokenMinus},
+	{"*", tokenMul},
+	{"/", tokenDiv},
+	{"(", tokenLParen},
+	{")", tokenRParen},
+	{".", tokenDot},
+}
+
+// lex splits source into tokens ended by tokenEOF.
+// Segment after dot is lexed as identifier even if it starts with digit (ex. balance.details.1INCH.USDT)
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(src); {
+		c := rune(src[pos])
+		switch {
+		case unicode.IsSpace(c):
+			pos++
+		case len(tokens) > 0 && tokens[len(tokens)-1].kind == tokenDot && isIdentChar(c):
+			end := scan(src, pos, isIdentChar)
+			tokens = append(tokens, token{kind: tokenIdent, text: src[pos:end], pos: pos})
+			pos = end
+		case isDigit(c):
+			end := scan(src, pos, isDigit)
+			if end < len(src) && src[end] == '.' {
+				frac := scan(src, end+1, isDigit)
+				if frac == end+1 {
+					return nil, &Error{Pos: end, Msg: "digit expected after decimal point"}
+				}
+				end = frac
+			}
+			if end < len(src) && isIdentChar(rune(src[end])) {
+				return nil, &Error{Pos: end, Msg: fmt.Sprintf("unexpected character %q in number", src[end])}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[pos:end], pos: pos})
+			pos = end
+		case isIdentChar(c):
+			end := scan(src, pos, isIdentChar)
+			tokens = append(tokens, token{kind: tokenIdent, text: src[pos:end], pos: pos})
+			pos = end
+		default:
+			var matched bool
+			for _, op := range operators {
+				if len(src)-pos >= len(op.text) && src[pos:pos+len(op.text)] == op.text {
+					tokens = append(tokens, token{kind: op.kind, text: op.text, pos: pos})
+					pos += len(op.text)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, &Error{Pos: pos, Msg: fmt.Sprintf("unexpected character %q", src[pos])}
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+func scan(src string, pos int, fn func(c rune) bool) int {
+	for pos < len(src) && fn(rune(src[pos])) {
+		pos++
+	}
+	return pos
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c rune) bool {
+	return c == '_' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,205 @@
+package expr
+
+import (
+	"fmt"
+
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+// node is a node of expression's syntax tree
+type node interface {
+	position() int
+}
+
+type (
+	numberLit struct {
+		pos   int
+		value decimal.Decimal
+	}
+	boolLit struct {
+		pos   int
+		value bool
+	}
+	// field is a dot-separated path to value provided by Schema and Env (ex. prices.ETH.USDT)
+	field struct {
+		pos  int
+		path []string
+	}
+	unary struct {
+		pos int
+		op  tokenKind
+		x   node
+	}
+	binary struct {
+		pos  int // position of operator
+		op   tokenKind
+		x, y node
+	}
+)
+
+func (n *numberLit) position() int { return n.pos }
+func (n *boolLit) position() int   { return n.pos }
+func (n *field) position() int     { return n.pos }
+func (n *unary) position() int     { return n.pos }
+func (n *binary) position() int    { return n.pos }
+
+// parser is a recursive descent parser. Grammar in order of precedence (lowest first):
+//
+//	or      = and { "||" and }
+//	and     = cmp { "&&" cmp }
+//	cmp     = sum [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) sum ]
+//	sum     = term { ( "+" | "-" ) term }
+//	term    = unary { ( "*" | "/" ) unary }
+//	unary   = ( "!" | "-" ) unary | primary
+//	primary = number | "true" | "false" | ident { "." ident } | "(" or ")"
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(src string) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) unexpected(tok token) error {
+	return &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+}
+
+// binaryLevel parses left-associative sequence of operands separated by operators of the same precedence
+func (p *parser) binaryLevel(operand func() (node, error), ops ...tokenKind) (node, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if !containsKind(ops, tok.kind) {
+			return x, nil
+		}
+		p.next()
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{pos: tok.pos, op: tok.kind, x: x, y: y}
+	}
+}
+
+func (p *parser) or() (node, error) {
+	return p.binaryLevel(p.and, tokenOr)
+}
+
+func (p *parser) and() (node, error) {
+	return p.binaryLevel(p.cmp, tokenAnd)
+}
+
+var comparisons = []tokenKind{tokenEq, tokenNe, tokenLt, tokenLe, tokenGt, tokenGe}
+
+func (p *parser) cmp() (node, error) {
+	x, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	if !containsKind(comparisons, tok.kind) {
+		return x, nil
+	}
+	p.next()
+	y, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	// Comparisons are non-associative: a < b < c is rejected
+	if next := p.peek(); containsKind(comparisons, next.kind) {
+		return nil, &Error{Pos: next.pos, Msg: "comparison operators can't be chained"}
+	}
+	return &binary{pos: tok.pos, op: tok.kind, x: x, y: y}, nil
+}
+
+func (p *parser) sum() (node, error) {
+	return p.binaryLevel(p.term, tokenPlus, tokenMinus)
+}
+
+func (p *parser) term() (node, error) {
+	return p.binaryLevel(p.unary, tokenMul, tokenDiv)
+}
+
+func (p *parser) unary() (node, error) {
+	tok := p.peek()
+	if tok.kind != tokenNot && tok.kind != tokenMinus {
+		return p.primary()
+	}
+	p.next()
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &unary{pos: tok.pos, op: tok.kind, x: x}, nil
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		return &numberLit{pos: tok.pos, value: decimal.ParseDecimal(tok.text)}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true", "false":
+			return &boolLit{pos: tok.pos, value: tok.text == "true"}, nil
+		}
+		f := &field{pos: tok.pos, path: []string{tok.text}}
+		for p.peek().kind == tokenDot {
+			p.next()
+			seg := p.next()
+			if seg.kind != tokenIdent {
+				return nil, &Error{Pos: seg.pos, Msg: fmt.Sprintf("identifier expected after \".\", got %s", seg)}
+			}
+			f.path = append(f.path, seg.text)
+		}
+		return f, nil
+	case tokenLParen:
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &Error{Pos: closing.pos, Msg: fmt.Sprintf("\")\" expected, got %s", closing)}
+		}
+		return x, nil
+	default:
+		return nil, p.unexpected(tok)
+	}
+}
+
+func containsKind(kinds []tokenKind, kind tokenKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/domain/portfolio/expr"
+)
+
+var EXPR = RegisterTriggerType(TriggerKind{
+	Name: "EXPRESSION",
+	Decode: func(data []byte) (TriggerParams, error) {
+		params := new(ExpressionParams)
+		err := json.Unmarshal(data, params)
+		return params, errors.WithStack(err)
+	},
+	New: func(portf *Portfolio, currency Currency, basis BalanceBasis, params TriggerParams) (Trigger, error) {
+		p := params.(*ExpressionParams)
+		if err := p.checkCurrencies(portf); err != nil {
+			return nil, ErrInvalidTrigger.Wrap(err)
+		}
+		return &Expression{
+			triggerBase: newTriggerBase(portf, currency, basis),
+			params:      *p,
+		}, nil
+	},
+	Restore: func(portf *Portfolio, state TriggerState, params TriggerParams) (Trigger, error) {
+		p := params.(*ExpressionParams)
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		return &Expression{
+			triggerBase: triggerBase{portf: portf, state: state},
+			params:      *p,
+		}, nil
+	},
+})
+
+// ExpressionParams
+// Expression is a boolean expression over Data fields (see package expr), ex.:
+// balance.total.USDT < 10000 || prices.ETH.USDT > 4000
+type ExpressionParams struct {
+	Expression string     `json:"expression"`
+	compiled   *expr.Expr // set by Validate
+}
+
+// Validate compiles expression. Error is *expr.Error if expression is invalid
+func (e *ExpressionParams) Validate() error {
+	if strings.TrimSpace(e.Expression) == "" {
+		return errors.New("expression is required")
+	}
+	compiled, err := expr.Compile(e.Expression, dataSchema)
+	if err != nil {
+		return errors.Wrap(err, "invalid expression")
+	}
+	e.compiled = compiled
+	return nil
+}
+
+// checkCurrencies checks that currencies of fields are portfolio's ones. Error is *expr.Error pointing to field otherwise
+func (e *ExpressionParams) checkCurrencies(portf *Portfolio) error {
+	_, err := expr.Compile(e.Expression, func(path []string) (expr.Type, error) {
+		field, err := parseDataField(path)
+		if err != nil {
+			return 0, err
+		}
+		if !containsCurrency(portf.currencies, field.currency) {
+			return 0, errors.Wrap(ErrUnsupportedCurrency, field.currency.String())
+		}
+		return expr.Number, nil
+	})
+	return errors.Wrap(err, "invalid expression")
+}
+
+// ExpressionDetails hold values of Data fields evaluated by expression
+type ExpressionDetails struct {
+	Fields map[string]decimal.Decimal `json:"fields"`
+}
+
+// Expression is a trigger executing when its expression over Data fields is true
+type Expression struct {
+	triggerBase
+	params ExpressionParams
+}
+
+// TryExecute returns non-empty ExecutionStatus if trigger is executed.
+// ExecutionStatus.CurrentValue is portfolio's total cost, ExecutionStatus.Details are ExpressionDetails.
+// ExecutionStatus.Done is always equal to ExecutionStatus.Ok for this type of trigger
+func (e *Expression) TryExecute() (*ExecutionStatus, error) {
+	details := ExpressionDetails{Fields: make(map[string]decimal.Decimal)}
+	ok, err := e.params.compiled.Eval(func(path []string) (expr.Value, error) {
+		value, err := e.dataField(path)
+		if err != nil {
+			return expr.Value{}, err
+		}
+		details.Fields[strings.Join(path, ".")] = value
+		return expr.NumberValue(value), nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to evaluate expression %q", e.params.Expression)
+	}
+	return &ExecutionStatus{
+		Ok:           ok,
+		Done:         ok,
+		CurrentValue: e.totalBalance(),
+		Details:      details,
+	}, nil
+}
+
+// dataField returns value of Data field. Balances are taken by trigger's balance basis, balance of currency that isn't held is zero.
+// Unknown price and currency that isn't converted to are errors
+func (e *Expression) dataField(path []string) (decimal.Decimal, error) {
+	field, err := parseDataField(path)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	if !containsCurrency(e.portf.currencies, field.currency) {
+		return decimal.Decimal{}, errors.Wrap(ErrUnsupportedCurrency, field.currency.String())
+	}
+	switch field.section {
+	case "prices":
+		price, ok := e.portf.dataHolder.Price(field.asset)
+		if !ok {
+			return decimal.Decimal{}, errors.Errorf("price of %s is unknown", field.asset)
+		}
+		return price[field.currency], nil
+	case "details":
+		return e.portf.dataHolder.Asset(field.asset).basis(e.state.Basis).Value[field.currency], nil
+	default:
+		return e.portf.dataHolder.TotalBalance(e.state.Basis, field.currency), nil
+	}
+}
+
+func (e *Expression) Settings() TriggerSettings {
+	params := e.params
+	return e.settings(EXPR, &params)
+}
+
+// Update returns copy of trigger with patched expression. Currencies of fields must be portfolio's ones
+func (e *Expression) Update(patch json.RawMessage) (Trigger, error) {
+	params, err := patchTriggerParams(EXPR, &e.params, patch)
+	if err != nil {
+		return nil, err
+	}
+	p := params.(*ExpressionParams)
+	if err := p.checkCurrencies(e.portf); err != nil {
+		return nil, ErrInvalidTriggerUpdate.Wrap(err)
+	}
+	updated := *e
+	updated.params = *p
+	return &updated, nil
+}
+
+// dataField is a parsed path of Data field available in expressions:
+// balance.total.<CURRENCY>, balance.details.<ASSET>.<CURRENCY>, prices.<ASSET>.<CURRENCY>
+type dataField struct {
+	section  string // total, details or prices
+	asset    core.Currency
+	currency Currency
+}
+
+func parseDataField(path []string) (dataField, error) {
+	var field dataField
+	var cur string
+	switch {
+	case len(path) == 3 && path[0] == "balance" && path[1] == "total":
+		field.section, cur = "total", path[2]
+	case len(path) == 4 && path[0] == "balance" && path[1] == "details":
+		field.section, field.asset, cur = "details", core.Currency(path[2]), path[3]
+	case len(path) == 3 && path[0] == "prices":
+		field.section, field.asset, cur = "prices", core.Currency(path[1]), path[2]
+	default:
+		return field, errors.Errorf(
+			"unknown field %s, expected balance.total.<CURRENCY>, balance.details.<ASSET>.<CURRENCY> or prices.<ASSET>.<CURRENCY>",
+			strings.Join(path, "."),
+		)
+	}
+	if err := field.currency.UnmarshalText([]byte(cur)); err != nil {
+		return field, err
+	}
+	return field, nil
+}
+
+// dataSchema is expr.Schema of Data fields. All of them are numbers
+func dataSchema(path []string) (expr.Type, error) {
+	if _, err := parseDataField(path); err != nil {
+		return 0, err
+	}
+	return expr.Number, nil
+}
@@ -0,0 +1,105 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/domain/portfolio/expr"
+)
+
+func TestExpression_TryExecute(t *testing.T) {
+	portf := NewPortfolio(0, "", nil, nil, nil, nil, Config{})
+	trigger, err := NewTrigger(portf, EXPR, USDT,
+		[]byte(`{"expression":"balance.total.USDT < 10000 || prices.ETH.USDT > 4000"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, tt := range []struct {
+		total  int64
+		price  int64
+		ok     bool
+		fields []string
+	}{
+		{total: 15000, price: 3000, fields: []string{"balance.total.USDT", "prices.ETH.USDT"}},
+		{total: 9000, price: 3000, ok: true, fields: []string{"balance.total.USDT"}},
+		{total: 15000, price: 4500, ok: true, fields: []string{"balance.total.USDT", "prices.ETH.USDT"}},
+	} {
+		portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(tt.total, 0)
+		portf.dataHolder.prices["ETH"] = ConvertedTo{USDT: decimal.NewDecimal(tt.price, 0)}
+		status, err := trigger.TryExecute()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, tt.ok, status.Ok)
+		assert.Equal(t, tt.ok, status.Done)
+		if details, ok := status.Details.(ExpressionDetails); assert.True(t, ok) {
+			assert.Len(t, details.Fields, len(tt.fields))
+			for _, field := range tt.fields {
+				assert.Contains(t, details.Fields, field)
+			}
+		}
+	}
+
+	t.Run("when currency isn't supported by portfolio", func(t *testing.T) {
+		_, err := NewTrigger(portf, EXPR, USDT, []byte(`{"expression":"balance.total.USDT < 1 || balance.total.EUR < 1"}`))
+		assert.ErrorIs(t, err, ErrInvalidTrigger)
+		var exprErr *expr.Error
+		if assert.True(t, errors.As(err, &exprErr)) {
+			assert.Equal(t, 26, exprErr.Pos)
+		}
+
+		_, err = trigger.Update(json.RawMessage(`{"expression":"prices.ETH.EUR > 1"}`))
+		assert.ErrorIs(t, err, ErrInvalidTriggerUpdate)
+		assert.True(t, errors.As(err, &exprErr))
+
+		// Currency is removed from portfolio's configuration after trigger is created
+		restored, err := restoreTrigger(portf, EXPR, TriggerState{Currency: USDT}, json.RawMessage(`{"expression":"balance.total.EUR < 1"}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = restored.TryExecute()
+		assert.ErrorContains(t, err, ErrUnsupportedCurrency.Error())
+	})
+
+	t.Run("when updated expression is invalid", func(t *testing.T) {
+		_, err := trigger.Update(json.RawMessage(`{"expression":"balance.total.USDT <"}`))
+		assert.ErrorIs(t, err, ErrInvalidTriggerUpdate)
+		var exprErr *expr.Error
+		if assert.True(t, errors.As(err, &exprErr)) {
+			assert.Equal(t, 20, exprErr.Pos)
+		}
+	})
+
+	t.Run("when price is unknown", func(t *testing.T) {
+		portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(15000, 0)
+		delete(portf.dataHolder.prices, "ETH")
+		_, err := trigger.TryExecute()
+		assert.Error(t, err)
+	})
+}
+
+func TestExpressionParams_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		expression string
+		pos        int
+	}{
+		{expression: "balance.total.usdt < 1", pos: 0},
+		{expression: "balance.total.USDT < 1 && prices.ETH > 1", pos: 26},
+		{expression: "balance.details.ETH.USDT", pos: 0},
+		{expression: "balance.total.USDT <", pos: 20},
+	} {
+		_, err := DecodeTriggerParams(EXPR, []byte(`{"expression":"`+tt.expression+`"}`))
+		var exprErr *expr.Error
+		if assert.True(t, errors.As(err, &exprErr), tt.expression) {
+			assert.Equal(t, tt.pos, exprErr.Pos, tt.expression)
+		}
+	}
+
+	_, err := DecodeTriggerParams(EXPR, []byte(`{"expression":" "}`))
+	assert.Error(t, err)
+}
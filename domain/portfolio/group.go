@@ -0,0 +1,244 @@
+package portfolio
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/pg"
+)
+
+// groupKeyPrefix separates Data of groups from Data of portfolios in Redis
+const groupKeyPrefix = "group:"
+
+// Group consolidates Data of member portfolios (accounts sharing alias) summing details of assets across them.
+// Group is revalued whenever any member revalues. It supports Trigger-s except ones evaluating gateway's
+// instruments (see TriggerKind.Gateway), so group has neither gateway nor ConversionGraph
+type Group struct {
+	*Portfolio
+	members   map[string]*Portfolio // portfolio name is a key
+	membersMu sync.RWMutex
+	revalueCh chan struct{}
+}
+
+func NewGroup(id int64, name string, db *pg.DB, rdb redis.UniversalClient, cfg Config) *Group {
+	g := &Group{
+		Portfolio: NewPortfolio(id, name, db, rdb, nil, nil, cfg),
+		members:   make(map[string]*Portfolio),
+		revalueCh: make(chan struct{}, 1),
+	}
+	g.group = true
+	g.dataHolder = newDataHolder(groupKeyPrefix+name, rdb)
+	g.revalue = g.revalueMembers
+	g.logger = log.Logger.With().
+		Str("namespace", "portfolio_group").
+		Int64("id", id).
+		Str("name", name).
+		Logger()
+	return g
+}
+
+// Members returns names of member portfolios sorted alphabetically
+func (g *Group) Members() []string {
+	g.membersMu.RLock()
+	names := make([]string, 0, len(g.members))
+	for name := range g.members {
+		names = append(names, name)
+	}
+	g.membersMu.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// addMember joins portfolio to group and revalues group
+func (g *Group) addMember(portf *Portfolio) {
+	g.membersMu.Lock()
+	g.members[portf.name] = portf
+	g.membersMu.Unlock()
+
+	portf.groupsMu.Lock()
+	portf.groups[g.name] = g
+	portf.groupsMu.Unlock()
+
+	g.notify()
+}
+
+// removeMember leaves portfolio from group and revalues group
+func (g *Group) removeMember(portf *Portfolio) {
+	g.membersMu.Lock()
+	delete(g.members, portf.name)
+	g.membersMu.Unlock()
+
+	portf.groupsMu.Lock()
+	delete(portf.groups, g.name)
+	portf.groupsMu.Unlock()
+
+	g.notify()
+}
+
+// notify notifies group's goroutine about revaluation of member without blocking
+func (g *Group) notify() {
+	select {
+	case g.revalueCh <- struct{}{}:
+	default:
+	}
+}
+
+// start listens revaluations of members in separate goroutine
+func (g *Group) start() {
+	if atomic.SwapUint32(&g.closed, 0) == 0 {
+		return
+	}
+
+	g.logger.Info().Msg("Portfolio group has started")
+
+	go func() {
+		defer g.logger.Info().Msg("Portfolio group has been closed")
+
+		expiry := time.NewTicker(ExpiryCheckInterval)
+		defer expiry.Stop()
+
+		for {
+			select {
+			case <-g.closedCh:
+				return
+			case <-g.revalueCh:
+				data, valued, err := g.aggregateMembers(context.Background())
+				if err != nil {
+					g.logger.Error().Stack().Err(err).Msg("Failed to handle revaluation of members")
+					continue
+				}
+				// Triggers aren't evaluated by zero Data of group without valued members
+				if valued {
+					g.handleData(data)
+				}
+			case <-expiry.C:
+				g.triggersMu.Lock()
+				g.expireTriggers(g.now())
+				g.triggersMu.Unlock()
+			}
+		}
+	}()
+}
+
+// revalueMembers aggregates the latest Data of members saving it into Redis.
+// Members which haven't revalued since process start are skipped, as their Data in Redis is left from the last run
+func (g *Group) revalueMembers(ctx context.Context) (*Data, error) {
+	data, _, err := g.aggregateMembers(ctx)
+	return data, err
+}
+
+// aggregateMembers is revalueMembers reporting whether Data of some member is aggregated
+func (g *Group) aggregateMembers(ctx context.Context) (*Data, bool, error) {
+	g.membersMu.RLock()
+	members := make([]Data, 0, len(g.members))
+	for name, member := range g.members {
+		if !member.dataHolder.Saved() {
+			continue
+		}
+		data, err := member.dataHolder.Get(ctx)
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			g.membersMu.RUnlock()
+			return nil, false, errors.Wrapf(err, "failed to get data of member %q", name)
+		}
+		members = append(members, *data)
+	}
+	g.membersMu.RUnlock()
+
+	data, balances := aggregateData(members, g.currencies)
+	if err := g.dataHolder.Save(ctx, *data, balances); err != nil {
+		return nil, false, err
+	}
+	return data, len(members) > 0, nil
+}
+
+// aggregateData sums details of assets and totals of members in currencies. Prices are average values of asset's unit,
+// price of the first member is taken if asset isn't held. PriceInfo is the worst one among members, price is UNPRICED
+// if some member doesn't value asset in currency. Pricing is set if all members share it.
+// Raw balances of assets summed across members are returned also
+func aggregateData(members []Data, currencies []Currency) (*Data, map[core.Currency]core.Balance) {
+	data := &Data{
+		Prices:    make(map[core.Currency]ConvertedTo),
+		PriceInfo: make(map[core.Currency]map[Currency]PriceInfo),
+		Balance: Balances{
+			Total:     make(ConvertedTo, len(currencies)),
+			Available: make(ConvertedTo, len(currencies)),
+			Locked:    make(ConvertedTo, len(currencies)),
+			Details:   make(map[core.Currency]AssetBalance),
+		},
+	}
+	balances := make(map[core.Currency]core.Balance)
+	for i, member := range members {
+		if i == 0 {
+			data.Pricing = member.Pricing
+		} else if member.Pricing != data.Pricing {
+			data.Pricing = 0
+		}
+		if data.FX == nil {
+			data.FX = member.FX
+		}
+
+		for cur, details := range member.Balance.Details {
+			bal := balances[cur]
+			balances[cur] = core.Balance{
+				Available: bal.Available.Add(details.Available.Quantity),
+				Locked:    bal.Locked.Add(details.Locked.Quantity),
+			}
+			data.Balance.Details[cur] = data.Balance.Details[cur].add(details, currencies)
+
+			infos, ok := data.PriceInfo[cur]
+			if !ok {
+				infos = make(map[Currency]PriceInfo, len(currencies))
+				data.PriceInfo[cur] = infos
+			}
+			for _, quote := range currencies {
+				info, ok := member.PriceInfo[cur][quote]
+				if _, valued := details.Total.Value[quote]; !ok || !valued {
+					info = PriceInfo{Status: PriceUnpriced}
+				}
+				if worst, ok := infos[quote]; !ok || worst.Status < info.Status {
+					infos[quote] = info
+				}
+			}
+		}
+	}
+
+	for cur, details := range data.Balance.Details {
+		prices := make(ConvertedTo, len(currencies))
+		for _, quote := range currencies {
+			if !details.Total.Quantity.IsZero() {
+				prices[quote] = details.Total.Value[quote].Div(details.Total.Quantity)
+			} else {
+				prices[quote] = firstPrice(members, cur, quote)
+			}
+			data.Balance.Total[quote] = data.Balance.Total[quote].Add(details.Total.Value[quote])
+			data.Balance.Available[quote] = data.Balance.Available[quote].Add(details.Available.Value[quote])
+			data.Balance.Locked[quote] = data.Balance.Locked[quote].Add(details.Locked.Value[quote])
+		}
+		data.Prices[cur] = prices
+	}
+	data.Quality = newQuality(balances, data.PriceInfo)
+	return data, balances
+}
+
+// firstPrice returns price of currency in quote currency by the first member valuing it, zero if nobody does
+func firstPrice(members []Data, cur core.Currency, quote Currency) decimal.Decimal {
+	for _, member := range members {
+		if price, ok := member.Prices[cur][quote]; ok && !price.IsZero() {
+			return price
+		}
+	}
+	return decimal.Decimal{}
+}
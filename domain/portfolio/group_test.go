@@ -0,0 +1,243 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/pg"
+	"github.com/egsam98/portfolio/pg/repo"
+	"github.com/egsam98/portfolio/test/mocks"
+)
+
+func TestAggregateData(t *testing.T) {
+	currencies := []Currency{USDT, BTC}
+	members := []Data{
+		{
+			Pricing: PricingAsk,
+			Prices: map[core.Currency]ConvertedTo{
+				"ETH": {USDT: decimal.NewDecimal(100, 0), BTC: decimal.NewDecimal(5, 3)},
+			},
+			PriceInfo: map[core.Currency]map[Currency]PriceInfo{
+				"ETH": {USDT: {Source: "binance", Status: PriceOK}, BTC: {Source: "binance", Status: PriceOK}},
+			},
+			Balance: Balances{
+				Details: map[core.Currency]AssetBalance{
+					"ETH": newAssetBalance(core.Balance{Available: decimal.NewDecimal(1, 0)},
+						ConvertedTo{USDT: decimal.NewDecimal(100, 0), BTC: decimal.NewDecimal(5, 3)}),
+				},
+			},
+		},
+		{
+			Pricing: PricingBid,
+			Prices: map[core.Currency]ConvertedTo{
+				"ETH":  {USDT: decimal.NewDecimal(110, 0)},
+				"DOGE": {USDT: decimal.NewDecimal(1, 1)},
+			},
+			PriceInfo: map[core.Currency]map[Currency]PriceInfo{
+				"ETH":  {USDT: {Source: "kraken", Status: PriceStale}},
+				"DOGE": {USDT: {Source: "kraken", Status: PriceOK}},
+			},
+			Balance: Balances{
+				Details: map[core.Currency]AssetBalance{
+					"ETH": newAssetBalance(core.Balance{Available: decimal.NewDecimal(1, 0), Locked: decimal.NewDecimal(1, 0)},
+						ConvertedTo{USDT: decimal.NewDecimal(110, 0)}),
+					"DOGE": newAssetBalance(core.Balance{}, ConvertedTo{USDT: decimal.NewDecimal(1, 1)}),
+				},
+			},
+		},
+	}
+
+	data, balances := aggregateData(members, currencies)
+	assert.Zero(t, data.Pricing)
+	if assert.Contains(t, balances, core.Currency("ETH")) {
+		assert.True(t, decimal.NewDecimal(2, 0).Eq(balances["ETH"].Available))
+		assert.True(t, decimal.NewDecimal(1, 0).Eq(balances["ETH"].Locked))
+	}
+
+	eth := data.Balance.Details["ETH"]
+	assert.True(t, decimal.NewDecimal(3, 0).Eq(eth.Total.Quantity))
+	assert.True(t, decimal.NewDecimal(320, 0).Eq(eth.Total.Value[USDT]))
+	assert.True(t, decimal.NewDecimal(110, 0).Eq(eth.Locked.Value[USDT]))
+	assert.True(t, decimal.NewDecimal(5, 3).Eq(eth.Total.Value[BTC]))
+	assert.True(t, decimal.NewDecimal(320, 0).Div(decimal.NewDecimal(3, 0)).Eq(data.Prices["ETH"][USDT]))
+	assert.True(t, decimal.NewDecimal(1, 1).Eq(data.Prices["DOGE"][USDT]))
+	assert.True(t, decimal.NewDecimal(320, 0).Eq(data.Balance.Total[USDT]))
+	assert.True(t, decimal.NewDecimal(210, 0).Eq(data.Balance.Available[USDT]))
+
+	// The worst status is taken, second member doesn't value ETH in BTC
+	assert.Equal(t, PriceInfo{Source: "kraken", Status: PriceStale}, data.PriceInfo["ETH"][USDT])
+	assert.Equal(t, PriceUnpriced, data.PriceInfo["ETH"][BTC].Status)
+	// DOGE isn't held
+	assert.Equal(t, 0, data.Quality.OK)
+	assert.Equal(t, 1, data.Quality.Stale)
+	assert.Equal(t, 1, data.Quality.Unpriced)
+	assert.True(t, data.Quality.Completeness.IsZero())
+
+	t.Run("when members share pricing", func(t *testing.T) {
+		members[1].Pricing = PricingAsk
+		data, _ := aggregateData(members, currencies)
+		assert.Equal(t, PricingAsk, data.Pricing)
+	})
+
+	t.Run("when no members", func(t *testing.T) {
+		data, balances := aggregateData(nil, currencies)
+		assert.Empty(t, balances)
+		assert.Empty(t, data.Balance.Details)
+		assert.True(t, decimal.NewDecimal(100, 0).Eq(data.Quality.Completeness))
+	})
+}
+
+func TestGroup_revalueMembers(t *testing.T) {
+	ctx := context.Background()
+	memberData := Data{
+		Prices: map[core.Currency]ConvertedTo{"ETH": {USDT: decimal.NewDecimal(100, 0)}},
+		PriceInfo: map[core.Currency]map[Currency]PriceInfo{
+			"ETH": {USDT: {Status: PriceOK}},
+		},
+		Balance: Balances{
+			Details: map[core.Currency]AssetBalance{
+				"ETH": newAssetBalance(core.Balance{Available: decimal.NewDecimal(2, 0)},
+					ConvertedTo{USDT: decimal.NewDecimal(100, 0)}),
+			},
+		},
+	}
+	dataJSON, _ := json.Marshal(memberData)
+
+	rdbMock := mocks.NewRedisClient(t)
+	getCmd := &redis.StringCmd{}
+	getCmd.SetVal(string(dataJSON))
+	rdbMock.
+		On("Get", ctx, "portfolio:a").
+		Return(getCmd)
+	getCmd = &redis.StringCmd{}
+	getCmd.SetErr(redis.Nil)
+	rdbMock.
+		On("Get", ctx, "portfolio:b").
+		Return(getCmd)
+	rdbMock.
+		On("Set", ctx, "portfolio:group:desk", mock.Anything, time.Duration(0)).
+		Return(&redis.StatusCmd{})
+	rdbMock.
+		On("Set", ctx, "portfolio:a", mock.Anything, time.Duration(0)).
+		Return(&redis.StatusCmd{})
+
+	cfg := Config{Currencies: []Currency{USDT}}
+	group := NewGroup(1, "desk", nil, rdbMock, cfg)
+	a := NewPortfolio(2, "a", nil, rdbMock, nil, nil, cfg)
+	b := NewPortfolio(3, "b", nil, rdbMock, nil, nil, cfg)
+	group.addMember(a)
+	group.addMember(b)
+	assert.Equal(t, []string{"a", "b"}, group.Members())
+
+	// Revaluation of member notifies group
+	<-group.revalueCh
+	a.notifyGroups()
+	select {
+	case <-group.revalueCh:
+	default:
+		t.Error("group isn't notified")
+	}
+
+	// Data left in Redis by the last run isn't aggregated
+	data, valued, err := group.aggregateMembers(ctx)
+	if assert.NoError(t, err) {
+		assert.False(t, valued, "no member has revalued")
+		assert.True(t, data.Balance.Total[USDT].IsZero())
+	}
+
+	// Members revalue, but data of b is missing in Redis
+	assert.NoError(t, a.dataHolder.Save(ctx, memberData, nil))
+	b.dataHolder.saved = true
+	data, err = group.revalueMembers(ctx)
+	if assert.NoError(t, err) {
+		assert.True(t, decimal.NewDecimal(200, 0).Eq(data.Balance.Total[USDT]))
+	}
+	assert.True(t, decimal.NewDecimal(200, 0).Eq(group.dataHolder.TotalBalance(BasisTotal, USDT)))
+	assert.True(t, decimal.NewDecimal(2, 0).Eq(group.dataHolder.Asset("ETH").Balance.Available))
+
+	t.Run("when member leaves", func(t *testing.T) {
+		group.removeMember(a)
+		assert.Equal(t, []string{"b"}, group.Members())
+		assert.Empty(t, a.groups)
+
+		data, valued, err := group.aggregateMembers(ctx)
+		if assert.NoError(t, err) {
+			assert.False(t, valued, "no member is valued")
+			assert.True(t, data.Balance.Total[USDT].IsZero())
+		}
+		assert.True(t, group.dataHolder.Asset("ETH").Total.Quantity.IsZero())
+	})
+}
+
+func TestGroup_start(t *testing.T) {
+	ctx := context.Background()
+	qMock := mocks.NewQuerier(t)
+	db := &pg.DB{Queries: qMock}
+
+	rdbMock := mocks.NewRedisClient(t)
+	getCmd := &redis.StringCmd{}
+	getCmd.SetVal(`{}`)
+	rdbMock.
+		On("Get", ctx, "portfolio:a").
+		Return(getCmd)
+	getCmd = &redis.StringCmd{}
+	getCmd.SetErr(redis.Nil)
+	rdbMock.
+		On("Get", ctx, mock.Anything).
+		Return(getCmd).
+		Maybe()
+	rdbMock.
+		On("Set", ctx, mock.Anything, mock.Anything, time.Duration(0)).
+		Return(&redis.StatusCmd{})
+
+	group := NewGroup(1, "desk", db, rdbMock, Config{})
+	trigger := NewCostReachedLimit(group.Portfolio, USDT, decimal.Decimal{})
+	group.addTriggers([]Trigger{trigger})
+	member := NewPortfolio(2, "a", db, rdbMock, nil, nil, Config{})
+	group.addMember(member)
+	<-group.revalueCh
+	group.start()
+	t.Cleanup(func() { group.Close(false) })
+
+	deleted := make(chan struct{})
+	qMock.
+		On("PortfolioTriggerEvents_Create", ctx, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			params := args.Get(1).(repo.PortfolioTriggerEvents_CreateParams)
+			assert.Equal(t, int64(1), params.PortfolioID)
+		}).
+		Once()
+	qMock.
+		On("PortfolioTriggerEventsOutbox_Create", ctx, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			params := args.Get(1).(repo.PortfolioTriggerEventsOutbox_CreateParams)
+			var event TriggerEvent
+			if assert.NoError(t, json.Unmarshal(params.Payload, &event)) {
+				assert.Equal(t, "desk", event.Portfolio)
+				assert.True(t, event.Group)
+			}
+		}).
+		Once()
+	qMock.
+		On("PortfolioTriggers_Delete", ctx, trigger.ID()).
+		Return(nil).
+		Run(func(mock.Arguments) { close(deleted) }).
+		Once()
+
+	assert.NoError(t, member.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+	select {
+	case <-deleted:
+	case <-time.After(time.Second):
+		t.Fatal("group trigger isn't executed on revaluation of member")
+	}
+}
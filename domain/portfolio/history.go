@@ -0,0 +1,83 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/core"
+
+	"github.com/egsam98/portfolio/pg/repo"
+)
+
+// Snapshot is a state of portfolio's balances (by BasisTotal) converted to Currency types at certain time
+type Snapshot struct {
+	Timestamp int64                         `json:"timestamp" validate:"required" format:"timestamp"`
+	Total     ConvertedTo                   `json:"total" validate:"required"`
+	Details   map[core.Currency]ConvertedTo `json:"details" validate:"required"`
+}
+
+// History returns portfolio's Snapshot-s within [from, to) time range downsampled by resolution:
+// the last snapshot is taken for every resolution period
+func (p *Portfolio) History(ctx context.Context, from, to time.Time, resolution time.Duration) ([]Snapshot, error) {
+	rows, err := p.db.Queries.PortfolioSnapshots_SelectDownsampled(ctx, repo.PortfolioSnapshots_SelectDownsampledParams{
+		Resolution:  int64(resolution / time.Second),
+		PortfolioID: p.id,
+		FromTime:    from.UTC(),
+		ToTime:      to.UTC(),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to select snapshots of portfolio %q", p.name)
+	}
+
+	snapshots := make([]Snapshot, len(rows))
+	for i, row := range rows {
+		snapshots[i].Timestamp = row.Bucket.Unix()
+		if err := json.Unmarshal(row.Total, &snapshots[i].Total); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal %s into %T", string(row.Total), snapshots[i].Total)
+		}
+		if err := json.Unmarshal(row.Details, &snapshots[i].Details); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal %s into %T", string(row.Details), snapshots[i].Details)
+		}
+	}
+	return snapshots, nil
+}
+
+// saveSnapshot saves Data's balances as a Snapshot into database if history is enabled
+// and Config.HistoryInterval is passed since the last one
+func (p *Portfolio) saveSnapshot(ctx context.Context, data *Data) error {
+	if !p.cfg.HistoryEnabled {
+		return nil
+	}
+
+	now := p.now().UTC()
+	if now.Sub(p.lastSnapshotAt) < p.cfg.HistoryInterval {
+		return nil
+	}
+
+	total, err := json.Marshal(data.Balance.Total)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %T", data.Balance.Total)
+	}
+	values := make(map[core.Currency]ConvertedTo, len(data.Balance.Details))
+	for cur, details := range data.Balance.Details {
+		values[cur] = details.Total.Value
+	}
+	details, err := json.Marshal(values)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %T", values)
+	}
+
+	if err := p.db.Queries.PortfolioSnapshots_Create(ctx, repo.PortfolioSnapshots_CreateParams{
+		PortfolioID: p.id,
+		Total:       total,
+		Details:     details,
+		CreatedAt:   now,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to save snapshot of portfolio %q", p.name)
+	}
+
+	p.lastSnapshotAt = now
+	return nil
+}
@@ -0,0 +1,106 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/pg"
+	"github.com/egsam98/portfolio/pg/repo"
+	"github.com/egsam98/portfolio/test/mocks"
+)
+
+func TestPortfolio_History(t *testing.T) {
+	ctx := context.Background()
+	qMock := mocks.NewQuerier(t)
+	db := &pg.DB{Queries: qMock}
+	portf := NewPortfolio(1, "", db, nil, nil, nil, Config{})
+
+	from := time.Unix(1654586400, 0)
+	to := from.Add(time.Hour)
+	qMock.
+		On("PortfolioSnapshots_SelectDownsampled", ctx, repo.PortfolioSnapshots_SelectDownsampledParams{
+			Resolution:  300,
+			PortfolioID: 1,
+			FromTime:    from.UTC(),
+			ToTime:      to.UTC(),
+		}).
+		Return([]repo.PortfolioSnapshots_SelectDownsampledRow{
+			{
+				Bucket:  from,
+				Total:   []byte(`{"USDT":200,"BTC":0.01}`),
+				Details: []byte(`{"ETH":{"USDT":200,"BTC":0.01}}`),
+			},
+		}, nil)
+
+	history, err := portf.History(ctx, from, to, 5*time.Minute)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, from.Unix(), history[0].Timestamp)
+		assert.True(t, decimal.NewDecimal(200, 0).Eq(history[0].Total[USDT]))
+		assert.True(t, decimal.NewDecimal(1, 2).Eq(history[0].Details["ETH"][BTC]))
+	}
+}
+
+func TestPortfolio_saveSnapshot(t *testing.T) {
+	ctx := context.Background()
+	qMock := mocks.NewQuerier(t)
+	db := &pg.DB{Queries: qMock}
+	portf := NewPortfolio(1, "", db, nil, nil, nil, Config{
+		HistoryEnabled:  true,
+		HistoryInterval: time.Hour,
+	})
+	now := time.Unix(1654586492, 0).UTC()
+	portf.now = func() time.Time { return now }
+
+	data := &Data{}
+	data.Balance.Total = ConvertedTo{USDT: decimal.NewDecimal(200, 0)}
+	data.Balance.Details = map[core.Currency]AssetBalance{
+		"ETH": newAssetBalance(core.Balance{Available: decimal.NewDecimal(1, 0)}, ConvertedTo{USDT: decimal.NewDecimal(200, 0)}),
+	}
+
+	qMock.
+		On("PortfolioSnapshots_Create", ctx, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			params := args.Get(1).(repo.PortfolioSnapshots_CreateParams)
+			assert.EqualValues(t, 1, params.PortfolioID)
+			assert.Equal(t, now, params.CreatedAt)
+			var total ConvertedTo
+			assert.NoError(t, json.Unmarshal(params.Total, &total))
+			assert.True(t, decimal.NewDecimal(200, 0).Eq(total[USDT]))
+			var details map[core.Currency]ConvertedTo
+			assert.NoError(t, json.Unmarshal(params.Details, &details))
+			assert.True(t, decimal.NewDecimal(200, 0).Eq(details["ETH"][USDT]))
+		}).
+		Once()
+
+	assert.NoError(t, portf.saveSnapshot(ctx, data))
+
+	t.Run("when interval isn't passed", func(t *testing.T) {
+		now = now.Add(time.Hour / 2)
+		assert.NoError(t, portf.saveSnapshot(ctx, data))
+	})
+
+	t.Run("when interval is passed", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		qMock.
+			On("PortfolioSnapshots_Create", ctx, mock.MatchedBy(func(params repo.PortfolioSnapshots_CreateParams) bool {
+				return params.CreatedAt.Equal(now)
+			})).
+			Return(nil).
+			Once()
+		assert.NoError(t, portf.saveSnapshot(ctx, data))
+	})
+
+	t.Run("when history is disabled", func(t *testing.T) {
+		portf := NewPortfolio(1, "", db, nil, nil, nil, Config{})
+		assert.NoError(t, portf.saveSnapshot(ctx, data))
+	})
+}
@@ -0,0 +1,243 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+var IND = RegisterTriggerType(TriggerKind{
+	Name:    "INDICATOR",
+	Gateway: true,
+	Decode: func(data []byte) (TriggerParams, error) {
+		params := new(IndicatorParams)
+		err := json.Unmarshal(data, params)
+		return params, errors.WithStack(err)
+	},
+	New: func(portf *Portfolio, currency Currency, basis BalanceBasis, params TriggerParams) (Trigger, error) {
+		i := &Indicator{
+			triggerBase: newTriggerBase(portf, currency, basis),
+			params:      *params.(*IndicatorParams),
+			lastValues:  make(map[string]decimal.Decimal),
+		}
+		// Configured instrument is checked and subscribed to price updates in advance
+		if i.params.Symbol != "" {
+			if _, err := portf.instrument(i.params.Symbol); err != nil {
+				return nil, err
+			}
+		}
+		return i, nil
+	},
+	Restore: func(portf *Portfolio, state TriggerState, params TriggerParams) (Trigger, error) {
+		return &Indicator{
+			triggerBase: triggerBase{portf: portf, state: state},
+			params:      *params.(*IndicatorParams),
+			lastValues:  make(map[string]decimal.Decimal),
+		}, nil
+	},
+})
+
+type IndicatorKind uint8
+
+const (
+	IndicatorRSI IndicatorKind = iota + 1
+	IndicatorMACD
+	IndicatorBollinger
+)
+
+var (
+	indicatorKindKeyValues = map[IndicatorKind]string{
+		IndicatorRSI:       "RSI",
+		IndicatorMACD:      "MACD",
+		IndicatorBollinger: "BOLLINGER",
+	}
+	indicatorKindValueKeys = map[string]IndicatorKind{
+		"RSI":       IndicatorRSI,
+		"MACD":      IndicatorMACD,
+		"BOLLINGER": IndicatorBollinger,
+	}
+)
+
+func (i IndicatorKind) String() string {
+	return indicatorKindKeyValues[i]
+}
+
+func (i IndicatorKind) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+func (i *IndicatorKind) UnmarshalText(text []byte) error {
+	txt := string(text)
+	if kind, ok := indicatorKindValueKeys[txt]; ok {
+		*i = kind
+		return nil
+	}
+	return errors.Errorf("invalid indicator: %s", txt)
+}
+
+// IndicatorParams
+// Indicator of gateway's instrument Symbol or, if Symbol is empty, of every held asset's instrument quoted in trigger's currency (ex. ETHUSDT).
+// RSI and MACD are compared with LimitCondition (ex. RSI crosses 70).
+// BOLLINGER condition is met when ask price leaves Bollinger band, so LimitCondition isn't set
+type IndicatorParams struct {
+	Indicator IndicatorKind `json:"indicator"`
+	Symbol    string        `json:"symbol,omitempty"`
+	LimitCondition
+}
+
+func (i *IndicatorParams) Validate() error {
+	switch i.Indicator {
+	case IndicatorRSI, IndicatorMACD:
+		return i.LimitCondition.Validate()
+	case IndicatorBollinger:
+		if i.Limit != nil || i.Direction != 0 || i.Low != nil || i.High != nil {
+			return errors.New("limit, direction and band can't be set for BOLLINGER indicator")
+		}
+		return nil
+	default:
+		return errors.New("indicator is required")
+	}
+}
+
+// IndicatorValues are indicators of instrument at execution time. Ok is true if instrument meets trigger's condition
+type IndicatorValues struct {
+	Symbol        string          `json:"symbol"`
+	Ok            bool            `json:"ok"`
+	Price         decimal.Decimal `json:"price"`
+	RSI           float64         `json:"rsi"`
+	MACD          float64         `json:"macd"`
+	BollingerLow  float64         `json:"bollinger_low"`
+	BollingerHigh float64         `json:"bollinger_high"`
+}
+
+// IndicatorDetails list indicators of all checked instruments sorted by symbol
+type IndicatorDetails struct {
+	Instruments []IndicatorValues `json:"instruments"`
+}
+
+// Indicator is a trigger executing when technical indicator (RSI, MACD, Bollinger band) of any checked instrument meets condition.
+// Instruments of held assets without market in trigger's currency are skipped
+type Indicator struct {
+	triggerBase
+	params     IndicatorParams
+	lastValues map[string]decimal.Decimal // indicator values of previous check by symbol
+}
+
+// TryExecute returns non-empty ExecutionStatus if trigger is executed.
+// ExecutionStatus.CurrentValue is an indicator value (ask price for BOLLINGER) of the first matched instrument
+// or the first checked one if none is matched, ExecutionStatus.Details are IndicatorDetails.
+// ExecutionStatus.Done is always equal to ExecutionStatus.Ok for this type of trigger
+func (i *Indicator) TryExecute() (*ExecutionStatus, error) {
+	symbols, insts, err := i.instruments()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ExecutionStatus{}
+	details := IndicatorDetails{Instruments: make([]IndicatorValues, 0, len(insts))}
+	for j, inst := range insts {
+		values, value := i.check(symbols[j], inst)
+		if values.Ok && !status.Ok || len(details.Instruments) == 0 {
+			status.CurrentValue = value
+		}
+		if values.Ok {
+			status.Ok = true
+		}
+		details.Instruments = append(details.Instruments, values)
+	}
+	status.Done = status.Ok
+	status.Details = details
+	return status, nil
+}
+
+// check checks indicator of symbol's instrument. It returns all indicators and compared value
+func (i *Indicator) check(symbol string, inst core.Instrument) (IndicatorValues, decimal.Decimal) {
+	_, ask := inst.Price()
+	values := IndicatorValues{
+		Symbol: symbol,
+		Price:  ask,
+		RSI:    inst.RSI(),
+		MACD:   inst.MACD(),
+	}
+	values.BollingerLow, values.BollingerHigh = inst.Bollinger()
+
+	switch i.params.Indicator {
+	case IndicatorBollinger:
+		low, high := decimal.FloatToDecimal(values.BollingerLow), decimal.FloatToDecimal(values.BollingerHigh)
+		values.Ok = !ask.IsZero() && (ask.LessThan(low) || high.LessThan(ask))
+		return values, ask
+	default:
+		value := decimal.FloatToDecimal(values.RSI)
+		if i.params.Indicator == IndicatorMACD {
+			value = decimal.FloatToDecimal(values.MACD)
+		}
+		var lastValue *decimal.Decimal
+		if last, ok := i.lastValues[symbol]; ok {
+			lastValue = &last
+		}
+		i.lastValues[symbol] = value
+		values.Ok = i.params.Check(value, lastValue)
+		return values, value
+	}
+}
+
+// instruments returns configured instrument or instruments of held assets along with their symbols sorted
+func (i *Indicator) instruments() ([]string, []core.Instrument, error) {
+	if i.params.Symbol != "" {
+		inst, err := i.portf.instrument(i.params.Symbol)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []string{i.params.Symbol}, []core.Instrument{inst}, nil
+	}
+
+	quote := core.Currency(i.state.Currency.String())
+	var symbols []string
+	for cur, asset := range i.portf.dataHolder.Assets() {
+		if cur == quote || asset.basis(i.state.Basis).Quantity.IsZero() {
+			continue
+		}
+		// Gateway's name of symbol pairing canonical currencies
+		if path, ok := i.portf.conversionGraph().Path(cur, quote); ok && len(path) == 1 && !path[0].Inverse {
+			symbols = append(symbols, path[0].Symbol)
+		}
+	}
+	sort.Strings(symbols)
+
+	var held []string
+	var insts []core.Instrument
+	for _, symbol := range symbols {
+		inst, err := i.portf.instrument(symbol)
+		if err != nil {
+			continue
+		}
+		held = append(held, symbol)
+		insts = append(insts, inst)
+	}
+	return held, insts, nil
+}
+
+func (i *Indicator) Settings() TriggerSettings {
+	params := i.params
+	return i.settings(IND, &params)
+}
+
+// Update returns copy of trigger with patched params. Previous indicator values are reset
+func (i *Indicator) Update(patch json.RawMessage) (Trigger, error) {
+	params, err := patchTriggerParams(IND, &i.params, patch)
+	if err != nil {
+		return nil, err
+	}
+	updated := *i
+	updated.params = *params.(*IndicatorParams)
+	if updated.params.Symbol != "" && updated.params.Symbol != i.params.Symbol {
+		if _, err := i.portf.instrument(updated.params.Symbol); err != nil {
+			return nil, errors.Wrap(ErrInvalidTriggerUpdate, err.Error())
+		}
+	}
+	updated.lastValues = make(map[string]decimal.Decimal)
+	return &updated, nil
+}
@@ -0,0 +1,92 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/test/mocks"
+)
+
+func TestIndicator_TryExecute(t *testing.T) {
+	rsi := map[string]float64{"ETHUSDT": 65, "SOLUSDT": 40}
+	gwMock := mocks.NewGateway(t)
+	for symbol := range rsi {
+		symbol := symbol
+		inst := mocks.NewInstrument(t)
+		inst.On("OnPriceUpdate", mock.Anything).Return().Once()
+		inst.On("Price").Return(decimal.NewDecimal(100, 0), decimal.NewDecimal(101, 0))
+		inst.On("RSI").Return(func() float64 { return rsi[symbol] })
+		inst.On("MACD").Return(0.5)
+		inst.On("Bollinger").Return(90.0, 100.0)
+		gwMock.On("Instrument", symbol).Return(inst, nil).Once()
+	}
+	gwMock.On("Instrument", mock.Anything).Return(nil, errors.New("not found"))
+	gwMock.On("Name").Return("test")
+	gwMock.On("AllSymbols").Return([]core.Symbol{{Base: "ETH", Quote: "USDT"}, {Base: "SOL", Quote: "USDT"}})
+
+	portf := NewPortfolio(0, "", nil, nil, gwMock, nil, Config{})
+	for _, cur := range []core.Currency{"ETH", "SOL", "DOGE", "USDT"} {
+		bal := core.Balance{Available: decimal.NewDecimal(1, 0)}
+		portf.dataHolder.assets[cur] = Asset{Balance: bal, AssetBalance: newAssetBalance(bal, nil)}
+	}
+
+	t.Run("RSI of held assets crosses limit", func(t *testing.T) {
+		trigger, err := NewTrigger(portf, IND, USDT, []byte(`{"indicator":"RSI","limit":"70","direction":"CROSSES"}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		status, err := trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.False(t, status.Ok)
+		if details, ok := status.Details.(IndicatorDetails); assert.True(t, ok) && assert.Len(t, details.Instruments, 2) {
+			assert.Equal(t, "ETHUSDT", details.Instruments[0].Symbol)
+			assert.Equal(t, "SOLUSDT", details.Instruments[1].Symbol)
+		}
+
+		rsi["SOLUSDT"] = 75
+		status, err = trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.True(t, status.Ok)
+		assert.True(t, decimal.NewDecimal(75, 0).Eq(status.CurrentValue))
+		if details, ok := status.Details.(IndicatorDetails); assert.True(t, ok) && assert.Len(t, details.Instruments, 2) {
+			assert.False(t, details.Instruments[0].Ok)
+			assert.True(t, details.Instruments[1].Ok)
+			assert.Equal(t, 75.0, details.Instruments[1].RSI)
+			assert.Equal(t, 0.5, details.Instruments[1].MACD)
+		}
+	})
+
+	t.Run("price leaves Bollinger band of symbol", func(t *testing.T) {
+		trigger, err := NewTrigger(portf, IND, USDT, []byte(`{"indicator":"BOLLINGER","symbol":"ETHUSDT"}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		status, err := trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.True(t, status.Ok)
+		assert.True(t, decimal.NewDecimal(101, 0).Eq(status.CurrentValue))
+		if details, ok := status.Details.(IndicatorDetails); assert.True(t, ok) && assert.Len(t, details.Instruments, 1) {
+			assert.Equal(t, 90.0, details.Instruments[0].BollingerLow)
+			assert.Equal(t, 100.0, details.Instruments[0].BollingerHigh)
+		}
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		for _, params := range []string{
+			`{"limit":"70"}`,
+			`{"indicator":"RSI"}`,
+			`{"indicator":"BOLLINGER","limit":"1"}`,
+			`{"indicator":"MACD","symbol":"UNKNOWN","limit":"1"}`,
+		} {
+			_, err := NewTrigger(portf, IND, USDT, []byte(params))
+			assert.Error(t, err, params)
+		}
+	})
+}
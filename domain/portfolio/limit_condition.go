@@ -0,0 +1,92 @@
+package portfolio
+
+import (
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+type LimitDirection uint8
+
+const (
+	LimitAbove LimitDirection = iota + 1
+	LimitBelow
+	LimitCrosses
+)
+
+var (
+	limitDirectionKeyValues = map[LimitDirection]string{
+		LimitAbove:   "ABOVE",
+		LimitBelow:   "BELOW",
+		LimitCrosses: "CROSSES",
+	}
+	limitDirectionValueKeys = map[string]LimitDirection{
+		"ABOVE":   LimitAbove,
+		"BELOW":   LimitBelow,
+		"CROSSES": LimitCrosses,
+	}
+)
+
+func (l LimitDirection) String() string {
+	return limitDirectionKeyValues[l]
+}
+
+func (l LimitDirection) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *LimitDirection) UnmarshalText(text []byte) error {
+	txt := string(text)
+	if direction, ok := limitDirectionValueKeys[txt]; ok {
+		*l = direction
+		return nil
+	}
+	return errors.Errorf("invalid limit direction: %s", txt)
+}
+
+// LimitCondition is a trigger params part comparing value with limit.
+// Direction is applied to Limit: ABOVE (default) - value >= limit, BELOW - value < limit,
+// CROSSES - value moves to other side of limit since previous check.
+// Low and High set band mode instead of Limit: condition is met when value leaves band [Low, High]
+type LimitCondition struct {
+	Limit     *decimal.Decimal `json:"limit,omitempty"`
+	Direction LimitDirection   `json:"direction,omitempty"`
+	Low       *decimal.Decimal `json:"low,omitempty"`
+	High      *decimal.Decimal `json:"high,omitempty"`
+}
+
+func (l *LimitCondition) Validate() error {
+	if l.Low != nil || l.High != nil {
+		if l.Low == nil || l.High == nil {
+			return errors.New("both low and high are required for band")
+		}
+		if !l.Low.LessThan(*l.High) {
+			return errors.New("low must be less than high")
+		}
+		if l.Limit != nil || l.Direction != 0 {
+			return errors.New("limit and direction can't be set along with band")
+		}
+		return nil
+	}
+
+	if l.Limit == nil || l.Limit.IsZero() {
+		return errors.New("limit is required")
+	}
+	return nil
+}
+
+// Check returns true if value meets condition. Previous value is nil if it's the first check
+func (l *LimitCondition) Check(value decimal.Decimal, prev *decimal.Decimal) bool {
+	if l.Low != nil && l.High != nil {
+		return value.LessThan(*l.Low) || l.High.LessThan(value)
+	}
+
+	limit := *l.Limit
+	switch l.Direction {
+	case LimitBelow:
+		return value.LessThan(limit)
+	case LimitCrosses:
+		return prev != nil && prev.LessThan(limit) != value.LessThan(limit)
+	default:
+		return !value.LessThan(limit)
+	}
+}
@@ -0,0 +1,27 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitCondition_Validate(t *testing.T) {
+	for _, params := range []string{
+		`{}`,
+		`{"limit":"0"}`,
+		`{"low":"90"}`,
+		`{"low":"110","high":"90"}`,
+		`{"low":"90","high":"110","limit":"100"}`,
+		`{"low":"90","high":"110","direction":"BELOW"}`,
+	} {
+		var p LimitCondition
+		if assert.NoError(t, json.Unmarshal([]byte(params), &p)) {
+			assert.Error(t, p.Validate(), params)
+		}
+	}
+
+	var p LimitCondition
+	assert.Error(t, json.Unmarshal([]byte(`{"limit":"100","direction":"SIDEWAYS"}`), &p))
+}
@@ -2,6 +2,7 @@ package portfolio
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	"github.com/egsam98/portfolio/domain/gateways"
@@ -17,29 +18,46 @@ import (
 
 // Manager holds and controls multiple Portfolio-s
 type Manager struct {
-	db             *pg.DB
-	rdb            redis.UniversalClient
-	gwsMngr        gateways.Manager
-	portfolios     map[string]*Portfolio // account name is a key
-	portfoliosMu   sync.RWMutex
-	eventPublisher TriggerEventPublisher
-	logger         zerolog.Logger
+	db           *pg.DB
+	rdb          redis.UniversalClient
+	gwsMngr      gateways.Manager
+	portfolios   map[string]*Portfolio // account name is a key
+	portfoliosMu sync.RWMutex
+	groups       map[string]*Group // alias of accounts is a key
+	groupsMu     sync.RWMutex
+	graphs       map[string]*ConversionGraph // gateway name is a key
+	graphsMu     sync.Mutex
+	cfg          Config
+	logger       zerolog.Logger
 }
 
-func NewManager(db *pg.DB, rdb redis.UniversalClient, gwsMngr gateways.Manager, eventPublisher TriggerEventPublisher) *Manager {
+func NewManager(
+	db *pg.DB,
+	rdb redis.UniversalClient,
+	gwsMngr gateways.Manager,
+	cfg Config,
+) *Manager {
 	return &Manager{
-		db:             db,
-		rdb:            rdb,
-		gwsMngr:        gwsMngr,
-		portfolios:     make(map[string]*Portfolio),
-		eventPublisher: eventPublisher,
+		cfg:        cfg,
+		db:         db,
+		rdb:        rdb,
+		gwsMngr:    gwsMngr,
+		portfolios: make(map[string]*Portfolio),
+		groups:     make(map[string]*Group),
+		graphs:     make(map[string]*ConversionGraph),
 		logger: log.Logger.With().
 			Str("namespace", "portfolio_manager").
 			Logger(),
 	}
 }
 
-// Start loads all portfolios from database and starts them with restored triggers
+// Currencies returns all configured quote currencies (see Config.AllCurrencies)
+func (pm *Manager) Currencies() []Currency {
+	return pm.cfg.AllCurrencies()
+}
+
+// Start loads all portfolios from database and starts them with restored triggers.
+// Then groups of portfolios are loaded by aliases of accounts
 func (pm *Manager) Start(ctx context.Context) error {
 	accs, err := pm.db.Queries.Accounts_SelectWithPortfolioTriggers(ctx)
 	if err != nil {
@@ -51,6 +69,33 @@ func (pm *Manager) Start(ctx context.Context) error {
 			pm.logger.Error().Stack().Err(err).Msgf("Failed to load portfolio %q", account.Name)
 		}
 	}
+
+	// Groups start with all their members to avoid evaluation of triggers by partial data
+	members := make(map[string][]*Portfolio)
+	var aliases []string
+	for _, account := range accs {
+		portf, err := pm.Portfolio(account.Name)
+		if err != nil {
+			continue
+		}
+		for _, alias := range account.Aliases {
+			if _, ok := members[alias]; !ok {
+				aliases = append(aliases, alias)
+			}
+			members[alias] = append(members[alias], portf)
+		}
+	}
+	for _, alias := range aliases {
+		group, err := pm.loadGroup(ctx, alias)
+		if err != nil {
+			pm.logger.Error().Stack().Err(err).Msgf("Failed to load portfolio group %q", alias)
+			continue
+		}
+		for _, portf := range members[alias] {
+			group.addMember(portf)
+		}
+		group.start()
+	}
 	return nil
 }
 
@@ -65,8 +110,34 @@ func (pm *Manager) Portfolio(name string) (*Portfolio, error) {
 	return portf, nil
 }
 
+// Group returns portfolio group registered in map by name
+func (pm *Manager) Group(name string) (*Group, error) {
+	pm.groupsMu.RLock()
+	group, ok := pm.groups[name]
+	pm.groupsMu.RUnlock()
+	if !ok {
+		return nil, errors.Wrap(ErrGroupNotFound, name)
+	}
+	return group, nil
+}
+
+// Groups returns all registered portfolio groups sorted by name
+func (pm *Manager) Groups() []*Group {
+	pm.groupsMu.RLock()
+	groups := make([]*Group, 0, len(pm.groups))
+	for _, group := range pm.groups {
+		groups = append(groups, group)
+	}
+	pm.groupsMu.RUnlock()
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].name < groups[j].name
+	})
+	return groups
+}
+
 // AddPortfolio searches account by name in database and starts new Portfolio for it.
-// Nothing happens if portfolio is registered by this name
+// Portfolio joins groups by aliases of account. Nothing happens if portfolio is registered by this name
 func (pm *Manager) AddPortfolio(name string) error {
 	pm.portfoliosMu.RLock()
 	_, ok := pm.portfolios[name] //nolint:ifshort
@@ -93,18 +164,30 @@ func (pm *Manager) AddPortfolio(name string) error {
 		return err
 	}
 
-	portf := NewPortfolio(account.ID, account.Name, pm.db, pm.rdb, gw, acc, pm.eventPublisher)
+	portf := NewPortfolio(account.ID, account.Name, pm.db, pm.rdb, gw, acc, pm.cfg)
+	portf.graph = pm.conversionGraph(gw)
 	pm.portfoliosMu.Lock()
 	if _, ok := pm.portfolios[account.Name]; !ok {
 		pm.portfolios[account.Name] = portf
 	}
 	pm.portfoliosMu.Unlock()
 
-	err = portf.start()
-	return errors.Wrapf(err, "failed to start portfolio scheduling for account %q", account.Name)
+	if err := portf.start(); err != nil {
+		return errors.Wrapf(err, "failed to start portfolio scheduling for account %q", account.Name)
+	}
+
+	for _, alias := range account.Aliases {
+		group, err := pm.loadGroup(context.Background(), alias)
+		if err != nil {
+			return err
+		}
+		group.addMember(portf)
+		group.start()
+	}
+	return nil
 }
 
-// DeletePortfolio destroys portfolio (see Portfolio.Destroy) and deletes from registered map.
+// DeletePortfolio destroys portfolio (see Portfolio.Close), deletes from registered map and leaves its groups.
 // Nothing happens if portfolio isn't registered by this name
 func (pm *Manager) DeletePortfolio(name string) error {
 	pm.portfoliosMu.RLock()
@@ -118,16 +201,32 @@ func (pm *Manager) DeletePortfolio(name string) error {
 	pm.portfoliosMu.Lock()
 	delete(pm.portfolios, name)
 	pm.portfoliosMu.Unlock()
+
+	portf.groupsMu.Lock()
+	groups := make([]*Group, 0, len(portf.groups))
+	for _, group := range portf.groups {
+		groups = append(groups, group)
+	}
+	portf.groupsMu.Unlock()
+	for _, group := range groups {
+		group.removeMember(portf)
+	}
 	return nil
 }
 
-// Close closes all registered portfolios
+// Close closes all registered portfolios and groups
 func (pm *Manager) Close() {
 	pm.portfoliosMu.RLock()
-	defer pm.portfoliosMu.RUnlock()
 	for _, portf := range pm.portfolios {
 		portf.Close(false)
 	}
+	pm.portfoliosMu.RUnlock()
+
+	pm.groupsMu.RLock()
+	for _, group := range pm.groups {
+		group.Close(false)
+	}
+	pm.groupsMu.RUnlock()
 	// TODO: graceful
 }
 
@@ -150,49 +249,11 @@ func (pm *Manager) load(account repo.Accounts_SelectWithPortfolioTriggersRow) er
 		return err
 	}
 
-	portf := NewPortfolio(account.ID, account.Name, pm.db, pm.rdb, gw, acc, pm.eventPublisher)
-
-	// Set restored triggers from database
-	if len(account.Triggers) > 0 {
-		triggers := make([]Trigger, 0, len(account.Triggers))
-		for _, dbt := range account.Triggers {
-			var cur Currency
-			if err := cur.Set(dbt.Currency); err != nil {
-				return err
-			}
-
-			var trigger Trigger
-			switch dbt.Type {
-			case CCBP.String():
-				if dbt.Percent == nil || dbt.StartTotalCost == nil {
-					return errors.Errorf("percent and start total cost are required for trigger type %q", dbt.Type)
-				}
-				ccbp := new(CostChangedByPercent)
-				ccbp.Restore(
-					portf,
-					dbt.ID,
-					cur,
-					*dbt.Percent,
-					*dbt.StartTotalCost,
-					dbt.TrailingAlert,
-					dbt.CreatedAt,
-				)
-				trigger = ccbp
-			case CRL.String():
-				if dbt.Limit == nil {
-					return errors.Errorf("limit is required for trigger type %q", dbt.Type)
-				}
-				crl := new(CostReachedLimit)
-				crl.Restore(portf, dbt.ID, cur, *dbt.Limit, dbt.CreatedAt)
-				trigger = crl
-			default:
-				continue
-			}
+	portf := NewPortfolio(account.ID, account.Name, pm.db, pm.rdb, gw, acc, pm.cfg)
+	portf.graph = pm.conversionGraph(gw)
 
-			triggers = append(triggers, trigger)
-		}
-
-		portf.addTriggers(triggers)
+	if err := pm.restoreTriggers(portf, account.Triggers); err != nil {
+		return err
 	}
 
 	pm.portfoliosMu.Lock()
@@ -205,6 +266,101 @@ func (pm *Manager) load(account repo.Accounts_SelectWithPortfolioTriggersRow) er
 	return errors.Wrapf(err, "failed to start portfolio scheduling for account %q", account.Name)
 }
 
+// loadGroup returns registered portfolio group by name. Otherwise group is saved into database (if it doesn't exist yet)
+// and registered with restored triggers. Registered group isn't started
+func (pm *Manager) loadGroup(ctx context.Context, name string) (*Group, error) {
+	pm.groupsMu.Lock()
+	defer pm.groupsMu.Unlock()
+	if group, ok := pm.groups[name]; ok {
+		return group, nil
+	}
+
+	id, err := pm.db.Queries.PortfolioGroups_Upsert(ctx, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to upsert portfolio group %q", name)
+	}
+	triggers, err := pm.db.Queries.PortfolioTriggers_SelectByPortfolioID(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to select triggers of portfolio group %q", name)
+	}
+
+	group := NewGroup(id, name, pm.db, pm.rdb, pm.cfg)
+	if err := pm.restoreTriggers(group.Portfolio, triggers); err != nil {
+		return nil, err
+	}
+	pm.groups[name] = group
+	return group, nil
+}
+
+// restoreTriggers attaches triggers restored from database to portfolio
+func (pm *Manager) restoreTriggers(portf *Portfolio, rows []repo.PortfolioTriggerRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	triggers := make([]Trigger, 0, len(rows))
+	for _, dbt := range rows {
+		var cur Currency
+		if err := cur.Set(dbt.Currency); err != nil {
+			return err
+		}
+		if !containsCurrency(portf.currencies, cur) {
+			pm.logger.Warn().
+				Str("trigger_id", dbt.ID.String()).
+				Msgf("Currency %s isn't configured for portfolio %q anymore", cur, portf.name)
+		}
+
+		var typ TriggerType
+		if err := typ.UnmarshalText([]byte(dbt.Type)); err != nil {
+			pm.logger.Warn().Err(err).Str("trigger_id", dbt.ID.String()).Msg("Not supported")
+			continue
+		}
+		schedule, err := restoreSchedule(dbt.ActiveFrom, dbt.ExpiresAt, dbt.ActiveHours)
+		if err != nil {
+			return errors.Wrapf(err, "failed to restore schedule of trigger %q", dbt.ID)
+		}
+		var basis BalanceBasis
+		if err := basis.UnmarshalText([]byte(dbt.BalanceBasis)); err != nil {
+			return errors.Wrapf(err, "failed to restore balance basis of trigger %q", dbt.ID)
+		}
+		trigger, err := restoreTrigger(portf, typ, TriggerState{
+			ID:        dbt.ID,
+			Currency:  cur,
+			Basis:     basis,
+			Paused:    dbt.Paused,
+			CreatedAt: dbt.CreatedAt,
+			Rearm: Rearm{
+				CooldownSecs: dbt.CooldownSecs,
+				Hysteresis:   dbt.Hysteresis,
+			},
+			Schedule:    schedule,
+			QualityGate: QualityGate{MinCompleteness: dbt.MinCompleteness},
+			FiredAt:     dbt.FiredAt,
+			FiredValue:  dbt.FiredValue,
+		}, dbt.Params)
+		if err != nil {
+			return errors.Wrapf(err, "failed to restore trigger %q", dbt.ID)
+		}
+
+		triggers = append(triggers, trigger)
+	}
+
+	portf.addTriggers(triggers)
+	return nil
+}
+
+// conversionGraph returns ConversionGraph of gateway shared by its portfolios
+func (pm *Manager) conversionGraph(gw core.Gateway) *ConversionGraph {
+	pm.graphsMu.Lock()
+	defer pm.graphsMu.Unlock()
+	graph, ok := pm.graphs[gw.Name()]
+	if !ok {
+		graph = NewConversionGraph(gw, pm.cfg.Normalization[gw.Name()])
+		pm.graphs[gw.Name()] = graph
+	}
+	return graph
+}
+
 func (pm *Manager) getGatewayAndAccount(exchangeName, accName, key, secret string, passphrase *string) (core.Gateway, core.Account, error) {
 	gw, ok := pm.gwsMngr.Gateway(exchangeName)
 	if !ok {
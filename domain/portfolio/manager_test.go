@@ -2,6 +2,7 @@ package portfolio
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -34,15 +35,75 @@ func TestManager_Start(t *testing.T) {
 			},
 		}, nil)
 
-	pm := NewManager(db, nil, nil, nil)
+	pm := NewManager(db, nil, nil, Config{})
 	pm.portfolios[accName] = nil
 	assert.NoError(t, pm.Start(ctx))
+
+	t.Run("when accounts share alias", func(t *testing.T) {
+		alias := uuid.NewString()
+		names := []string{uuid.NewString(), uuid.NewString()}
+		qMock := mocks.NewQuerier(t)
+		db := &pg.DB{Queries: qMock}
+		qMock.
+			On("Accounts_SelectWithPortfolioTriggers", ctx).
+			Return([]repo.Accounts_SelectWithPortfolioTriggersRow{
+				{Name: names[0], ExchangeName: exchangeName, Aliases: []string{alias}},
+				{Name: names[1], ExchangeName: exchangeName, Aliases: []string{alias}},
+			}, nil)
+		qMock.
+			On("PortfolioGroups_Upsert", ctx, alias).
+			Return(int64(10), nil).
+			Once()
+		qMock.
+			On("PortfolioTriggers_SelectByPortfolioID", ctx, int64(10)).
+			Return([]repo.PortfolioTriggerRow{
+				{
+					ID:           uuid.New(),
+					Type:         CRL.String(),
+					Currency:     USDT.String(),
+					Paused:       true,
+					Params:       json.RawMessage(`{"limit":100}`),
+					BalanceBasis: BasisTotal.String(),
+				},
+			}, nil).
+			Once()
+
+		rdbMock := mocks.NewRedisClient(t)
+		getCmd := &redis.StringCmd{}
+		getCmd.SetErr(redis.Nil)
+		rdbMock.
+			On("Get", mock.Anything, mock.Anything).
+			Return(getCmd).
+			Maybe()
+		rdbMock.
+			On("Set", mock.Anything, "portfolio:group:"+alias, mock.Anything, time.Duration(0)).
+			Return(&redis.StatusCmd{}).
+			Maybe()
+
+		pm := NewManager(db, rdbMock, nil, Config{})
+		t.Cleanup(pm.Close)
+		for _, name := range names {
+			pm.portfolios[name] = NewPortfolio(0, name, nil, rdbMock, nil, nil, Config{})
+		}
+		assert.NoError(t, pm.Start(ctx))
+
+		group, err := pm.Group(alias)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.ElementsMatch(t, names, group.Members())
+		assert.Len(t, group.Triggers(), 1)
+		assert.False(t, group.IsClosed())
+		for _, name := range names {
+			assert.Contains(t, pm.portfolios[name].groups, alias)
+		}
+	})
 }
 
 func TestManager_Portfolio(t *testing.T) {
-	pm := NewManager(nil, nil, nil, nil)
+	pm := NewManager(nil, nil, nil, Config{})
 	portfName := "test"
-	portf := NewPortfolio(0, portfName, nil, nil, nil, nil, nil)
+	portf := NewPortfolio(0, portfName, nil, nil, nil, nil, Config{})
 	pm.portfolios[portfName] = portf
 
 	portfFound, err := pm.Portfolio(portfName)
@@ -81,6 +142,9 @@ func TestManager_AddPortfolio(t *testing.T) {
 	gwMock.
 		On("Account", core.Auth{}).
 		Return(accMock, nil)
+	gwMock.
+		On("Name").
+		Return("test")
 
 	gwsMngrMock := mocks.NewGatewaysManager(t)
 	gwsMngrMock.
@@ -89,16 +153,11 @@ func TestManager_AddPortfolio(t *testing.T) {
 		Once()
 
 	rdbMock := mocks.NewRedisClient(t)
-	getCmd := &redis.StringCmd{}
-	getCmd.SetErr(redis.Nil)
-	rdbMock.
-		On("Get", ctx, mock.Anything).
-		Return(getCmd)
 	rdbMock.
 		On("Set", ctx, mock.Anything, mock.Anything, time.Duration(0)).
 		Return(&redis.StatusCmd{})
 
-	pm := NewManager(db, rdbMock, gwsMngrMock, nil)
+	pm := NewManager(db, rdbMock, gwsMngrMock, Config{})
 	t.Cleanup(pm.Close)
 
 	assert.NoError(t, pm.AddPortfolio(name))
@@ -176,8 +235,8 @@ func TestManager_DeletePortfolio(t *testing.T) {
 	name := uuid.NewString()
 	qMock := mocks.NewQuerier(t)
 	db := &pg.DB{Queries: qMock}
-	pm := NewManager(db, nil, nil, nil)
-	portf := NewPortfolio(0, name, nil, nil, nil, nil, nil)
+	pm := NewManager(db, nil, nil, Config{})
+	portf := NewPortfolio(0, name, nil, nil, nil, nil, Config{})
 	portf.closed = 0
 	pm.portfolios[name] = portf
 
@@ -189,10 +248,46 @@ func TestManager_DeletePortfolio(t *testing.T) {
 		name := uuid.NewString()
 		assert.ErrorIs(t, pm.DeletePortfolio(name), ErrNotFound)
 	})
+
+	t.Run("when portfolio is a member of group", func(t *testing.T) {
+		name := uuid.NewString()
+		portf := NewPortfolio(0, name, nil, nil, nil, nil, Config{})
+		portf.closed = 0
+		pm.portfolios[name] = portf
+		group := NewGroup(0, uuid.NewString(), nil, nil, Config{})
+		group.addMember(portf)
+
+		assert.NoError(t, pm.DeletePortfolio(name))
+		assert.Empty(t, group.Members())
+		assert.Empty(t, portf.groups)
+	})
+}
+
+func TestManager_Group(t *testing.T) {
+	pm := NewManager(nil, nil, nil, Config{})
+	names := []string{"b", "a"}
+	for _, name := range names {
+		pm.groups[name] = NewGroup(0, name, nil, nil, Config{})
+	}
+
+	group, err := pm.Group("a")
+	assert.NoError(t, err)
+	assert.Equal(t, pm.groups["a"], group)
+
+	groups := pm.Groups()
+	if assert.Len(t, groups, 2) {
+		assert.Equal(t, "a", groups[0].Name())
+		assert.Equal(t, "b", groups[1].Name())
+	}
+
+	t.Run("when not found", func(t *testing.T) {
+		_, err := pm.Group(uuid.NewString())
+		assert.ErrorIs(t, err, ErrGroupNotFound)
+	})
 }
 
 func TestManager_Close(t *testing.T) {
-	pm := NewManager(nil, nil, nil, nil)
+	pm := NewManager(nil, nil, nil, Config{})
 
 	for i := 0; i < 2; i++ {
 		accMock := mocks.NewAccount(t)
@@ -208,17 +303,12 @@ func TestManager_Close(t *testing.T) {
 			Maybe()
 
 		rdbMock := mocks.NewRedisClient(t)
-		getCmd := &redis.StringCmd{}
-		getCmd.SetErr(redis.Nil)
-		rdbMock.
-			On("Get", context.Background(), mock.Anything).
-			Return(getCmd)
 		rdbMock.
 			On("Set", context.Background(), mock.Anything, mock.Anything, time.Duration(0)).
 			Return(&redis.StatusCmd{})
 
 		name := uuid.NewString()
-		portf := NewPortfolio(0, name, nil, rdbMock, nil, accMock, nil)
+		portf := NewPortfolio(0, name, nil, rdbMock, nil, accMock, Config{})
 		assert.NoError(t, portf.start())
 		pm.portfolios[name] = portf
 	}
@@ -254,6 +344,9 @@ func TestManager_load(t *testing.T) {
 	gwMock.
 		On("Account", auth).
 		Return(accMock, nil)
+	gwMock.
+		On("Name").
+		Return("test")
 
 	gwsMngrMock := mocks.NewGatewaysManager(t)
 	gwsMngrMock.
@@ -261,11 +354,6 @@ func TestManager_load(t *testing.T) {
 		Return(gwMock, true)
 
 	rdbMock := mocks.NewRedisClient(t)
-	getCmd := &redis.StringCmd{}
-	getCmd.SetErr(redis.Nil)
-	rdbMock.
-		On("Get", ctx, mock.Anything).
-		Return(getCmd)
 	rdbMock.
 		On("Set", ctx, mock.Anything, mock.Anything, time.Duration(0)).
 		Return(&redis.StatusCmd{})
@@ -273,10 +361,16 @@ func TestManager_load(t *testing.T) {
 	qMock := mocks.NewQuerier(t)
 	db := &pg.DB{Queries: qMock}
 	qMock.
-		On("PortfolioTriggers_UpdateStartTotalCost", ctx, mock.Anything).
+		On("PortfolioTriggers_Update", ctx, mock.Anything).
+		Return(nil)
+	qMock.
+		On("PortfolioTriggerEvents_Create", ctx, mock.Anything).
+		Return(nil)
+	qMock.
+		On("PortfolioTriggerEventsOutbox_Create", ctx, mock.Anything).
 		Return(nil)
 
-	mgr := NewManager(db, rdbMock, gwsMngrMock, nil)
+	mgr := NewManager(db, rdbMock, gwsMngrMock, Config{})
 	t.Cleanup(mgr.Close)
 
 	limit := decimal.NewDecimal(100, 0)
@@ -286,15 +380,17 @@ func TestManager_load(t *testing.T) {
 			ID:       uuid.New(),
 			Type:     CRL,
 			Currency: USDT,
-			Limit:    &limit,
+			Params:   &CostReachedLimitParams{LimitCondition{Limit: &limit}},
 		},
 		{
-			ID:             uuid.New(),
-			Type:           CCBP,
-			Currency:       BTC,
-			Percent:        &percent,
-			StartTotalCost: &limit,
-			TrailingAlert:  true,
+			ID:       uuid.New(),
+			Type:     CCBP,
+			Currency: BTC,
+			Params: &CostChangedByPercentParams{
+				Percent:        percent,
+				StartTotalCost: &limit,
+				TrailingAlert:  true,
+			},
 		},
 	}
 
@@ -307,24 +403,18 @@ func TestManager_load(t *testing.T) {
 	}
 	for i, set := range triggerSettings {
 		expTriggerIds[i] = set.ID
-		row.Triggers = append(row.Triggers, struct {
-			ID             uuid.UUID
-			Type           string
-			Currency       string
-			CreatedAt      time.Time
-			Limit          *decimal.Decimal
-			Percent        *decimal.Decimal
-			StartTotalCost *decimal.Decimal
-			TrailingAlert  bool
-		}{
-			ID:             set.ID,
-			Type:           set.Type.String(),
-			Currency:       set.Currency.String(),
-			CreatedAt:      time.Unix(set.CreatedAt, 0),
-			Limit:          set.Limit,
-			Percent:        set.Percent,
-			StartTotalCost: set.StartTotalCost,
-			TrailingAlert:  set.TrailingAlert,
+		params, err := json.Marshal(set.Params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		row.Triggers = append(row.Triggers, repo.PortfolioTriggerRow{
+			ID:              set.ID,
+			Type:            set.Type.String(),
+			Currency:        set.Currency.String(),
+			CreatedAt:       time.Unix(set.CreatedAt, 0),
+			Params:          params,
+			BalanceBasis:    BasisTotal.String(),
+			MinCompleteness: set.MinCompleteness,
 		})
 	}
 
@@ -0,0 +1,127 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+// MaxDrawdownSaveInterval limits how often rising peak of MaxDrawdown is persisted
+const MaxDrawdownSaveInterval = time.Minute
+
+var MDD = RegisterTriggerType(TriggerKind{
+	Name: "MAX_DRAWDOWN",
+	Decode: func(data []byte) (TriggerParams, error) {
+		params := new(MaxDrawdownParams)
+		err := json.Unmarshal(data, params)
+		return params, errors.WithStack(err)
+	},
+	New: func(portf *Portfolio, currency Currency, basis BalanceBasis, params TriggerParams) (Trigger, error) {
+		m := &MaxDrawdown{
+			triggerBase: newTriggerBase(portf, currency, basis),
+			params:      *params.(*MaxDrawdownParams),
+		}
+		peak := m.totalBalance()
+		m.params.Peak = &peak
+		m.savedPeak = peak
+		return m, nil
+	},
+	Restore: func(portf *Portfolio, state TriggerState, params TriggerParams) (Trigger, error) {
+		p := params.(*MaxDrawdownParams)
+		if p.Peak == nil {
+			return nil, errors.New("peak is required")
+		}
+		return &MaxDrawdown{
+			triggerBase: triggerBase{portf: portf, state: state},
+			params:      *p,
+			savedPeak:   *p.Peak,
+		}, nil
+	},
+})
+
+// MaxDrawdownParams
+// Percent is a max allowed drawdown of total cost from its peak.
+// Peak is the highest total cost since trigger creation. It's set by trigger itself
+type MaxDrawdownParams struct {
+	Percent decimal.Decimal  `json:"percent"`
+	Peak    *decimal.Decimal `json:"peak,omitempty"`
+}
+
+func (m *MaxDrawdownParams) Validate() error {
+	if !decimal.NewDecimal(0, 0).LessThan(m.Percent) || decimal.NewDecimal(100, 0).LessThan(m.Percent) {
+		return errors.New("percent must be within (0, 100]")
+	}
+	return nil
+}
+
+// MaxDrawdown is a trailing-stop trigger tracking running peak of portfolio's total cost.
+// It's executed when total cost drops from peak by certain %. Peak is tracked in memory on every revaluation
+// with complete data, even while trigger is paused, out of schedule or disarmed, so drawdown isn't measured
+// from stale peak when trigger is evaluated again. Rising peak is persisted along with evaluation of trigger
+// at most once per MaxDrawdownSaveInterval, i.e. peak of the last interval may be lost on restart
+type MaxDrawdown struct {
+	triggerBase
+	params    MaxDrawdownParams
+	savedPeak decimal.Decimal
+	savedAt   time.Time
+}
+
+// TryExecute returns non-empty ExecutionStatus if trigger is executed.
+// ExecutionStatus.CurrentValue is a drawdown from peak in %.
+// ExecutionStatus.Done is always equal to ExecutionStatus.Ok for this type of trigger
+func (m *MaxDrawdown) TryExecute() (*ExecutionStatus, error) {
+	m.track()
+	totalCost := m.totalBalance()
+	peak := *m.params.Peak
+	if peak.IsZero() {
+		return &ExecutionStatus{}, nil
+	}
+	drawdown := peak.Sub(totalCost).Div(peak).MulFloat(100)
+	ok := !drawdown.LessThan(m.params.Percent)
+	return &ExecutionStatus{
+		Ok:           ok,
+		Done:         ok,
+		CurrentValue: drawdown,
+	}, nil
+}
+
+// track raises peak up to the current total cost
+func (m *MaxDrawdown) track() {
+	if totalCost := m.totalBalance(); m.params.Peak.LessThan(totalCost) {
+		m.params.Peak = &totalCost
+	}
+}
+
+// next returns params with risen peak if it hasn't been persisted within MaxDrawdownSaveInterval
+func (m *MaxDrawdown) next(status *ExecutionStatus) TriggerParams {
+	if status.Done || m.params.Peak.Eq(m.savedPeak) || m.portf.now().Before(m.savedAt.Add(MaxDrawdownSaveInterval)) {
+		return nil
+	}
+	params := m.params
+	return &params
+}
+
+func (m *MaxDrawdown) setParams(params TriggerParams) {
+	m.params = *params.(*MaxDrawdownParams)
+	m.savedPeak = *m.params.Peak
+	m.savedAt = m.portf.now()
+}
+
+func (m *MaxDrawdown) Settings() TriggerSettings {
+	params := m.params
+	return m.settings(MDD, &params)
+}
+
+// Update returns copy of trigger with patched percent. Peak can't be patched as it's set by trigger itself
+func (m *MaxDrawdown) Update(patch json.RawMessage) (Trigger, error) {
+	params, err := patchTriggerParams(MDD, &m.params, patch)
+	if err != nil {
+		return nil, err
+	}
+	updated := *m
+	updated.params = *params.(*MaxDrawdownParams)
+	updated.params.Peak = m.params.Peak
+	return &updated, nil
+}
@@ -0,0 +1,78 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/pg"
+	"github.com/egsam98/portfolio/test/mocks"
+)
+
+func TestMaxDrawdown_TryExecute(t *testing.T) {
+	qMock := mocks.NewQuerier(t)
+	db := &pg.DB{Queries: qMock}
+	portf := NewPortfolio(0, "", db, nil, nil, nil, Config{})
+
+	trigger, err := restoreTrigger(portf, MDD, TriggerState{ID: uuid.New(), Currency: USDT},
+		json.RawMessage(`{"percent":"10","peak":"1000"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, tt := range []struct {
+		cost int64
+		ok   bool
+	}{
+		{cost: 950},
+		{cost: 2000},
+		{cost: 1900},
+		{cost: 1800, ok: true},
+	} {
+		portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(tt.cost, 0)
+		status, err := trigger.TryExecute()
+		if assert.NoError(t, err) {
+			assert.Equal(t, tt.ok, status.Ok, "cost: %d", tt.cost)
+		}
+	}
+	if params, ok := trigger.Settings().Params.(*MaxDrawdownParams); assert.True(t, ok) {
+		assert.True(t, decimal.NewDecimal(2000, 0).Eq(*params.Peak))
+	}
+
+	t.Run("risen peak is persisted at most once per interval", func(t *testing.T) {
+		now := time.Date(2022, 6, 7, 8, 0, 0, 0, time.UTC)
+		portf.now = func() time.Time { return now }
+		stateful := trigger.(statefulTrigger)
+
+		assert.Nil(t, stateful.next(&ExecutionStatus{Ok: true, Done: true}), "executed trigger is removed")
+		params := stateful.next(&ExecutionStatus{})
+		if !assert.NotNil(t, params) {
+			return
+		}
+		data, err := json.Marshal(params)
+		if assert.NoError(t, err) {
+			assert.JSONEq(t, `{"percent":"10","peak":"2000"}`, string(data))
+		}
+		stateful.setParams(params)
+		assert.Nil(t, stateful.next(&ExecutionStatus{}), "peak is already persisted")
+
+		portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(3000, 0)
+		_, err = trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.Nil(t, stateful.next(&ExecutionStatus{}), "peak is persisted before interval elapses")
+
+		now = now.Add(MaxDrawdownSaveInterval)
+		if params := stateful.next(&ExecutionStatus{}); assert.NotNil(t, params) {
+			assert.True(t, decimal.NewDecimal(3000, 0).Eq(*params.(*MaxDrawdownParams).Peak))
+		}
+	})
+
+	t.Run("when peak isn't restored", func(t *testing.T) {
+		_, err := restoreTrigger(portf, MDD, TriggerState{ID: uuid.New()}, json.RawMessage(`{"percent":"10"}`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,36 @@
+package portfolio
+
+import (
+	"strings"
+
+	"gitlab.com/moderntoken/gateways/core"
+)
+
+// CurrencyNormalization maps gateway's names of currencies onto canonical ones (ex. Kraken's XBT and XXBT onto BTC)
+// before pricing and aggregation of balances. Currencies starting with one of Prefixes (ex. Binance's LD-prefixed
+// earn tokens) are counted as underlying ones unless gateway lists prefixed name as a currency itself (ex. LDO)
+type CurrencyNormalization struct {
+	Aliases  map[core.Currency]core.Currency
+	Prefixes []string
+}
+
+// canonical returns canonical name of gateway's currency. Listed are currencies of gateway's symbols
+func (n CurrencyNormalization) canonical(cur core.Currency, listed map[core.Currency]struct{}) core.Currency {
+	if alias, ok := n.Aliases[cur]; ok {
+		return alias
+	}
+	if _, ok := listed[cur]; ok {
+		return cur
+	}
+	for _, prefix := range n.Prefixes {
+		underlying := core.Currency(strings.TrimPrefix(cur.String(), prefix))
+		if underlying == cur || underlying == "" {
+			continue
+		}
+		if alias, ok := n.Aliases[underlying]; ok {
+			return alias
+		}
+		return underlying
+	}
+	return cur
+}
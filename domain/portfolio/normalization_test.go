@@ -0,0 +1,61 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/test/mocks"
+)
+
+func TestCurrencyNormalization_canonical(t *testing.T) {
+	norm := CurrencyNormalization{
+		Aliases:  map[core.Currency]core.Currency{"XBT": "BTC", "XXBT": "BTC"},
+		Prefixes: []string{"LD"},
+	}
+	listed := map[core.Currency]struct{}{"XBT": {}, "LDO": {}, "ETH": {}}
+
+	for cur, expected := range map[core.Currency]core.Currency{
+		"XBT":   "BTC",
+		"XXBT":  "BTC",
+		"ETH":   "ETH",
+		"LDETH": "ETH",
+		"LDXBT": "BTC",
+		"LDO":   "LDO", // listed by gateway
+		"LD":    "LD",
+	} {
+		assert.Equal(t, expected, norm.canonical(cur, listed), cur.String())
+	}
+}
+
+func TestPortfolio_normalizeBalances(t *testing.T) {
+	gwMock := mocks.NewGateway(t)
+	gwMock.
+		On("AllSymbols").
+		Return([]core.Symbol{{Base: "XBT", Quote: "USDT"}, {Base: "LDO", Quote: "USDT"}}).
+		Once()
+	norm := CurrencyNormalization{
+		Aliases:  map[core.Currency]core.Currency{"XBT": "BTC", "XXBT": "BTC"},
+		Prefixes: []string{"LD"},
+	}
+	portf := NewPortfolio(0, "", nil, nil, gwMock, nil, Config{})
+	portf.graph = NewConversionGraph(gwMock, norm)
+
+	balances := portf.normalizeBalances(map[core.Currency]core.Balance{
+		"XBT":   {Available: decimal.NewDecimal(1, 0)},
+		"XXBT":  {Available: decimal.NewDecimal(2, 0), Locked: decimal.NewDecimal(1, 0)},
+		"LDBTC": {Available: decimal.NewDecimal(3, 0)},
+		"LDO":   {Available: decimal.NewDecimal(4, 0)},
+	})
+	assert.Len(t, balances, 2)
+	assert.True(t, decimal.NewDecimal(6, 0).Eq(balances["BTC"].Available))
+	assert.True(t, decimal.NewDecimal(1, 0).Eq(balances["BTC"].Locked))
+	assert.True(t, decimal.NewDecimal(4, 0).Eq(balances["LDO"].Available))
+
+	// Symbols keep gateway's names
+	path, ok := portf.graph.Path("BTC", "USDT")
+	assert.True(t, ok)
+	assert.Equal(t, ConversionPath{{Symbol: "XBTUSDT"}}, path)
+}
@@ -0,0 +1,154 @@
+package portfolio
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/egsam98/portfolio/pg"
+	"github.com/egsam98/portfolio/pg/repo"
+)
+
+const (
+	// DefaultOutboxInterval is a default period outbox is polled for unsent TriggerEvent-s within
+	DefaultOutboxInterval = time.Second
+	// MaxOutboxBackoff limits a delay between relay attempts after publishing failures
+	MaxOutboxBackoff = 30 * time.Second
+	// OutboxRetention is a period sent TriggerEvent-s are kept in outbox for
+	OutboxRetention = 24 * time.Hour
+
+	outboxBatchSize = 100
+)
+
+// OutboxRelay publishes TriggerEvent-s saved into outbox by Portfolio-s with TriggerEventPublisher.
+// Events are published in order they were saved and marked as sent only after successful publishing,
+// failed ones are retried with exponential backoff. This gives at-least-once delivery guarantee
+type OutboxRelay struct {
+	db        *pg.DB
+	publisher TriggerEventPublisher
+	interval  time.Duration
+	logger    zerolog.Logger
+}
+
+func NewOutboxRelay(db *pg.DB, publisher TriggerEventPublisher, interval time.Duration) *OutboxRelay {
+	if interval == 0 {
+		interval = DefaultOutboxInterval
+	}
+	return &OutboxRelay{
+		db:        db,
+		publisher: publisher,
+		interval:  interval,
+		logger: log.Logger.With().
+			Str("namespace", "outbox_relay").
+			Logger(),
+	}
+}
+
+// Start relaying TriggerEvent-s in goroutine until ctx is done
+func (r *OutboxRelay) Start(ctx context.Context) {
+	go func() {
+		delay := r.interval
+		var cleanedAt time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			n, err := r.relay(ctx)
+			switch {
+			case err != nil:
+				delay = r.backoff(delay)
+				r.logger.Error().Stack().Err(err).Msgf("Failed to relay trigger events, retry in %s", delay)
+				continue
+			case n == outboxBatchSize:
+				delay = 0 // outbox may still have unsent events
+			default:
+				delay = r.interval
+			}
+
+			if time.Since(cleanedAt) >= time.Hour {
+				if err := r.db.Queries.PortfolioTriggerEventsOutbox_DeleteSentBefore(ctx, sql.NullTime{
+					Time:  time.Now().UTC().Add(-OutboxRetention),
+					Valid: true,
+				}); err != nil {
+					r.logger.Error().Stack().Err(err).Msg("Failed to delete sent trigger events")
+					continue
+				}
+				cleanedAt = time.Now()
+			}
+		}
+	}()
+}
+
+// backoff returns doubled delay of the next relay attempt after failure limited by MaxOutboxBackoff.
+// Delay is at least doubled polling interval even if outbox has been drained without delay
+func (r *OutboxRelay) backoff(delay time.Duration) time.Duration {
+	if delay < r.interval {
+		delay = r.interval
+	}
+	if delay *= 2; delay > MaxOutboxBackoff {
+		delay = MaxOutboxBackoff
+	}
+	return delay
+}
+
+// relay publishes batch of unsent TriggerEvent-s within transaction locking them from other relays.
+// Batch is interrupted on first publishing failure to keep events order. Events that can't be decoded are marked
+// as failed and skipped since they'd block outbox forever. Returns number of sent events
+func (r *OutboxRelay) relay(ctx context.Context) (int, error) {
+	var sent int
+	var pubErr error
+	err := r.db.Tx(ctx, func(q repo.Querier) error {
+		rows, err := q.PortfolioTriggerEventsOutbox_SelectUnsent(ctx, outboxBatchSize)
+		if err != nil {
+			return errors.Wrap(err, "failed to select unsent trigger events")
+		}
+
+		for _, row := range rows {
+			var event TriggerEvent
+			if err := json.Unmarshal(row.Payload, &event); err != nil {
+				lastError := errors.Wrapf(err, "failed to unmarshal %s into %T", string(row.Payload), event).Error()
+				if err := q.PortfolioTriggerEventsOutbox_MarkFailed(ctx, repo.PortfolioTriggerEventsOutbox_MarkFailedParams{
+					LastError: &lastError,
+					ID:        row.ID,
+				}); err != nil {
+					return errors.Wrapf(err, "failed to mark trigger event %d as failed", row.ID)
+				}
+				r.logger.Error().Int64("id", row.ID).Msgf("Trigger event is skipped: %s", lastError)
+				continue
+			}
+
+			if pubErr = r.publisher(event); pubErr != nil {
+				lastError := pubErr.Error()
+				if err := q.PortfolioTriggerEventsOutbox_MarkFailed(ctx, repo.PortfolioTriggerEventsOutbox_MarkFailedParams{
+					LastError: &lastError,
+					ID:        row.ID,
+				}); err != nil {
+					return errors.Wrapf(err, "failed to mark trigger event %d as failed", row.ID)
+				}
+				pubErr = errors.Wrapf(pubErr, "failed to publish trigger event %d (attempt %d)", row.ID, row.Attempts+1)
+				return nil // commit attempts counter
+			}
+
+			if err := q.PortfolioTriggerEventsOutbox_MarkSent(ctx, repo.PortfolioTriggerEventsOutbox_MarkSentParams{
+				SentAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+				ID:     row.ID,
+			}); err != nil {
+				return errors.Wrapf(err, "failed to mark trigger event %d as sent", row.ID)
+			}
+			sent++
+		}
+		return nil
+	})
+	if err != nil {
+		return sent, err
+	}
+	return sent, pubErr
+}
@@ -0,0 +1,132 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/egsam98/portfolio/pg"
+	"github.com/egsam98/portfolio/pg/repo"
+	"github.com/egsam98/portfolio/test/mocks"
+)
+
+func TestOutboxRelay_relay(t *testing.T) {
+	ctx := context.Background()
+	qMock := mocks.NewQuerier(t)
+	db := &pg.DB{Queries: qMock}
+
+	events := []TriggerEvent{
+		{Portfolio: "test", Kind: EventExecuted, TriggerSettings: TriggerSettings{ID: uuid.New(), Type: CRL, Currency: USDT}},
+		{Portfolio: "test", Kind: EventExecuted, TriggerSettings: TriggerSettings{ID: uuid.New(), Type: CCBP, Currency: USDT}},
+	}
+	var rows []repo.PortfolioTriggerEventsOutbox_SelectUnsentRow
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, repo.PortfolioTriggerEventsOutbox_SelectUnsentRow{ID: int64(i + 1), Payload: payload})
+	}
+
+	var published []TriggerEvent
+	relay := NewOutboxRelay(db, func(event TriggerEvent) error {
+		if event.TriggerSettings.Type == CCBP {
+			return errors.New("test")
+		}
+		published = append(published, event)
+		return nil
+	}, 0)
+
+	qMock.
+		On("PortfolioTriggerEventsOutbox_SelectUnsent", ctx, int32(outboxBatchSize)).
+		Return(rows, nil).
+		Once()
+	qMock.
+		On("PortfolioTriggerEventsOutbox_MarkSent", ctx, mock.MatchedBy(
+			func(params repo.PortfolioTriggerEventsOutbox_MarkSentParams) bool {
+				return params.ID == 1 && params.SentAt.Valid
+			}),
+		).
+		Return(nil).
+		Once()
+	qMock.
+		On("PortfolioTriggerEventsOutbox_MarkFailed", ctx, mock.MatchedBy(
+			func(params repo.PortfolioTriggerEventsOutbox_MarkFailedParams) bool {
+				return params.ID == 2 && params.LastError != nil && *params.LastError == "test"
+			}),
+		).
+		Return(nil).
+		Once()
+
+	sent, err := relay.relay(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 1, sent)
+	if assert.Len(t, published, 1) {
+		assert.Equal(t, events[0].TriggerSettings.ID, published[0].TriggerSettings.ID)
+	}
+}
+
+func TestOutboxRelay_relay_undecodable(t *testing.T) {
+	ctx := context.Background()
+	qMock := mocks.NewQuerier(t)
+	db := &pg.DB{Queries: qMock}
+
+	payload, err := json.Marshal(TriggerEvent{
+		Portfolio:       "test",
+		Kind:            EventExecuted,
+		TriggerSettings: TriggerSettings{ID: uuid.New(), Type: CRL, Currency: USDT},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var published int
+	relay := NewOutboxRelay(db, func(TriggerEvent) error {
+		published++
+		return nil
+	}, 0)
+
+	qMock.
+		On("PortfolioTriggerEventsOutbox_SelectUnsent", ctx, int32(outboxBatchSize)).
+		Return([]repo.PortfolioTriggerEventsOutbox_SelectUnsentRow{
+			{ID: 1, Payload: json.RawMessage(`{"kind":"UNKNOWN"}`)},
+			{ID: 2, Payload: payload},
+		}, nil).
+		Once()
+	qMock.
+		On("PortfolioTriggerEventsOutbox_MarkFailed", ctx, mock.MatchedBy(
+			func(params repo.PortfolioTriggerEventsOutbox_MarkFailedParams) bool {
+				return params.ID == 1 && params.LastError != nil
+			}),
+		).
+		Return(nil).
+		Once()
+	qMock.
+		On("PortfolioTriggerEventsOutbox_MarkSent", ctx, mock.MatchedBy(
+			func(params repo.PortfolioTriggerEventsOutbox_MarkSentParams) bool {
+				return params.ID == 2
+			}),
+		).
+		Return(nil).
+		Once()
+
+	// Undecodable event doesn't block the rest of outbox
+	sent, err := relay.relay(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, 1, published)
+}
+
+func TestOutboxRelay_backoff(t *testing.T) {
+	relay := NewOutboxRelay(nil, nil, time.Second)
+
+	// Outbox has been drained without delay
+	assert.Equal(t, 2*time.Second, relay.backoff(0))
+	assert.Equal(t, 4*time.Second, relay.backoff(2*time.Second))
+	assert.Equal(t, MaxOutboxBackoff, relay.backoff(MaxOutboxBackoff))
+}
@@ -3,11 +3,13 @@ package portfolio
 import (
 	"context"
 	"encoding/json"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -18,39 +20,74 @@ import (
 	"github.com/egsam98/portfolio/pg/repo"
 )
 
+// DefaultPriceDebounce is a default period price updates are coalesced within before portfolio revaluation
+const DefaultPriceDebounce = 3 * time.Second
+
 // Portfolio holds account's balances converted to Currency types + prices converted as well.
-// Portfolio supports Trigger-s registration that can be executed on account's balance update and
-// reported with TriggerEventPublisher
+// Portfolio supports Trigger-s registration that can be executed on account's balance or prices update and
+// saved into outbox to be reported by OutboxRelay
 type Portfolio struct {
-	closed      uint32
-	id          int64
-	name        string
-	db          *pg.DB
-	dataHolder  *dataHolder
-	gw          core.Gateway
-	acc         core.Account
-	triggers    map[string]Trigger
-	triggersMu  sync.RWMutex
-	tePublisher TriggerEventPublisher
-	closedCh    chan bool // true if portfolio is supposed to be destroyed
-	logger      zerolog.Logger
+	closed         uint32
+	id             int64
+	name           string
+	db             *pg.DB
+	dataHolder     *dataHolder
+	gw             core.Gateway
+	acc            core.Account
+	balances       map[core.Currency]core.Balance // last handled balances
+	symbols        map[string]core.Instrument     // instruments of prices and price triggers
+	symbolsMu      sync.Mutex
+	priceUpdates   map[string]time.Time // times prices of symbols were updated at
+	priceUpdatesMu sync.Mutex
+	graph          *ConversionGraph // shared by portfolios of the same gateway, see conversionGraph
+	graphOnce      sync.Once
+	priceCh        chan struct{}
+	cfg            Config
+	currencies     []Currency // quote currencies prices and balances are converted to
+	pricing        PricingPolicy
+	lastSnapshotAt time.Time
+	totals         *totalsWindow
+	now            func() time.Time // clock, it's replaced in tests
+	triggers       map[string]Trigger
+	triggersMu     sync.RWMutex
+	closedCh       chan bool                                // true if portfolio is supposed to be destroyed
+	revalue        func(ctx context.Context) (*Data, error) // revalues data missing in Redis
+	groups         map[string]*Group                        // groups portfolio is a member of
+	group          bool                                     // portfolio consolidates members of Group
+	groupsMu       sync.Mutex
+	logger         zerolog.Logger
 }
 
 type (
 	TriggerEventPublisher func(event TriggerEvent) error
 	Data                  struct {
 		Prices  map[core.Currency]ConvertedTo `json:"prices" validate:"required"`
-		Balance struct {
-			Total   ConvertedTo                   `json:"total" validate:"required"`
-			Details map[core.Currency]ConvertedTo `json:"details" validate:"required"`
-		} `json:"balance"`
+		Balance Balances                      `json:"balance"`
+		// Pricing is a policy prices are calculated by
+		Pricing PricingPolicy `json:"pricing,omitempty" enums:"ASK,BID,MID,LIQUIDATION" swaggertype:"string"`
+		// PriceInfo describes sources and statuses of Prices
+		PriceInfo map[core.Currency]map[Currency]PriceInfo `json:"price_info" validate:"required"`
+		Quality   Quality                                  `json:"quality"`
+		FX        *FXState                                 `json:"fx,omitempty"`
+	}
+	// FXState holds rates (per 1 USDT) fiat currencies of Data are converted by. It's absent if portfolio has no fiat currencies.
+	// Stale is true if rates are outdated or some of them are unknown (values in such currencies are zero)
+	FXState struct {
+		Rates     ConvertedTo `json:"rates" validate:"required"`
+		UpdatedAt int64       `json:"updated_at,omitempty" format:"timestamp"`
+		Stale     bool        `json:"stale"`
 	}
 	ConvertedTo  map[Currency]decimal.Decimal
 	TriggerEvent struct {
-		Portfolio       string          `json:"portfolio" required:"true"`
-		Timestamp       int64           `json:"timestamp" required:"true" format:"timestamp"`
-		CurrentValue    decimal.Decimal `json:"current_value" required:"true"`
-		TriggerSettings TriggerSettings `json:"trigger_settings" required:"true"`
+		Portfolio       string           `json:"portfolio" required:"true"`
+		Group           bool             `json:"group,omitempty"` // Portfolio is a name of Group
+		Timestamp       int64            `json:"timestamp" required:"true" format:"timestamp"`
+		CurrentValue    decimal.Decimal  `json:"current_value" required:"true"`
+		Done            bool             `json:"done"`
+		Kind            TriggerEventKind `json:"kind" required:"true" swaggertype:"string" enums:"EXECUTED,EXPIRED"`
+		Total           ConvertedTo      `json:"total" required:"true"`
+		TriggerSettings TriggerSettings  `json:"trigger_settings" required:"true"`
+		Details         json.RawMessage  `json:"details,omitempty" swaggertype:"object"`
 	}
 	Info struct {
 		TriggerSettings []TriggerSettings `json:"trigger_settings" validate:"required"`
@@ -73,35 +110,61 @@ func NewPortfolio(
 	rdb redis.UniversalClient,
 	gw core.Gateway,
 	acc core.Account,
-	eventPublisher TriggerEventPublisher,
+	cfg Config,
 ) *Portfolio {
-	return &Portfolio{
-		id:          id,
-		name:        name,
-		db:          db,
-		dataHolder:  newDataHolder(name, rdb),
-		gw:          gw,
-		acc:         acc,
-		tePublisher: eventPublisher,
-		triggers:    make(map[string]Trigger),
-		closed:      1,
-		closedCh:    make(chan bool, 1),
+	if cfg.PriceDebounce == 0 {
+		cfg.PriceDebounce = DefaultPriceDebounce
+	}
+	if cfg.PriceMaxAge == 0 {
+		cfg.PriceMaxAge = DefaultPriceMaxAge
+	}
+	p := &Portfolio{
+		id:           id,
+		name:         name,
+		db:           db,
+		dataHolder:   newDataHolder(name, rdb),
+		gw:           gw,
+		acc:          acc,
+		symbols:      make(map[string]core.Instrument),
+		priceUpdates: make(map[string]time.Time),
+		priceCh:      make(chan struct{}, 1),
+		cfg:          cfg,
+		currencies:   cfg.currencies(name),
+		pricing:      cfg.pricing(name),
+		totals:       newTotalsWindow(MaxRateOfChangeWindow),
+		now:          time.Now,
+		triggers:     make(map[string]Trigger),
+		closed:       1,
+		closedCh:     make(chan bool, 1),
+		groups:       make(map[string]*Group),
 		logger: log.Logger.With().
 			Str("namespace", "portfolio").
 			Int64("id", id).
 			Str("name", name).
 			Logger(),
 	}
+	p.revalue = p.revalueAccount
+	return p
+}
+
+// Name returns name of portfolio (account or Group)
+func (p *Portfolio) Name() string {
+	return p.name
+}
+
+// Currencies returns quote currencies prices and balances are converted to
+func (p *Portfolio) Currencies() []Currency {
+	return p.currencies
+}
+
+// Pricing returns policy prices are calculated by
+func (p *Portfolio) Pricing() PricingPolicy {
+	return p.pricing
 }
 
 // Info returns Data + TriggerSettings
 func (p *Portfolio) Info(ctx context.Context) (*Info, error) {
-	settings := make([]TriggerSettings, 0, len(p.triggers))
-	p.triggersMu.RLock()
-	for _, trigger := range p.triggers {
-		settings = append(settings, trigger.Settings())
-	}
-	p.triggersMu.RUnlock()
+	settings := p.Triggers()
 
 	data, err := p.dataHolder.Get(ctx)
 	if err != nil {
@@ -109,11 +172,7 @@ func (p *Portfolio) Info(ctx context.Context) (*Info, error) {
 			return nil, err
 		}
 
-		bals, err := p.acc.Balances()
-		if err != nil {
-			return nil, err
-		}
-		if data, err = p.updateData(bals); err != nil {
+		if data, err = p.revalue(ctx); err != nil {
 			return nil, err
 		}
 	}
@@ -131,17 +190,29 @@ func (p *Portfolio) AddTriggers(ctx context.Context, triggers []Trigger) ([]Trig
 	settings := make([]TriggerSettings, len(triggers))
 	for i, t := range triggers {
 		sets := t.Settings()
+		params, err := json.Marshal(sets.Params)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal %T", sets.Params)
+		}
+		schedule, err := sets.Schedule.params()
+		if err != nil {
+			return nil, err
+		}
 		settings[i] = sets
 		dbArgs[i] = repo.PortfolioTriggers_CreateParams{
-			ID:             sets.ID,
-			PortfolioID:    p.id,
-			Type:           sets.Type.String(),
-			Currency:       sets.Currency.String(),
-			CreatedAt:      time.Unix(sets.CreatedAt, 0),
-			Limit:          sets.Limit,
-			Percent:        sets.Percent,
-			TrailingAlert:  sets.TrailingAlert,
-			StartTotalCost: sets.StartTotalCost,
+			ID:              sets.ID,
+			PortfolioID:     p.id,
+			Type:            sets.Type.String(),
+			Currency:        sets.Currency.String(),
+			Params:          params,
+			CooldownSecs:    sets.CooldownSecs,
+			Hysteresis:      sets.Hysteresis,
+			ActiveFrom:      schedule.ActiveFrom,
+			ExpiresAt:       schedule.ExpiresAt,
+			ActiveHours:     schedule.ActiveHours,
+			BalanceBasis:    sets.Basis.String(),
+			MinCompleteness: sets.MinCompleteness,
+			CreatedAt:       time.Unix(sets.CreatedAt, 0),
 		}
 	}
 	if _, err := p.db.Queries.PortfolioTriggers_Create(ctx, dbArgs); err != nil {
@@ -152,6 +223,168 @@ func (p *Portfolio) AddTriggers(ctx context.Context, triggers []Trigger) ([]Trig
 	return settings, nil
 }
 
+// Triggers returns settings of all attached triggers sorted by creation time
+func (p *Portfolio) Triggers() []TriggerSettings {
+	p.triggersMu.RLock()
+	settings := make([]TriggerSettings, 0, len(p.triggers))
+	for _, trigger := range p.triggers {
+		settings = append(settings, trigger.Settings())
+	}
+	p.triggersMu.RUnlock()
+
+	sort.Slice(settings, func(i, j int) bool {
+		if settings[i].CreatedAt == settings[j].CreatedAt {
+			return settings[i].ID.String() < settings[j].ID.String()
+		}
+		return settings[i].CreatedAt < settings[j].CreatedAt
+	})
+	return settings
+}
+
+// Trigger returns settings of attached trigger by ID
+func (p *Portfolio) Trigger(id uuid.UUID) (*TriggerSettings, error) {
+	p.triggersMu.RLock()
+	t, ok := p.triggers[id.String()]
+	p.triggersMu.RUnlock()
+	if !ok {
+		return nil, errors.Wrap(ErrTriggerNotFound, id.String())
+	}
+
+	settings := t.Settings()
+	return &settings, nil
+}
+
+// UpdateTrigger patches params, Rearm, Schedule and QualityGate settings of attached trigger with JSON object and saves it into database.
+// Trigger is replaced in portfolio only if database update succeeds. Trigger is re-armed if Rearm settings are removed.
+// Rearm settings must be removed along with patch making trigger one-shot
+func (p *Portfolio) UpdateTrigger(ctx context.Context, id uuid.UUID, patch json.RawMessage) (*TriggerSettings, error) {
+	p.triggersMu.Lock()
+	defer p.triggersMu.Unlock()
+
+	t, ok := p.triggers[id.String()]
+	if !ok {
+		return nil, errors.Wrap(ErrTriggerNotFound, id.String())
+	}
+
+	rearm, patch, err := patchRearm(t.base().state.Rearm, patch)
+	if err != nil {
+		return nil, err
+	}
+	schedule, patch, err := patchSchedule(t.base().state.Schedule, patch)
+	if err != nil {
+		return nil, err
+	}
+	gate, patch, err := patchQualityGate(t.base().state.QualityGate, patch)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := t.Update(patch)
+	if err != nil {
+		return nil, err
+	}
+	if gate != nil {
+		updated.base().state.QualityGate = *gate
+	}
+	if rearm != nil {
+		state := &updated.base().state
+		state.Rearm = *rearm
+		if !rearm.enabled() {
+			state.FiredAt, state.FiredValue = nil, nil
+		}
+	}
+	if updated.base().state.Rearm.enabled() && !supportsRearm(updated) {
+		return nil, errors.Wrap(ErrInvalidTriggerUpdate, ErrUnsupportedRearm.Error())
+	}
+	var scheduleParams repo.PortfolioTriggers_UpdateScheduleParams
+	if schedule != nil {
+		updated.base().state.Schedule = *schedule
+		if scheduleParams, err = schedule.params(); err != nil {
+			return nil, err
+		}
+		scheduleParams.ID = id
+	}
+
+	settings := updated.Settings()
+	params, err := json.Marshal(settings.Params)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal %T", settings.Params)
+	}
+	if err := p.db.Tx(ctx, func(q repo.Querier) error {
+		if err := q.PortfolioTriggers_Update(ctx, repo.PortfolioTriggers_UpdateParams{
+			Params: params,
+			ID:     id,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to update portfolio trigger %q", id)
+		}
+		if rearm != nil {
+			if err := q.PortfolioTriggers_UpdateRearm(ctx, updated.base().state.rearmParams()); err != nil {
+				return errors.Wrapf(err, "failed to update re-arm settings of portfolio trigger %q", id)
+			}
+		}
+		if schedule != nil {
+			if err := q.PortfolioTriggers_UpdateSchedule(ctx, scheduleParams); err != nil {
+				return errors.Wrapf(err, "failed to update schedule of portfolio trigger %q", id)
+			}
+		}
+		if gate != nil {
+			err := q.PortfolioTriggers_UpdateMinCompleteness(ctx, repo.PortfolioTriggers_UpdateMinCompletenessParams{
+				MinCompleteness: gate.MinCompleteness,
+				ID:              id,
+			})
+			return errors.Wrapf(err, "failed to update min completeness of portfolio trigger %q", id)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	p.triggers[id.String()] = updated
+	p.logger.Info().Interface("trigger", settings).Msg("Trigger has been updated")
+	return &settings, nil
+}
+
+// SetTriggerPaused pauses/resumes attached trigger. Paused trigger isn't executed on balance updates
+func (p *Portfolio) SetTriggerPaused(ctx context.Context, id uuid.UUID, paused bool) (*TriggerSettings, error) {
+	p.triggersMu.Lock()
+	defer p.triggersMu.Unlock()
+
+	t, ok := p.triggers[id.String()]
+	if !ok {
+		return nil, errors.Wrap(ErrTriggerNotFound, id.String())
+	}
+
+	if err := p.db.Queries.PortfolioTriggers_UpdatePaused(ctx, repo.PortfolioTriggers_UpdatePausedParams{
+		Paused: paused,
+		ID:     id,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to update paused state of portfolio trigger %q", id)
+	}
+
+	t.SetPaused(paused)
+	settings := t.Settings()
+	p.logger.Info().Interface("trigger", settings).Msg("Trigger's paused state has been changed")
+	return &settings, nil
+}
+
+// DeleteTrigger detaches trigger from portfolio and deletes it from database
+func (p *Portfolio) DeleteTrigger(ctx context.Context, id uuid.UUID) error {
+	p.triggersMu.Lock()
+	defer p.triggersMu.Unlock()
+
+	if _, ok := p.triggers[id.String()]; !ok {
+		return errors.Wrap(ErrTriggerNotFound, id.String())
+	}
+	if err := p.db.Queries.PortfolioTriggers_Delete(ctx, id); err != nil {
+		return errors.Wrapf(err, "failed to delete portfolio trigger %q", id)
+	}
+
+	delete(p.triggers, id.String())
+	p.logger.Info().Str("trigger_id", id.String()).Msg("Trigger has been deleted")
+	return nil
+}
+
+// Close stops portfolio. Destroyed portfolio deletes its triggers, snapshots and Data in Redis as well.
+// History of TriggerEvent-s is kept since it's an audit of executions
 func (p *Portfolio) Close(destroy bool) {
 	if atomic.SwapUint32(&p.closed, 1) == 0 {
 		p.closedCh <- destroy
@@ -176,7 +409,8 @@ func (p *Portfolio) addTriggers(triggers []Trigger) {
 	p.logger.Info().Interface("triggers", settings).Msg("Triggers have been registered")
 }
 
-// start listens balance updates in separate goroutine
+// start listens balance and price updates in separate goroutine.
+// Price updates are coalesced within Config.PriceDebounce period and result portfolio revaluation with last balances
 func (p *Portfolio) start() error {
 	if atomic.SwapUint32(&p.closed, 0) == 0 {
 		return nil
@@ -186,7 +420,7 @@ func (p *Portfolio) start() error {
 	if err != nil {
 		return err
 	}
-	if err := p.handleBalanceUpdate(bals); err != nil {
+	if err := p.handleBalanceUpdate(p.normalizeBalances(bals)); err != nil {
 		return err
 	}
 
@@ -198,7 +432,12 @@ func (p *Portfolio) start() error {
 	go func() {
 		defer p.logger.Info().Msg("Portfolio has been closed/destroyed")
 		defer p.acc.Release()
+		defer p.releaseInstruments()
+
+		expiry := time.NewTicker(ExpiryCheckInterval)
+		defer expiry.Stop()
 
+		var revalue <-chan time.Time
 		for {
 			select {
 			case destroyed := <-p.closedCh:
@@ -207,6 +446,9 @@ func (p *Portfolio) start() error {
 					if err := p.db.Queries.PortfolioTriggers_DeleteByPortfolioID(context.Background(), p.id); err != nil {
 						p.logger.Err(err).Msgf("Failed to delete portfolio triggers by portfolio ID=%d", p.id)
 					}
+					if err := p.db.Queries.PortfolioSnapshots_DeleteByPortfolioID(context.Background(), p.id); err != nil {
+						p.logger.Err(err).Msgf("Failed to delete portfolio snapshots by portfolio ID=%d", p.id)
+					}
 					if err := p.dataHolder.Delete(context.Background()); err != nil {
 						p.logger.Err(err).Msg("Failed to delete portfolio data in redis")
 					}
@@ -219,9 +461,22 @@ func (p *Portfolio) start() error {
 					p.logger.Info().Msgf("Closing portfolio due to gateway %s stop...", p.gw.Name())
 					return
 				}
-				if err := p.handleBalanceUpdate(bals); err != nil {
+				if err := p.handleBalanceUpdate(p.normalizeBalances(bals)); err != nil {
 					p.logger.Error().Stack().Err(err).Msg("Failed to handle balance update")
 				}
+			case <-p.priceCh:
+				if revalue == nil {
+					revalue = time.After(p.cfg.PriceDebounce)
+				}
+			case <-revalue:
+				revalue = nil
+				if err := p.handleBalanceUpdate(p.balances); err != nil {
+					p.logger.Error().Stack().Err(err).Msg("Failed to handle price update")
+				}
+			case <-expiry.C:
+				p.triggersMu.Lock()
+				p.expireTriggers(p.now())
+				p.triggersMu.Unlock()
 			}
 		}
 	}()
@@ -229,127 +484,382 @@ func (p *Portfolio) start() error {
 	return nil
 }
 
-// handleBalanceUpdate:
-// 1. It converts all currencies prices and balances to Currency types
-// 2. It checks triggers state and fires TriggerEvent on execution.
-// Triggers claiming to be deleted are deleted from database also
+// revalueAccount converts current balances of account without handling of triggers
+func (p *Portfolio) revalueAccount(context.Context) (*Data, error) {
+	bals, err := p.acc.Balances()
+	if err != nil {
+		return nil, err
+	}
+	return p.updateData(p.normalizeBalances(bals))
+}
+
+// notifyGroups notifies groups portfolio is a member of about revaluation
+func (p *Portfolio) notifyGroups() {
+	p.groupsMu.Lock()
+	defer p.groupsMu.Unlock()
+	for _, group := range p.groups {
+		group.notify()
+	}
+}
+
+// conversionGraph returns ConversionGraph injected by Manager. Graph of portfolio's gateway without currency normalization
+// is built on the first call if none is injected
+func (p *Portfolio) conversionGraph() *ConversionGraph {
+	p.graphOnce.Do(func() {
+		if p.graph == nil {
+			p.graph = NewConversionGraph(p.gw, CurrencyNormalization{})
+		}
+	})
+	return p.graph
+}
+
+// normalizeBalances merges balances of account's currencies by their canonical names (see CurrencyNormalization)
+func (p *Portfolio) normalizeBalances(balances map[core.Currency]core.Balance) map[core.Currency]core.Balance {
+	normalized := make(map[core.Currency]core.Balance, len(balances))
+	for cur, bal := range balances {
+		cur = p.conversionGraph().Canonical(cur)
+		merged := normalized[cur]
+		normalized[cur] = core.Balance{
+			Available: merged.Available.Add(bal.Available),
+			Locked:    merged.Locked.Add(bal.Locked),
+		}
+	}
+	return normalized
+}
+
+// onPriceUpdate notifies portfolio's goroutine about price update without blocking
+func (p *Portfolio) onPriceUpdate(_, _ decimal.Decimal) {
+	select {
+	case p.priceCh <- struct{}{}:
+	default:
+	}
+}
+
+// instrument returns gateway's instrument of symbol subscribed to price updates.
+// Instruments are looked up once, they're released on portfolio close. Time of subscription is the first price update
+func (p *Portfolio) instrument(symbol string) (core.Instrument, error) {
+	p.symbolsMu.Lock()
+	defer p.symbolsMu.Unlock()
+	if inst, ok := p.symbols[symbol]; ok {
+		return inst, nil
+	}
+
+	inst, err := p.gw.Instrument(symbol)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get instrument %s of gateway %s", symbol, p.gw.Name())
+	}
+	p.setPriceUpdated(symbol)
+	inst.OnPriceUpdate(func(bid, ask decimal.Decimal) {
+		p.setPriceUpdated(symbol)
+		p.onPriceUpdate(bid, ask)
+	})
+	p.symbols[symbol] = inst
+	return inst, nil
+}
+
+func (p *Portfolio) setPriceUpdated(symbol string) {
+	p.priceUpdatesMu.Lock()
+	p.priceUpdates[symbol] = p.now()
+	p.priceUpdatesMu.Unlock()
+}
+
+// priceUpdatedAt returns time of the least recent price update among instruments of conversion path
+func (p *Portfolio) priceUpdatedAt(path ConversionPath) time.Time {
+	p.priceUpdatesMu.Lock()
+	defer p.priceUpdatesMu.Unlock()
+	var updatedAt time.Time
+	for _, step := range path {
+		if t := p.priceUpdates[step.Symbol]; updatedAt.IsZero() || t.Before(updatedAt) {
+			updatedAt = t
+		}
+	}
+	return updatedAt
+}
+
+func (p *Portfolio) releaseInstruments() {
+	p.symbolsMu.Lock()
+	for symbol, inst := range p.symbols {
+		inst.Release()
+		delete(p.symbols, symbol)
+	}
+	p.symbolsMu.Unlock()
+}
+
+// handleBalanceUpdate converts all currencies prices and balances to Currency types, handles converted Data
+// and notifies groups portfolio is a member of
 func (p *Portfolio) handleBalanceUpdate(balances map[core.Currency]core.Balance) error {
-	if _, err := p.updateData(balances); err != nil {
+	p.balances = balances
+	data, err := p.updateData(balances)
+	if err != nil {
 		return err
 	}
+	p.handleData(data)
+	p.notifyGroups()
+	return nil
+}
+
+// handleData:
+// 1. It saves balances Snapshot (see Config.HistoryEnabled)
+// 2. It removes expired triggers (see Schedule)
+// 3. It checks state of active triggers passing QualityGate, saves TriggerEvent into database and fires it on execution.
+// Triggers claiming to be deleted are deleted from database also
+func (p *Portfolio) handleData(data *Data) {
+	if err := p.saveSnapshot(context.Background(), data); err != nil {
+		p.logger.Error().Stack().Err(err).Msg("Failed to save snapshot")
+	}
+	now := p.now()
+	p.totals.add(now, data.Balance)
 
 	// Check triggers
+	p.triggersMu.Lock()
+	defer p.triggersMu.Unlock()
+	p.expireTriggers(now)
 	for tID, t := range p.triggers {
+		qualified := t.base().state.QualityGate.passes(data.Quality)
+		if tracking, ok := t.(trackingTrigger); ok && qualified {
+			tracking.track()
+		}
+		if t.Paused() || !t.base().state.Schedule.active(now) || t.base().state.disarmed(now) {
+			continue
+		}
+		if !qualified {
+			p.logger.Debug().
+				Str("trigger_id", tID).
+				Interface("quality", data.Quality).
+				Msg("Trigger isn't evaluated due to incomplete data")
+			continue
+		}
+
 		execStatus, err := t.TryExecute()
 		if err != nil {
 			p.logger.Error().Stack().Err(err).Msgf("Failed to execute trigger %s", t.ID())
 			continue
 		}
 
+		// Execution of disarmed trigger is suppressed
+		base := t.base()
+		state, armed := base.state.rearm(now, execStatus)
+		if !armed {
+			continue
+		}
+		rearmChanged := state.FiredAt != base.state.FiredAt
+		var params TriggerParams
+		stateful, isStateful := t.(statefulTrigger)
+		if isStateful {
+			params = stateful.next(execStatus)
+		}
+
 		// Trigger is executed
+		var event *TriggerEvent
 		if execStatus.Ok {
 			p.logger.Info().
 				Interface("trigger", t.Settings()).
 				Interface("status", execStatus).
 				Msg("Trigger has been executed")
-			if p.tePublisher != nil {
-				if err := p.tePublisher(TriggerEvent{
-					Portfolio:       p.name,
-					TriggerSettings: t.Settings(),
-					Timestamp:       time.Now().Unix(),
-					CurrentValue:    execStatus.CurrentValue,
-				}); err != nil {
-					p.logger.Error().Stack().Err(err).Msg("Failed to publish event")
+			event = &TriggerEvent{
+				Portfolio:       p.name,
+				Group:           p.group,
+				TriggerSettings: t.Settings(),
+				Timestamp:       now.Unix(),
+				CurrentValue:    execStatus.CurrentValue,
+				Done:            execStatus.Done,
+				Kind:            EventExecuted,
+				Total:           data.Balance.Total,
+			}
+			if execStatus.Details != nil {
+				if event.Details, err = json.Marshal(execStatus.Details); err != nil {
+					p.logger.Error().Stack().Err(err).Msgf("Failed to marshal details of trigger %s", tID)
 				}
 			}
 		}
+		if event == nil && !execStatus.Done && !rearmChanged && params == nil {
+			continue
+		}
 
-		// Trigger is done (claims to be deleted)
-		if execStatus.Done {
-			if err := p.db.Queries.PortfolioTriggers_Delete(context.Background(), t.ID()); err != nil {
-				p.logger.Err(err).Msgf("Failed to delete portfolio trigger %q", tID)
-				continue
+		// Event is saved into history and outbox together with changed params of trigger,
+		// deletion of done trigger or its re-arm state
+		if err := p.db.Tx(context.Background(), func(q repo.Querier) error {
+			if event != nil {
+				if err := p.saveTriggerEvent(context.Background(), q, *event); err != nil {
+					return err
+				}
+			}
+			if params != nil {
+				if err := base.saveParams(q, params); err != nil {
+					return err
+				}
+			}
+			// Trigger is done (claims to be deleted)
+			if execStatus.Done {
+				err := q.PortfolioTriggers_Delete(context.Background(), t.ID())
+				return errors.Wrapf(err, "failed to delete portfolio trigger %q", tID)
+			}
+			if rearmChanged {
+				err := q.PortfolioTriggers_UpdateRearm(context.Background(), state.rearmParams())
+				return errors.Wrapf(err, "failed to update re-arm state of portfolio trigger %q", tID)
 			}
+			return nil
+		}); err != nil {
+			p.logger.Error().Stack().Err(err).Msgf("Failed to commit execution of trigger %s", tID)
+			continue
+		}
+		if execStatus.Done {
 			delete(p.triggers, tID)
 		}
+		if params != nil {
+			stateful.setParams(params)
+		}
+		base.state = state
 	}
-
-	return nil
 }
 
-// updateData updates prices and balances converted to different kinds of Currency saving them into Redis
-func (p *Portfolio) updateData(balances map[core.Currency]core.Balance) (*Data, error) {
-	data, err := p.dataHolder.Get(context.Background())
-	if err != nil {
-		if !errors.Is(err, redis.Nil) {
-			return nil, err
+// expireTriggers removes triggers expired at certain time (see Schedule) from portfolio and database.
+// Expiration is reported with TriggerEvent of EXPIRED kind. It's supposed to be called under triggersMu lock
+func (p *Portfolio) expireTriggers(now time.Time) {
+	for tID, t := range p.triggers {
+		base := t.base()
+		if !base.state.Schedule.expired(now) {
+			continue
 		}
 
-		data = &Data{
-			Prices: map[core.Currency]ConvertedTo{},
-			Balance: struct {
-				Total   ConvertedTo                   `json:"total" validate:"required"`
-				Details map[core.Currency]ConvertedTo `json:"details" validate:"required"`
-			}{
-				Total:   map[Currency]decimal.Decimal{},
-				Details: map[core.Currency]ConvertedTo{},
-			},
+		event := TriggerEvent{
+			Portfolio:       p.name,
+			Group:           p.group,
+			TriggerSettings: t.Settings(),
+			Timestamp:       now.Unix(),
+			CurrentValue:    base.totalBalance(),
+			Done:            true,
+			Kind:            EventExpired,
+			Total:           p.dataHolder.Balances().Total,
+		}
+		if err := p.db.Tx(context.Background(), func(q repo.Querier) error {
+			if err := p.saveTriggerEvent(context.Background(), q, event); err != nil {
+				return err
+			}
+			err := q.PortfolioTriggers_Delete(context.Background(), t.ID())
+			return errors.Wrapf(err, "failed to delete portfolio trigger %q", tID)
+		}); err != nil {
+			p.logger.Error().Stack().Err(err).Msgf("Failed to commit expiration of trigger %s", tID)
+			continue
 		}
+		delete(p.triggers, tID)
+		p.logger.Info().Interface("trigger", event.TriggerSettings).Msg("Trigger has expired")
 	}
+}
 
+// updateData updates prices and balances converted to different kinds of Currency saving them into Redis
+func (p *Portfolio) updateData(balances map[core.Currency]core.Balance) (*Data, error) {
+	// Data is rebuilt from scratch, so assets disappeared from balances aren't valued anymore
+	data := &Data{
+		Prices:    make(map[core.Currency]ConvertedTo, len(balances)),
+		PriceInfo: make(map[core.Currency]map[Currency]PriceInfo, len(balances)),
+		Balance: Balances{
+			Details: make(map[core.Currency]AssetBalance, len(balances)),
+		},
+	}
+	data.Pricing = p.pricing
+	data.FX = p.fxState()
 	for cur, bal := range balances {
-		priceUSDT := p.price(cur, core.Currency(USDT.String()), 0)
-		priceBTC := p.price(cur, core.Currency(BTC.String()), 0) // TODO: XBT Kraken?
-		data.Prices[cur] = ConvertedTo{
-			USDT: priceUSDT,
-			BTC:  priceBTC,
+		prices := make(ConvertedTo, len(p.currencies))
+		infos := make(map[Currency]PriceInfo, len(p.currencies))
+		for _, quote := range p.currencies {
+			var price decimal.Decimal
+			var info PriceInfo
+			if data.FX != nil && containsCurrency(p.cfg.Fiat, quote) {
+				price, info = p.price(cur, core.Currency(USDT))
+				price = price.Mul(data.FX.Rates[quote])
+				info = info.withFX(quote, data.FX, p.now())
+			} else {
+				price, info = p.price(cur, core.Currency(quote.String()))
+			}
+			prices[quote] = price
+			infos[quote] = info
 		}
-		costUSDT := bal.Available.Mul(priceUSDT)
-		costBTC := bal.Available.Mul(priceBTC)
-		data.Balance.Details[cur] = ConvertedTo{
-			USDT: costUSDT,
-			BTC:  costBTC,
+		data.Prices[cur] = prices
+		data.PriceInfo[cur] = infos
+		data.Balance.Details[cur] = newAssetBalance(bal, prices)
+	}
+	data.Quality = newQuality(balances, data.PriceInfo)
+
+	data.Balance.Total, data.Balance.Available, data.Balance.Locked = ConvertedTo{}, ConvertedTo{}, ConvertedTo{}
+	for _, details := range data.Balance.Details {
+		for _, quote := range p.currencies {
+			data.Balance.Total[quote] = data.Balance.Total[quote].Add(details.Total.Value[quote])
+			data.Balance.Available[quote] = data.Balance.Available[quote].Add(details.Available.Value[quote])
+			data.Balance.Locked[quote] = data.Balance.Locked[quote].Add(details.Locked.Value[quote])
 		}
-		data.Balance.Total[USDT] = data.Balance.Total[USDT].Add(costUSDT)
-		data.Balance.Total[BTC] = data.Balance.Total[BTC].Add(costBTC)
 	}
 
-	if err := p.dataHolder.Save(context.Background(), *data); err != nil {
+	if err := p.dataHolder.Save(context.Background(), *data, balances); err != nil {
 		return nil, err
 	}
 	return data, nil
 }
 
-// price calculates recursively the price of base currency in quote currency.
-// depth is provided as a limit equal to 5 of recursive calls
-func (p *Portfolio) price(base, quote core.Currency, depth int) decimal.Decimal {
-	if base == quote {
-		return decimal.NewDecimal(1, 0)
+// fxState returns the latest rates of portfolio's fiat currencies, nil if portfolio has no fiat currencies
+func (p *Portfolio) fxState() *FXState {
+	var fiat []Currency
+	for _, cur := range p.currencies {
+		if containsCurrency(p.cfg.Fiat, cur) {
+			fiat = append(fiat, cur)
+		}
+	}
+	if len(fiat) == 0 {
+		return nil
+	}
+
+	state := &FXState{Rates: make(ConvertedTo), Stale: true}
+	if p.cfg.FX == nil {
+		return state
 	}
-	if inst, err := p.gw.Instrument(base.String() + quote.String()); err == nil {
-		_, ask := inst.Price()
-		return ask
+	rates, stale := p.cfg.FX.Latest()
+	state.Stale = stale
+	if !rates.UpdatedAt.IsZero() {
+		state.UpdatedAt = rates.UpdatedAt.Unix()
 	}
-	if inst, err := p.gw.Instrument(quote.String() + base.String()); err == nil {
-		_, ask := inst.Price()
-		if ask.IsZero() {
-			return decimal.Decimal{}
+	for _, cur := range fiat {
+		rate, ok := rates.Values[cur.String()]
+		if !ok {
+			state.Stale = true
+			continue
 		}
-		return decimal.NewDecimal(1, 0).Div(ask)
+		state.Rates[cur] = rate
+	}
+	return state
+}
+
+// price calculates the price of base currency in quote currency by portfolio's PricingPolicy
+// along the shortest path of conversion graph. Instruments of path are subscribed to price updates.
+// PriceInfo describes the source and status of price, price is zero if it's UNPRICED
+func (p *Portfolio) price(base, quote core.Currency) (decimal.Decimal, PriceInfo) {
+	info := PriceInfo{Status: PriceUnpriced}
+	path, ok := p.conversionGraph().Path(base, quote)
+	if !ok {
+		return decimal.Decimal{}, info
+	}
+	info.Path = path
+	if len(path) == 0 {
+		info.Status = PriceOK
+		return decimal.NewDecimal(1, 0), info
 	}
 
-	if depth > 5 {
-		return decimal.Decimal{}
+	info.Source = p.gw.Name()
+	var takerFee decimal.Decimal
+	if p.pricing == PricingLiquidation {
+		takerFee = decimal.FloatToDecimal(p.cfg.TakerFees[p.gw.Name()])
+	}
+	price := path.price(p.instrument, p.pricing, takerFee)
+	if price.IsZero() {
+		return price, info
 	}
 
-	for _, symbol := range p.gw.AllSymbols() {
-		if symbol.Base != base {
-			continue
-		}
-		if price := p.price(symbol.Quote, quote, depth+1); !price.IsZero() {
-			return p.price(symbol.Base, symbol.Quote, 0).Mul(price)
+	info.Status = PriceOK
+	now := p.now()
+	if updatedAt := p.priceUpdatedAt(path); !updatedAt.IsZero() {
+		info.setUpdatedAt(updatedAt, now)
+		if now.Sub(updatedAt) > p.cfg.PriceMaxAge {
+			info.Status = PriceStale
 		}
 	}
-
-	return decimal.Decimal{}
+	return price, info
 }
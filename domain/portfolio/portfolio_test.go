@@ -7,12 +7,14 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"gitlab.com/moderntoken/gateways/core"
 	"gitlab.com/moderntoken/gateways/decimal"
 
+	"github.com/egsam98/portfolio/domain/fx"
 	"github.com/egsam98/portfolio/pg"
 	"github.com/egsam98/portfolio/pg/repo"
 	"github.com/egsam98/portfolio/test/mocks"
@@ -40,7 +42,7 @@ func TestPortfolio_Info(t *testing.T) {
 
 	accMock := mocks.NewAccount(t)
 
-	portf := NewPortfolio(0, "", nil, rdbMock, nil, accMock, nil)
+	portf := NewPortfolio(0, "", nil, rdbMock, nil, accMock, Config{})
 	trigger := NewCostReachedLimit(portf, BTC, core.Amount{})
 	portf.addTriggers([]Trigger{trigger})
 
@@ -57,7 +59,7 @@ func TestPortfolio_Info(t *testing.T) {
 		rdbMock.
 			On("Get", ctx, mock.Anything).
 			Return(cmd).
-			Twice()
+			Once()
 		rdbMock.
 			On("Set", ctx, mock.Anything, mock.Anything, time.Duration(0)).
 			Return(&redis.StatusCmd{})
@@ -77,7 +79,7 @@ func TestPortfolio_AddTriggers(t *testing.T) {
 	qMock := mocks.NewQuerier(t)
 	db := &pg.DB{Queries: qMock}
 
-	portf := NewPortfolio(0, "", db, nil, nil, nil, nil)
+	portf := NewPortfolio(0, "", db, nil, nil, nil, Config{})
 	triggers := []Trigger{
 		NewCostReachedLimit(portf, USDT, core.Amount{}),
 		NewCostChangedByPercent(portf, BTC, core.Amount{}, false),
@@ -107,6 +109,141 @@ func TestPortfolio_AddTriggers(t *testing.T) {
 	}
 }
 
+func TestPortfolio_UpdateTrigger(t *testing.T) {
+	ctx := context.Background()
+	qMock := mocks.NewQuerier(t)
+	db := &pg.DB{Queries: qMock}
+
+	portf := NewPortfolio(0, "", db, nil, nil, nil, Config{})
+	trigger := NewCostChangedByPercent(portf, USDT, decimal.NewDecimal(5, 0), false)
+	portf.addTriggers([]Trigger{trigger})
+
+	percent := decimal.NewDecimal(10, 0)
+	qMock.
+		On("PortfolioTriggers_Update", ctx, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			params := args.Get(1).(repo.PortfolioTriggers_UpdateParams)
+			assert.Equal(t, trigger.ID(), params.ID)
+			var ccbpParams CostChangedByPercentParams
+			if assert.NoError(t, json.Unmarshal(params.Params, &ccbpParams)) {
+				assert.True(t, percent.Eq(ccbpParams.Percent))
+				assert.True(t, ccbpParams.TrailingAlert)
+				assert.NotNil(t, ccbpParams.StartTotalCost)
+			}
+		}).
+		Once()
+
+	settings, err := portf.UpdateTrigger(ctx, trigger.ID(), json.RawMessage(`{"percent":"10","trailing_alert":true}`))
+	assert.NoError(t, err)
+	if params, ok := settings.Params.(*CostChangedByPercentParams); assert.True(t, ok) {
+		assert.True(t, percent.Eq(params.Percent))
+		assert.True(t, params.TrailingAlert)
+	}
+	assert.Equal(t, *settings, portf.triggers[trigger.ID().String()].Settings())
+	assert.False(t, trigger.params.TrailingAlert, "original trigger must be untouched")
+
+	t.Run("re-arm settings", func(t *testing.T) {
+		qMock.On("PortfolioTriggers_Update", ctx, mock.Anything).Return(nil).Once()
+		qMock.
+			On("PortfolioTriggers_UpdateRearm", ctx, mock.Anything).
+			Return(nil).
+			Run(func(args mock.Arguments) {
+				params := args.Get(1).(repo.PortfolioTriggers_UpdateRearmParams)
+				assert.Equal(t, trigger.ID(), params.ID)
+				assert.Equal(t, int64(60), params.CooldownSecs)
+				assert.Nil(t, params.Hysteresis)
+			}).
+			Once()
+
+		settings, err := portf.UpdateTrigger(ctx, trigger.ID(), json.RawMessage(`{"cooldown_secs":60}`))
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(60), settings.CooldownSecs)
+		}
+
+		_, err = portf.UpdateTrigger(ctx, trigger.ID(), json.RawMessage(`{"hysteresis":"-1"}`))
+		assert.ErrorIs(t, err, ErrInvalidTriggerUpdate)
+
+		// Trigger becomes one-shot
+		_, err = portf.UpdateTrigger(ctx, trigger.ID(), json.RawMessage(`{"trailing_alert":false}`))
+		assert.ErrorIs(t, err, ErrInvalidTriggerUpdate)
+	})
+
+	t.Run("when not supported field", func(t *testing.T) {
+		_, err := portf.UpdateTrigger(ctx, trigger.ID(), json.RawMessage(`{"limit":"100"}`))
+		assert.ErrorIs(t, err, ErrInvalidTriggerUpdate)
+	})
+
+	t.Run("when invalid value", func(t *testing.T) {
+		_, err := portf.UpdateTrigger(ctx, trigger.ID(), json.RawMessage(`{"percent":"0"}`))
+		assert.ErrorIs(t, err, ErrInvalidTriggerUpdate)
+	})
+
+	t.Run("when not found", func(t *testing.T) {
+		_, err := portf.UpdateTrigger(ctx, uuid.New(), json.RawMessage(`{"percent":"10"}`))
+		assert.ErrorIs(t, err, ErrTriggerNotFound)
+	})
+}
+
+func TestPortfolio_SetTriggerPaused(t *testing.T) {
+	ctx := context.Background()
+	qMock := mocks.NewQuerier(t)
+	db := &pg.DB{Queries: qMock}
+
+	portf := NewPortfolio(0, "", db, nil, nil, nil, Config{})
+	trigger := NewCostReachedLimit(portf, USDT, decimal.NewDecimal(100, 0))
+	portf.addTriggers([]Trigger{trigger})
+
+	qMock.
+		On("PortfolioTriggers_UpdatePaused", ctx, repo.PortfolioTriggers_UpdatePausedParams{
+			Paused: true,
+			ID:     trigger.ID(),
+		}).
+		Return(nil).
+		Once()
+
+	settings, err := portf.SetTriggerPaused(ctx, trigger.ID(), true)
+	assert.NoError(t, err)
+	assert.True(t, settings.Paused)
+	assert.True(t, trigger.Paused())
+
+	t.Run("when database error", func(t *testing.T) {
+		qMock.
+			On("PortfolioTriggers_UpdatePaused", ctx, repo.PortfolioTriggers_UpdatePausedParams{
+				Paused: false,
+				ID:     trigger.ID(),
+			}).
+			Return(errors.New("")).
+			Once()
+
+		_, err := portf.SetTriggerPaused(ctx, trigger.ID(), false)
+		assert.Error(t, err)
+		assert.True(t, trigger.Paused())
+	})
+}
+
+func TestPortfolio_DeleteTrigger(t *testing.T) {
+	ctx := context.Background()
+	qMock := mocks.NewQuerier(t)
+	db := &pg.DB{Queries: qMock}
+
+	portf := NewPortfolio(0, "", db, nil, nil, nil, Config{})
+	trigger := NewCostReachedLimit(portf, USDT, decimal.NewDecimal(100, 0))
+	portf.addTriggers([]Trigger{trigger})
+
+	qMock.
+		On("PortfolioTriggers_Delete", ctx, trigger.ID()).
+		Return(nil).
+		Once()
+
+	assert.NoError(t, portf.DeleteTrigger(ctx, trigger.ID()))
+	assert.Empty(t, portf.triggers)
+
+	t.Run("when not found", func(t *testing.T) {
+		assert.ErrorIs(t, portf.DeleteTrigger(ctx, trigger.ID()), ErrTriggerNotFound)
+	})
+}
+
 func TestPortfolio_Close(t *testing.T) {
 	ctx := context.Background()
 	accMock := mocks.NewAccount(t)
@@ -122,11 +259,6 @@ func TestPortfolio_Close(t *testing.T) {
 		Maybe()
 
 	rdbMock := mocks.NewRedisClient(t)
-	getCmd := &redis.StringCmd{}
-	getCmd.SetErr(redis.Nil)
-	rdbMock.
-		On("Get", ctx, mock.Anything).
-		Return(getCmd)
 	rdbMock.
 		On("Set", ctx, mock.Anything, mock.Anything, time.Duration(0)).
 		Return(&redis.StatusCmd{})
@@ -134,7 +266,7 @@ func TestPortfolio_Close(t *testing.T) {
 		On("Del", ctx, mock.Anything).
 		Return(&redis.IntCmd{})
 
-	portf := NewPortfolio(1, "", nil, rdbMock, nil, accMock, nil)
+	portf := NewPortfolio(1, "", nil, rdbMock, nil, accMock, Config{})
 	assert.NoError(t, portf.start())
 	portf.Close(false)
 	assert.EqualValues(t, 1, portf.closed)
@@ -145,8 +277,11 @@ func TestPortfolio_Close(t *testing.T) {
 		qMock.
 			On("PortfolioTriggers_DeleteByPortfolioID", context.Background(), int64(1)).
 			Return(nil)
+		qMock.
+			On("PortfolioSnapshots_DeleteByPortfolioID", context.Background(), int64(1)).
+			Return(nil)
 
-		portf := NewPortfolio(1, "", db, rdbMock, nil, accMock, nil)
+		portf := NewPortfolio(1, "", db, rdbMock, nil, accMock, Config{})
 		assert.NoError(t, portf.start())
 		portf.Close(true)
 		assert.EqualValues(t, 1, portf.closed)
@@ -170,16 +305,11 @@ func TestPortfolio_IsClosed(t *testing.T) {
 		Maybe()
 
 	rdbMock := mocks.NewRedisClient(t)
-	getCmd := &redis.StringCmd{}
-	getCmd.SetErr(redis.Nil)
-	rdbMock.
-		On("Get", context.Background(), mock.Anything).
-		Return(getCmd)
 	rdbMock.
 		On("Set", context.Background(), mock.Anything, mock.Anything, time.Duration(0)).
 		Return(&redis.StatusCmd{})
 
-	portf := NewPortfolio(0, "", nil, rdbMock, nil, accMock, nil)
+	portf := NewPortfolio(0, "", nil, rdbMock, nil, accMock, Config{})
 	assert.True(t, portf.IsClosed())
 	assert.NoError(t, portf.start())
 	assert.False(t, portf.IsClosed())
@@ -187,81 +317,297 @@ func TestPortfolio_IsClosed(t *testing.T) {
 	assert.True(t, portf.IsClosed())
 }
 
-func TestPortfolio_price(t *testing.T) {
-	ethDogeSymbol := core.Symbol{Base: "ETH", Quote: "DOGE"}
-	ethDogePrice := decimal.NewDecimal(105, 1)
+func TestPortfolio_subscribePrices(t *testing.T) {
+	ctx := context.Background()
+	bals := map[core.Currency]core.Balance{
+		"ETH": {Available: decimal.NewDecimal(2, 0), Locked: decimal.NewDecimal(1, 0)},
+	}
+	accMock := mocks.NewAccount(t)
+	accMock.
+		On("Balances").
+		Return(bals, nil)
+	accMock.
+		On("NotifyBalance", mock.Anything).
+		Return()
+	accMock.
+		On("Release").
+		Return().
+		Maybe()
 
-	ethDoge := mocks.NewInstrument(t)
-	ethDoge.
+	var onPriceUpdate core.PriceUpdateHandler
+	ethUsdt := mocks.NewInstrument(t)
+	ethUsdt.
 		On("Price").
-		Return(decimal.Decimal{}, ethDogePrice)
+		Return(decimal.Decimal{}, decimal.NewDecimal(100, 0))
+	ethUsdt.
+		On("OnPriceUpdate", mock.Anything).
+		Return().
+		Run(func(args mock.Arguments) {
+			onPriceUpdate = args.Get(0).(core.PriceUpdateHandler)
+		}).
+		Once()
+	ethUsdt.
+		On("Release").
+		Return().
+		Maybe()
 
 	gwMock := mocks.NewGateway(t)
 	gwMock.
-		On("Instrument", ethDogeSymbol.String()).
-		Return(ethDoge, nil).
-		Once()
+		On("Instrument", "ETHUSDT").
+		Return(ethUsdt, nil)
+	gwMock.
+		On("Instrument", mock.Anything).
+		Return(nil, errors.New(""))
+	gwMock.
+		On("AllSymbols").
+		Return([]core.Symbol{{Base: "ETH", Quote: "USDT"}})
+	gwMock.
+		On("Name").
+		Return("test")
+
+	rdbMock := mocks.NewRedisClient(t)
+	saved := make(chan Data, 2)
+	rdbMock.
+		On("Set", ctx, mock.Anything, mock.Anything, time.Duration(0)).
+		Return(&redis.StatusCmd{}).
+		Run(func(args mock.Arguments) {
+			saved <- args.Get(2).(Data)
+		})
+
+	portf := NewPortfolio(0, "", nil, rdbMock, gwMock, accMock, Config{PriceDebounce: time.Millisecond})
+	assert.NoError(t, portf.start())
+	t.Cleanup(func() { portf.Close(false) })
+
+	data := <-saved
+	assert.True(t, decimal.NewDecimal(300, 0).Eq(data.Balance.Total[USDT]))
+	assert.True(t, decimal.NewDecimal(200, 0).Eq(data.Balance.Available[USDT]))
+	assert.True(t, decimal.NewDecimal(100, 0).Eq(data.Balance.Locked[USDT]))
+	assert.True(t, decimal.NewDecimal(3, 0).Eq(data.Balance.Details["ETH"].Total.Quantity))
+	assert.Equal(t, PriceInfo{
+		Source:    "test",
+		Path:      ConversionPath{{Symbol: "ETHUSDT"}},
+		UpdatedAt: data.PriceInfo["ETH"][USDT].UpdatedAt,
+		Status:    PriceOK,
+	}, data.PriceInfo["ETH"][USDT])
+	assert.Equal(t, PriceUnpriced, data.PriceInfo["ETH"][BTC].Status)
+	assert.Equal(t, 1, data.Quality.OK)
+	assert.Equal(t, 1, data.Quality.Unpriced)
+	assert.True(t, decimal.NewDecimal(50, 0).Eq(data.Quality.Completeness))
+	if !assert.NotNil(t, onPriceUpdate) {
+		return
+	}
+	assert.Len(t, portf.symbols, 1)
+
+	// Price updates are coalesced into revaluation
+	for i := 0; i < 3; i++ {
+		onPriceUpdate(decimal.Decimal{}, decimal.NewDecimal(100, 0))
+	}
+	select {
+	case data := <-saved:
+		assert.True(t, decimal.NewDecimal(300, 0).Eq(data.Balance.Total[USDT]))
+	case <-time.After(time.Second):
+		t.Fatal("portfolio isn't revalued on price update")
+	}
+}
+
+func TestPortfolio_conversionGraph(t *testing.T) {
+	gwMock := mocks.NewGateway(t)
+	portf := NewPortfolio(0, "", nil, nil, gwMock, nil, Config{})
+	assert.Nil(t, portf.graph, "graph is built before it's needed")
+	graph := portf.conversionGraph()
+	if assert.NotNil(t, graph) {
+		assert.Same(t, graph, portf.conversionGraph())
+	}
+
+	injected := NewConversionGraph(gwMock, CurrencyNormalization{})
+	portf = NewPortfolio(0, "", nil, nil, gwMock, nil, Config{})
+	portf.graph = injected
+	assert.Same(t, injected, portf.conversionGraph())
+}
+
+func TestPortfolio_price(t *testing.T) {
+	ethUsdt := mocks.NewInstrument(t)
+	ethUsdt.
+		On("Price").
+		Return(decimal.NewDecimal(199, 0), decimal.NewDecimal(200, 0))
+	dogeUsdt := mocks.NewInstrument(t)
+	dogeUsdt.
+		On("Price").
+		Return(decimal.NewDecimal(5, 2), decimal.NewDecimal(1, 1))
+	for _, inst := range []*mocks.Instrument{ethUsdt, dogeUsdt} {
+		inst.
+			On("OnPriceUpdate", mock.Anything).
+			Return()
+	}
 
-	portf := NewPortfolio(0, "", nil, nil, gwMock, nil, nil)
-	price := portf.price(ethDogeSymbol.Base, ethDogeSymbol.Quote, 0)
-	assert.True(t, ethDogePrice.Eq(price))
+	gwMock := mocks.NewGateway(t)
+	gwMock.
+		On("AllSymbols").
+		Return([]core.Symbol{{Base: "ETH", Quote: "USDT"}, {Base: "DOGE", Quote: "USDT"}})
+	gwMock.
+		On("Instrument", "ETHUSDT").
+		Return(ethUsdt, nil)
+	gwMock.
+		On("Instrument", "DOGEUSDT").
+		Return(dogeUsdt, nil)
+	gwMock.
+		On("Name").
+		Return("binance")
+
+	now := time.Unix(1654586492, 0)
+	portf := NewPortfolio(0, "", nil, nil, gwMock, nil, Config{})
+	portf.now = func() time.Time { return now }
+	price, info := portf.price("ETH", "USDT")
+	assert.True(t, decimal.NewDecimal(200, 0).Eq(price))
+	assert.Equal(t, PriceInfo{
+		Source:    "binance",
+		Path:      ConversionPath{{Symbol: "ETHUSDT"}},
+		UpdatedAt: now.Unix(),
+		Status:    PriceOK,
+	}, info)
 
 	t.Run("when same symbols", func(t *testing.T) {
-		price := portf.price(ethDogeSymbol.Base, ethDogeSymbol.Base, 0)
+		price, info := portf.price("ETH", "ETH")
 		assert.True(t, price.Eq(decimal.NewDecimal(1, 0)))
+		assert.Empty(t, info.Path)
+		assert.Equal(t, PriceOK, info.Status)
 	})
 
 	t.Run("when reverse", func(t *testing.T) {
-		gwMock.
-			On("Instrument", core.Symbol{Base: ethDogeSymbol.Quote, Quote: ethDogeSymbol.Base}.String()).
-			Return(nil, errors.New("")).
-			Once()
-		gwMock.
-			On("Instrument", ethDogeSymbol.String()).
-			Return(ethDoge, nil).
-			Once()
+		price, info := portf.price("USDT", "ETH")
+		assert.True(t, decimal.NewDecimal(1, 0).Div(decimal.NewDecimal(200, 0)).Eq(price))
+		assert.Equal(t, ConversionPath{{Symbol: "ETHUSDT", Inverse: true}}, info.Path)
+	})
 
-		price := portf.price(ethDogeSymbol.Quote, ethDogeSymbol.Base, 0)
-		assert.True(t, decimal.NewDecimal(1, 0).Div(ethDogePrice).Eq(price))
+	t.Run("when through other currency", func(t *testing.T) {
+		price, info := portf.price("ETH", "DOGE")
+		// ETHDOGE = ETHUSDT / DOGEUSDT
+		assert.True(t, decimal.NewDecimal(2000, 0).Eq(price))
+		assert.Equal(t, ConversionPath{{Symbol: "ETHUSDT"}, {Symbol: "DOGEUSDT", Inverse: true}}, info.Path)
 	})
 
-	t.Run("when recursive", func(t *testing.T) {
-		gwMock.
-			On("Instrument", ethDogeSymbol.String()).
-			Return(nil, errors.New("")).
-			Once()
-		gwMock.
-			On("Instrument", core.Symbol{Base: ethDogeSymbol.Quote, Quote: ethDogeSymbol.Base}.String()).
-			Return(nil, errors.New("")).
-			Once()
-		gwMock.
-			On("AllSymbols").
-			Return([]core.Symbol{
-				{
-					Base: "ETH", Quote: "USDT",
-				},
-			}).
-			Once()
-		usdtDoge := mocks.NewInstrument(t)
-		usdtDogePrice := decimal.NewDecimal(200, 0)
-		usdtDoge.
-			On("Price").
-			Return(decimal.Decimal{}, usdtDogePrice)
-		gwMock.
-			On("Instrument", core.Symbol{Base: "USDT", Quote: ethDogeSymbol.Quote}.String()).
-			Return(usdtDoge, nil).
-			Once()
-		ethUsdt := mocks.NewInstrument(t)
-		ethUsdtPrice := decimal.NewDecimal(200, 0)
-		ethUsdt.
-			On("Price").
-			Return(decimal.Decimal{}, ethUsdtPrice)
-		gwMock.
-			On("Instrument", core.Symbol{Base: "ETH", Quote: "USDT"}.String()).
-			Return(ethUsdt, nil)
-
-		price := portf.price(ethDogeSymbol.Base, ethDogeSymbol.Quote, 0)
-		// ETHDOGE = ETHUSDT * USDTDOGE
-		assert.True(t, ethUsdtPrice.Mul(usdtDogePrice).Eq(price))
+	t.Run("when no path", func(t *testing.T) {
+		price, info := portf.price("ETH", "XRP")
+		assert.True(t, price.IsZero())
+		assert.Equal(t, PriceInfo{Status: PriceUnpriced}, info)
+	})
+
+	t.Run("when stale", func(t *testing.T) {
+		now := now.Add(DefaultPriceMaxAge + time.Second)
+		portf.now = func() time.Time { return now }
+		portf.setPriceUpdated("DOGEUSDT")
+
+		_, info := portf.price("ETH", "DOGE")
+		assert.Equal(t, PriceStale, info.Status)
+		// Age of the least recent update
+		assert.Equal(t, int64(DefaultPriceMaxAge/time.Second+1), info.AgeSecs)
+
+		portf.setPriceUpdated("ETHUSDT")
+		_, info = portf.price("ETH", "DOGE")
+		assert.Equal(t, PriceOK, info.Status)
+		assert.Equal(t, now.Unix(), info.UpdatedAt)
+		assert.Zero(t, info.AgeSecs)
+	})
+
+	t.Run("when pricing policy", func(t *testing.T) {
+		cfg := Config{TakerFees: map[string]float64{"binance": 0.001}}
+		for pricing, expected := range map[PricingPolicy]float64{
+			PricingAsk:         200 / 0.1,
+			PricingBid:         199 / 0.1,
+			PricingMid:         199.5 / 0.075,
+			PricingLiquidation: 199 * 0.999 / 0.1 * 0.999,
+		} {
+			cfg.Pricing = pricing
+			price, _ := NewPortfolio(0, "", nil, nil, gwMock, nil, cfg).price("ETH", "DOGE")
+			assert.InDelta(t, expected, price.Float(), 1e-9, pricing.String())
+		}
+	})
+}
+
+type fxRatesStub struct {
+	rates fx.Rates
+	stale bool
+}
+
+func (f fxRatesStub) Latest() (fx.Rates, bool) {
+	return f.rates, f.stale
+}
+
+func TestPortfolio_fxState(t *testing.T) {
+	eur := Currency("EUR")
+	rates := fx.Rates{
+		Values:    map[string]decimal.Decimal{"EUR": decimal.NewDecimal(92, 2)},
+		UpdatedAt: time.Unix(1654586492, 0),
+	}
+	cfg := Config{Currencies: []Currency{USDT, eur}, Fiat: []Currency{eur}, FX: fxRatesStub{rates: rates}}
+
+	portf := NewPortfolio(0, "", nil, nil, nil, nil, cfg)
+	state := portf.fxState()
+	if assert.NotNil(t, state) {
+		assert.False(t, state.Stale)
+		assert.Equal(t, int64(1654586492), state.UpdatedAt)
+		assert.True(t, decimal.NewDecimal(92, 2).Eq(state.Rates[eur]))
+	}
+
+	t.Run("when no fiat currencies", func(t *testing.T) {
+		cfg := cfg
+		cfg.Currencies = []Currency{USDT}
+		assert.Nil(t, NewPortfolio(0, "", nil, nil, nil, nil, cfg).fxState())
+	})
+
+	t.Run("when rate is unknown", func(t *testing.T) {
+		cfg := cfg
+		cfg.Currencies = []Currency{USDT, eur, "USD"}
+		cfg.Fiat = []Currency{eur, "USD"}
+		state := NewPortfolio(0, "", nil, nil, nil, nil, cfg).fxState()
+		if assert.NotNil(t, state) {
+			assert.True(t, state.Stale)
+			assert.NotContains(t, state.Rates, Currency("USD"))
+		}
+	})
+
+	t.Run("when no provider", func(t *testing.T) {
+		cfg := cfg
+		cfg.FX = nil
+		state := NewPortfolio(0, "", nil, nil, nil, nil, cfg).fxState()
+		if assert.NotNil(t, state) {
+			assert.True(t, state.Stale)
+			assert.Empty(t, state.Rates)
+		}
+	})
+}
+
+func TestPortfolio_updateData(t *testing.T) {
+	ctx := context.Background()
+	rdbMock := mocks.NewRedisClient(t)
+	rdbMock.
+		On("Set", ctx, mock.Anything, mock.Anything, time.Duration(0)).
+		Return(&redis.StatusCmd{})
+	gwMock := mocks.NewGateway(t)
+	gwMock.
+		On("AllSymbols").
+		Return([]core.Symbol{}).
+		Maybe()
+
+	portf := NewPortfolio(0, "", nil, rdbMock, gwMock, nil, Config{Currencies: []Currency{USDT}})
+	data, err := portf.updateData(map[core.Currency]core.Balance{
+		"USDT": {Available: decimal.NewDecimal(10, 0)},
+		"ETH":  {Available: decimal.NewDecimal(1, 0)},
+	})
+	if assert.NoError(t, err) {
+		assert.Contains(t, data.Balance.Details, core.Currency("ETH"))
+		assert.True(t, decimal.NewDecimal(10, 0).Eq(data.Balance.Total[USDT]))
+	}
+
+	t.Run("when asset disappears from balances", func(t *testing.T) {
+		data, err := portf.updateData(map[core.Currency]core.Balance{
+			"USDT": {Available: decimal.NewDecimal(5, 0)},
+		})
+		if assert.NoError(t, err) {
+			assert.NotContains(t, data.Balance.Details, core.Currency("ETH"))
+			assert.NotContains(t, data.Prices, core.Currency("ETH"))
+			assert.True(t, decimal.NewDecimal(5, 0).Eq(data.Balance.Total[USDT]))
+		}
+		assert.True(t, portf.dataHolder.Asset("ETH").Balance.Available.IsZero())
 	})
 }
@@ -0,0 +1,163 @@
+package portfolio
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+var PRL = RegisterTriggerType(TriggerKind{
+	Name:    "PRICE_REACHED_LIMIT",
+	Gateway: true,
+	Decode: func(data []byte) (TriggerParams, error) {
+		params := new(PriceReachedLimitParams)
+		err := json.Unmarshal(data, params)
+		return params, errors.WithStack(err)
+	},
+	New: func(portf *Portfolio, currency Currency, basis BalanceBasis, params TriggerParams) (Trigger, error) {
+		p := &PriceReachedLimit{
+			triggerBase: newTriggerBase(portf, currency, basis),
+			params:      *params.(*PriceReachedLimitParams),
+		}
+		// Instrument is checked and subscribed to price updates in advance
+		price, _, err := p.price()
+		if err != nil {
+			return nil, err
+		}
+		if !price.IsZero() {
+			p.lastPrice = &price
+		}
+		return p, nil
+	},
+	Restore: func(portf *Portfolio, state TriggerState, params TriggerParams) (Trigger, error) {
+		return &PriceReachedLimit{
+			triggerBase: triggerBase{portf: portf, state: state},
+			params:      *params.(*PriceReachedLimitParams),
+		}, nil
+	},
+})
+
+type PriceSide uint8
+
+const (
+	PriceAsk PriceSide = iota + 1
+	PriceBid
+)
+
+var (
+	priceSideKeyValues = map[PriceSide]string{
+		PriceAsk: "ASK",
+		PriceBid: "BID",
+	}
+	priceSideValueKeys = map[string]PriceSide{
+		"ASK": PriceAsk,
+		"BID": PriceBid,
+	}
+)
+
+func (p PriceSide) String() string {
+	return priceSideKeyValues[p]
+}
+
+func (p PriceSide) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *PriceSide) UnmarshalText(text []byte) error {
+	txt := string(text)
+	if side, ok := priceSideValueKeys[txt]; ok {
+		*p = side
+		return nil
+	}
+	return errors.Errorf("invalid price side: %s", txt)
+}
+
+// PriceReachedLimitParams are LimitCondition applied to Side (ASK by default) price of gateway's instrument Symbol, ex. ETHUSDT.
+// Price is in quote currency of Symbol, so currency of trigger isn't used
+type PriceReachedLimitParams struct {
+	Symbol string    `json:"symbol"`
+	Side   PriceSide `json:"side,omitempty"`
+	LimitCondition
+}
+
+func (p *PriceReachedLimitParams) Validate() error {
+	if p.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+	return p.LimitCondition.Validate()
+}
+
+// PriceDetails are bid and ask prices of instrument at execution time
+type PriceDetails struct {
+	Bid decimal.Decimal `json:"bid"`
+	Ask decimal.Decimal `json:"ask"`
+}
+
+// PriceReachedLimit is a trigger executing when price of instrument reaches certain limit or leaves band of prices.
+// Instrument doesn't depend on account's balances: portfolio is revalued on its price updates as well
+type PriceReachedLimit struct {
+	triggerBase
+	params    PriceReachedLimitParams
+	lastPrice *decimal.Decimal // price of previous check, nil if trigger has just been restored
+}
+
+// TryExecute returns non-empty ExecutionStatus if trigger is executed.
+// ExecutionStatus.CurrentValue is a price, ExecutionStatus.Details are PriceDetails.
+// Trigger isn't executed until instrument has price. ExecutionStatus.Done is always equal to ExecutionStatus.Ok for this type of trigger
+func (p *PriceReachedLimit) TryExecute() (*ExecutionStatus, error) {
+	price, details, err := p.price()
+	if err != nil {
+		return nil, err
+	}
+	if price.IsZero() {
+		return &ExecutionStatus{}, nil
+	}
+
+	lastPrice := p.lastPrice
+	p.lastPrice = &price
+
+	ok := p.params.Check(price, lastPrice)
+	return &ExecutionStatus{
+		Ok:           ok,
+		Done:         ok,
+		CurrentValue: price,
+		Details:      details,
+	}, nil
+}
+
+func (p *PriceReachedLimit) Settings() TriggerSettings {
+	params := p.params
+	return p.settings(PRL, &params)
+}
+
+// Update returns copy of trigger with patched params. Previous price is reset if symbol or side is changed
+func (p *PriceReachedLimit) Update(patch json.RawMessage) (Trigger, error) {
+	params, err := patchTriggerParams(PRL, &p.params, patch)
+	if err != nil {
+		return nil, err
+	}
+	updated := *p
+	updated.params = *params.(*PriceReachedLimitParams)
+	if updated.params.Symbol != p.params.Symbol || updated.params.Side != p.params.Side {
+		if _, _, err := updated.price(); err != nil {
+			return nil, errors.Wrap(ErrInvalidTriggerUpdate, err.Error())
+		}
+		updated.lastPrice = nil
+	}
+	return &updated, nil
+}
+
+// price returns current price of instrument's side, zero if it's unknown yet
+func (p *PriceReachedLimit) price() (decimal.Decimal, PriceDetails, error) {
+	inst, err := p.portf.instrument(p.params.Symbol)
+	if err != nil {
+		return decimal.Decimal{}, PriceDetails{}, err
+	}
+	var details PriceDetails
+	details.Bid, details.Ask = inst.Price()
+	if p.params.Side == PriceBid {
+		return details.Bid, details, nil
+	}
+	return details.Ask, details, nil
+}
@@ -0,0 +1,92 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/test/mocks"
+)
+
+func TestPriceReachedLimit_TryExecute(t *testing.T) {
+	bid, ask := decimal.NewDecimal(3900, 0), decimal.NewDecimal(3950, 0)
+	ethUsdt := mocks.NewInstrument(t)
+	ethUsdt.
+		On("Price").
+		Return(func() decimal.Decimal { return bid }, func() decimal.Decimal { return ask })
+	ethUsdt.
+		On("OnPriceUpdate", mock.Anything).
+		Return().
+		Once()
+
+	gwMock := mocks.NewGateway(t)
+	gwMock.
+		On("Instrument", "ETHUSDT").
+		Return(ethUsdt, nil).
+		Once()
+	gwMock.
+		On("Instrument", "UNKNOWN").
+		Return(nil, errors.New("not found"))
+	gwMock.
+		On("Name").
+		Return("test")
+
+	portf := NewPortfolio(0, "", nil, nil, gwMock, nil, Config{})
+
+	t.Run("ask above", func(t *testing.T) {
+		trigger, err := NewTrigger(portf, PRL, USDT, []byte(`{"symbol":"ETHUSDT","limit":"4000"}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		status, err := trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.False(t, status.Ok)
+
+		ask = decimal.NewDecimal(4010, 0)
+		status, err = trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.True(t, status.Ok)
+		assert.True(t, ask.Eq(status.CurrentValue))
+		assert.Equal(t, PriceDetails{Bid: bid, Ask: ask}, status.Details)
+	})
+
+	t.Run("bid crosses", func(t *testing.T) {
+		trigger, err := NewTrigger(portf, PRL, USDT,
+			[]byte(`{"symbol":"ETHUSDT","side":"BID","limit":"3800","direction":"CROSSES"}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		bid = decimal.NewDecimal(3850, 0)
+		status, err := trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.False(t, status.Ok)
+
+		bid = decimal.NewDecimal(3750, 0)
+		status, err = trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.True(t, status.Ok)
+		assert.True(t, bid.Eq(status.CurrentValue))
+	})
+
+	t.Run("when price is unknown", func(t *testing.T) {
+		trigger, err := NewTrigger(portf, PRL, USDT, []byte(`{"symbol":"ETHUSDT","limit":"1","direction":"BELOW"}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ask = decimal.Decimal{}
+		status, err := trigger.TryExecute()
+		assert.NoError(t, err)
+		assert.False(t, status.Ok)
+	})
+
+	t.Run("when instrument is unknown", func(t *testing.T) {
+		_, err := NewTrigger(portf, PRL, USDT, []byte(`{"symbol":"UNKNOWN","limit":"1"}`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,88 @@
+package portfolio
+
+import (
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+// DefaultPricing is a PricingPolicy of portfolios if it isn't configured
+const DefaultPricing = PricingAsk
+
+// PricingPolicy defines which instrument's price currencies are converted by at every step of ConversionPath.
+// Base currency is converted to quote one by the price, quote currency is converted to base one by the opposite price inverted:
+//   - ASK: ask in both directions (the default)
+//   - BID: bid, 1/ask in reverse (value of sale at market)
+//   - MID: middle between bid and ask in both directions
+//   - LIQUIDATION: BID reduced by gateway's taker fee at every step (value of sale at market after fees)
+type PricingPolicy uint8
+
+const (
+	PricingAsk PricingPolicy = iota + 1
+	PricingBid
+	PricingMid
+	PricingLiquidation
+)
+
+var (
+	pricingPolicyKeyValues = map[PricingPolicy]string{
+		PricingAsk:         "ASK",
+		PricingBid:         "BID",
+		PricingMid:         "MID",
+		PricingLiquidation: "LIQUIDATION",
+	}
+	pricingPolicyValueKeys = map[string]PricingPolicy{
+		"ASK":         PricingAsk,
+		"BID":         PricingBid,
+		"MID":         PricingMid,
+		"LIQUIDATION": PricingLiquidation,
+	}
+)
+
+func (p PricingPolicy) String() string {
+	return pricingPolicyKeyValues[p]
+}
+
+func (p PricingPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *PricingPolicy) UnmarshalText(text []byte) error {
+	txt := string(text)
+	if policy, ok := pricingPolicyValueKeys[txt]; ok {
+		*p = policy
+		return nil
+	}
+	return errors.Errorf("invalid pricing policy: %s", txt)
+}
+
+// rate returns amount of target currency per 1 unit of source one by instrument's bid/ask according to policy.
+// Inverse is true if source currency is quote one of instrument. Zero is returned if required price is unknown
+func (p PricingPolicy) rate(bid, ask decimal.Decimal, inverse bool, takerFee decimal.Decimal) decimal.Decimal {
+	var price decimal.Decimal
+	switch p {
+	case PricingBid, PricingLiquidation:
+		price = bid
+		if inverse {
+			price = ask
+		}
+	case PricingMid:
+		if bid.IsZero() || ask.IsZero() {
+			return decimal.Decimal{}
+		}
+		price = bid.Add(ask).Div(decimal.NewDecimal(2, 0))
+	default:
+		price = ask
+	}
+	if price.IsZero() {
+		return decimal.Decimal{}
+	}
+
+	one := decimal.NewDecimal(1, 0)
+	if inverse {
+		price = one.Div(price)
+	}
+	if p == PricingLiquidation {
+		price = price.Mul(one.Sub(takerFee))
+	}
+	return price
+}
@@ -0,0 +1,173 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+// DefaultPriceMaxAge is a default period price stays OK within since the last update of its instruments
+const DefaultPriceMaxAge = 5 * time.Minute
+
+// PriceStatus is a quality of price in Data:
+//   - OK: price is derived from instruments (and FX rates) updated within Config.PriceMaxAge
+//   - STALE: some instrument of conversion path hasn't been updated within Config.PriceMaxAge or FX rates are stale
+//   - UNPRICED: there is no conversion path or some price along it is unknown, price is zero
+type PriceStatus uint8
+
+const (
+	PriceOK PriceStatus = iota + 1
+	PriceStale
+	PriceUnpriced
+)
+
+var (
+	priceStatusKeyValues = map[PriceStatus]string{
+		PriceOK:       "OK",
+		PriceStale:    "STALE",
+		PriceUnpriced: "UNPRICED",
+	}
+	priceStatusValueKeys = map[string]PriceStatus{
+		"OK":       PriceOK,
+		"STALE":    PriceStale,
+		"UNPRICED": PriceUnpriced,
+	}
+)
+
+func (s PriceStatus) String() string {
+	return priceStatusKeyValues[s]
+}
+
+func (s PriceStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *PriceStatus) UnmarshalText(text []byte) error {
+	txt := string(text)
+	if status, ok := priceStatusValueKeys[txt]; ok {
+		*s = status
+		return nil
+	}
+	return errors.Errorf("invalid price status: %s", txt)
+}
+
+type (
+	// PriceInfo describes price of Data: Source is a gateway name ("fx" is appended for fiat currencies),
+	// Path is a conversion path, UpdatedAt is a time of the least recent update among instruments of path (and FX rates)
+	PriceInfo struct {
+		Source    string         `json:"source,omitempty" example:"binance"`
+		Path      ConversionPath `json:"path,omitempty"`
+		UpdatedAt int64          `json:"updated_at,omitempty" format:"timestamp"`
+		AgeSecs   int64          `json:"age_secs"`
+		Status    PriceStatus    `json:"status" validate:"required" swaggertype:"string" enums:"OK,STALE,UNPRICED"`
+	}
+	// Quality summarizes statuses of prices held assets are valued by in every Currency.
+	// Completeness is a percentage of OK prices, it's 100 if portfolio holds nothing
+	Quality struct {
+		Completeness decimal.Decimal `json:"completeness" validate:"required" example:"100"`
+		OK           int             `json:"ok"`
+		Stale        int             `json:"stale"`
+		Unpriced     int             `json:"unpriced"`
+	}
+)
+
+// setUpdatedAt sets UpdatedAt and AgeSecs of price by time of its least recent update
+func (i *PriceInfo) setUpdatedAt(updatedAt, now time.Time) {
+	i.UpdatedAt = updatedAt.Unix()
+	i.AgeSecs = 0
+	if age := now.Sub(updatedAt); age > 0 {
+		i.AgeSecs = int64(age / time.Second)
+	}
+}
+
+// withFX extends info of USDT price by conversion into fiat currency by FX rate.
+// Staleness of FX rates is defined by their provider (see fx.Poller)
+func (i PriceInfo) withFX(fiat Currency, fx *FXState, now time.Time) PriceInfo {
+	if i.Source == "" {
+		i.Source = "fx"
+	} else {
+		i.Source += "+fx"
+	}
+	if i.Path != nil {
+		i.Path = append(i.Path[:len(i.Path):len(i.Path)], ConversionStep{Symbol: USDT.String() + fiat.String(), FX: true})
+	}
+	if fx.Rates[fiat].IsZero() {
+		i.Status = PriceUnpriced
+	}
+	if i.Status == PriceOK && fx.Stale {
+		i.Status = PriceStale
+	}
+	if fx.UpdatedAt != 0 && (i.UpdatedAt == 0 || fx.UpdatedAt < i.UpdatedAt) {
+		i.setUpdatedAt(time.Unix(fx.UpdatedAt, 0), now)
+	}
+	return i
+}
+
+// newQuality summarizes statuses of prices of assets with non-zero total quantity
+func newQuality(balances map[core.Currency]core.Balance, info map[core.Currency]map[Currency]PriceInfo) Quality {
+	var q Quality
+	for cur, bal := range balances {
+		if bal.Available.Add(bal.Locked).IsZero() {
+			continue
+		}
+		for _, price := range info[cur] {
+			switch price.Status {
+			case PriceOK:
+				q.OK++
+			case PriceStale:
+				q.Stale++
+			default:
+				q.Unpriced++
+			}
+		}
+	}
+	total := q.OK + q.Stale + q.Unpriced
+	if total == 0 {
+		q.Completeness = decimal.NewDecimal(100, 0)
+		return q
+	}
+	q.Completeness = decimal.NewDecimal(int64(q.OK*100), 0).Div(decimal.NewDecimal(int64(total), 0))
+	return q
+}
+
+// QualityGate settings are common for all trigger types. Trigger isn't evaluated while Quality.Completeness
+// of portfolio's Data is less than MinCompleteness (percentage). Trigger without the settings is always evaluated
+type QualityGate struct {
+	MinCompleteness *decimal.Decimal `json:"min_completeness,omitempty" swaggertype:"number" example:"95"`
+}
+
+func (g *QualityGate) Validate() error {
+	if g.MinCompleteness == nil {
+		return nil
+	}
+	if !decimal.NewDecimal(0, 0).LessThan(*g.MinCompleteness) || decimal.NewDecimal(100, 0).LessThan(*g.MinCompleteness) {
+		return errors.New("min_completeness must be within (0, 100]")
+	}
+	return nil
+}
+
+// passes returns true if trigger may be evaluated with data of quality
+func (g *QualityGate) passes(quality Quality) bool {
+	return g.MinCompleteness == nil || !quality.Completeness.LessThan(*g.MinCompleteness)
+}
+
+// patchQualityGate extracts QualityGate settings from patch of trigger and overlays them onto copy of gate.
+// Null removes setting. It returns nil QualityGate if patch doesn't contain them and the rest of patch
+func patchQualityGate(gate QualityGate, patch json.RawMessage) (*QualityGate, json.RawMessage, error) {
+	gatePatch, patch, err := splitPatch(patch, "min_completeness")
+	if err != nil || gatePatch == nil {
+		return nil, patch, err
+	}
+
+	gate.MinCompleteness = nil
+	if err := json.Unmarshal(gatePatch, &gate); err != nil {
+		return nil, nil, errors.Wrap(ErrInvalidTriggerUpdate, err.Error())
+	}
+	if err := gate.Validate(); err != nil {
+		return nil, nil, errors.Wrap(ErrInvalidTriggerUpdate, err.Error())
+	}
+	return &gate, patch, nil
+}
@@ -0,0 +1,95 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+func TestNewQuality(t *testing.T) {
+	balances := map[core.Currency]core.Balance{
+		"ETH":  {Available: decimal.NewDecimal(1, 0)},
+		"DOGE": {Locked: decimal.NewDecimal(1, 0)},
+		"XRP":  {},
+	}
+	info := map[core.Currency]map[Currency]PriceInfo{
+		"ETH":  {USDT: {Status: PriceOK}, BTC: {Status: PriceOK}},
+		"DOGE": {USDT: {Status: PriceStale}, BTC: {Status: PriceUnpriced}},
+		"XRP":  {USDT: {Status: PriceUnpriced}, BTC: {Status: PriceUnpriced}},
+	}
+
+	quality := newQuality(balances, info)
+	assert.Equal(t, 2, quality.OK)
+	assert.Equal(t, 1, quality.Stale)
+	assert.Equal(t, 1, quality.Unpriced, "assets that aren't held are ignored")
+	assert.True(t, decimal.NewDecimal(50, 0).Eq(quality.Completeness))
+
+	t.Run("when nothing is held", func(t *testing.T) {
+		quality := newQuality(nil, info)
+		assert.True(t, decimal.NewDecimal(100, 0).Eq(quality.Completeness))
+	})
+}
+
+func TestPriceInfo_withFX(t *testing.T) {
+	eur := Currency("EUR")
+	now := time.Unix(1654586492, 0)
+	fx := &FXState{
+		Rates:     ConvertedTo{eur: decimal.NewDecimal(92, 2)},
+		UpdatedAt: now.Add(-time.Minute).Unix(),
+	}
+	info := PriceInfo{Source: "binance", Path: ConversionPath{{Symbol: "ETHUSDT"}}, Status: PriceOK}
+	info.setUpdatedAt(now, now)
+
+	assert.Equal(t, PriceInfo{
+		Source:    "binance+fx",
+		Path:      ConversionPath{{Symbol: "ETHUSDT"}, {Symbol: "USDTEUR", FX: true}},
+		UpdatedAt: fx.UpdatedAt,
+		AgeSecs:   60,
+		Status:    PriceOK,
+	}, info.withFX(eur, fx, now))
+
+	fx.Stale = true
+	assert.Equal(t, PriceStale, info.withFX(eur, fx, now).Status)
+	assert.Equal(t, PriceUnpriced, info.withFX("USD", fx, now).Status)
+	assert.Equal(t, "fx", PriceInfo{Path: ConversionPath{}, Status: PriceOK}.withFX(eur, fx, now).Source)
+}
+
+func TestQualityGate_Validate(t *testing.T) {
+	for _, value := range []int64{0, -1, 101} {
+		value := decimal.NewDecimal(value, 0)
+		assert.Error(t, (&QualityGate{MinCompleteness: &value}).Validate(), value.String())
+	}
+	value := decimal.NewDecimal(100, 0)
+	assert.NoError(t, (&QualityGate{MinCompleteness: &value}).Validate())
+	assert.NoError(t, (&QualityGate{}).Validate())
+}
+
+func TestPatchQualityGate(t *testing.T) {
+	minCompleteness := decimal.NewDecimal(90, 0)
+	gate := QualityGate{MinCompleteness: &minCompleteness}
+
+	patched, rest, err := patchQualityGate(gate, []byte(`{"min_completeness": 95, "limit": 1}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `{"limit": 1}`, string(rest))
+	if assert.NotNil(t, patched.MinCompleteness) {
+		assert.True(t, decimal.NewDecimal(95, 0).Eq(*patched.MinCompleteness))
+	}
+	assert.True(t, decimal.NewDecimal(90, 0).Eq(*gate.MinCompleteness), "original gate is untouched")
+
+	patched, _, err = patchQualityGate(gate, []byte(`{"min_completeness": null}`))
+	if assert.NoError(t, err) {
+		assert.Nil(t, patched.MinCompleteness)
+	}
+
+	_, _, err = patchQualityGate(gate, []byte(`{"min_completeness": 101}`))
+	assert.ErrorIs(t, err, ErrInvalidTriggerUpdate)
+
+	patched, _, err = patchQualityGate(gate, []byte(`{"limit": 1}`))
+	assert.NoError(t, err)
+	assert.Nil(t, patched)
+}
@@ -0,0 +1,164 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+// MaxRateOfChangeWindow is a max window of RateOfChange trigger and retention period of portfolio's recent total costs
+const MaxRateOfChangeWindow = 24 * time.Hour
+
+var ROC = RegisterTriggerType(TriggerKind{
+	Name: "RATE_OF_CHANGE",
+	Decode: func(data []byte) (TriggerParams, error) {
+		params := new(RateOfChangeParams)
+		err := json.Unmarshal(data, params)
+		return params, errors.WithStack(err)
+	},
+	New: func(portf *Portfolio, currency Currency, basis BalanceBasis, params TriggerParams) (Trigger, error) {
+		return &RateOfChange{
+			triggerBase: newTriggerBase(portf, currency, basis),
+			params:      *params.(*RateOfChangeParams),
+		}, nil
+	},
+	Restore: func(portf *Portfolio, state TriggerState, params TriggerParams) (Trigger, error) {
+		return &RateOfChange{
+			triggerBase: triggerBase{portf: portf, state: state},
+			params:      *params.(*RateOfChangeParams),
+		}, nil
+	},
+})
+
+type ChangeDirection uint8
+
+const (
+	ChangeAny ChangeDirection = iota + 1
+	ChangeUp
+	ChangeDown
+)
+
+var (
+	changeDirectionKeyValues = map[ChangeDirection]string{
+		ChangeAny:  "ANY",
+		ChangeUp:   "UP",
+		ChangeDown: "DOWN",
+	}
+	changeDirectionValueKeys = map[string]ChangeDirection{
+		"ANY":  ChangeAny,
+		"UP":   ChangeUp,
+		"DOWN": ChangeDown,
+	}
+)
+
+func (c ChangeDirection) String() string {
+	return changeDirectionKeyValues[c]
+}
+
+func (c ChangeDirection) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *ChangeDirection) UnmarshalText(text []byte) error {
+	txt := string(text)
+	if direction, ok := changeDirectionValueKeys[txt]; ok {
+		*c = direction
+		return nil
+	}
+	return errors.Errorf("invalid change direction: %s", txt)
+}
+
+// RateOfChangeParams
+// Window is a duration string (ex. "15m") of recent period total cost change is measured within.
+// Direction is UP - rise from window's minimum, DOWN - drop from window's maximum, ANY (default) - either of them
+type RateOfChangeParams struct {
+	Window    string          `json:"window"`
+	Percent   decimal.Decimal `json:"percent"`
+	Direction ChangeDirection `json:"direction,omitempty"`
+}
+
+func (r *RateOfChangeParams) Validate() error {
+	window, err := time.ParseDuration(r.Window)
+	if err != nil {
+		return errors.Wrap(err, "invalid window")
+	}
+	if window <= 0 || window > MaxRateOfChangeWindow {
+		return errors.Errorf("window must be within (0, %s]", MaxRateOfChangeWindow)
+	}
+	if !decimal.NewDecimal(0, 0).LessThan(r.Percent) {
+		return errors.New("percent must be positive")
+	}
+	return nil
+}
+
+// window returns parsed window. It's supposed to be called after successful validation
+func (r *RateOfChangeParams) window() time.Duration {
+	window, _ := time.ParseDuration(r.Window)
+	return window
+}
+
+// RateOfChange is a trigger executing when portfolio's total cost changes by certain % within recent time window.
+// Window is a rolling window of portfolio's total costs, so trigger is executed regardless of its creation time
+type RateOfChange struct {
+	triggerBase
+	params RateOfChangeParams
+}
+
+// TryExecute returns non-empty ExecutionStatus if trigger is executed.
+// ExecutionStatus.CurrentValue is a change within window in %, negative for drop.
+// ExecutionStatus.Done is always equal to ExecutionStatus.Ok for this type of trigger
+func (r *RateOfChange) TryExecute() (*ExecutionStatus, error) {
+	totalCost := r.totalBalance()
+	min, max, ok := r.portf.totals.extremes(r.portf.now().Add(-r.params.window()), r.state.Basis, r.state.Currency)
+	if !ok {
+		return &ExecutionStatus{}, nil
+	}
+
+	var rise, drop decimal.Decimal
+	if !min.IsZero() {
+		rise = totalCost.Sub(min).Div(min).MulFloat(100)
+	}
+	if !max.IsZero() {
+		drop = max.Sub(totalCost).Div(max).MulFloat(100)
+	}
+
+	var change decimal.Decimal
+	switch r.params.Direction {
+	case ChangeUp:
+		change = rise
+		ok = !rise.LessThan(r.params.Percent)
+	case ChangeDown:
+		change = drop.MulFloat(-1)
+		ok = !drop.LessThan(r.params.Percent)
+	default:
+		if drop.LessThan(rise) {
+			change = rise
+		} else {
+			change = drop.MulFloat(-1)
+		}
+		ok = !rise.LessThan(r.params.Percent) || !drop.LessThan(r.params.Percent)
+	}
+	return &ExecutionStatus{
+		Ok:           ok,
+		Done:         ok,
+		CurrentValue: change,
+	}, nil
+}
+
+func (r *RateOfChange) Settings() TriggerSettings {
+	params := r.params
+	return r.settings(ROC, &params)
+}
+
+// Update returns copy of trigger with patched window, percent and/or direction
+func (r *RateOfChange) Update(patch json.RawMessage) (Trigger, error) {
+	params, err := patchTriggerParams(ROC, &r.params, patch)
+	if err != nil {
+		return nil, err
+	}
+	updated := *r
+	updated.params = *params.(*RateOfChangeParams)
+	return &updated, nil
+}
@@ -0,0 +1,93 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+func TestRateOfChange_TryExecute(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		direction ChangeDirection
+		costs     []int64 // sampled every minute unless every is set
+		every     time.Duration
+		ok        bool
+	}{
+		{name: "when rise within window", direction: ChangeUp, costs: []int64{1000, 1050, 1100}, ok: true},
+		{name: "when rise is outside window", direction: ChangeUp, costs: []int64{900, 1000}, every: 3 * time.Minute},
+		{name: "when drop for UP", direction: ChangeUp, costs: []int64{1000, 950, 900}},
+		{name: "when drop within window", direction: ChangeDown, costs: []int64{1000, 1100, 990}, ok: true},
+		{name: "when drop is less than percent", direction: ChangeDown, costs: []int64{1000, 950}},
+		{name: "when drop for ANY", direction: ChangeAny, costs: []int64{1000, 900}, ok: true},
+		{name: "when rise for ANY", direction: ChangeAny, costs: []int64{1000, 1100}, ok: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(1654586492, 0)
+			portf := NewPortfolio(0, "", nil, nil, nil, nil, Config{})
+			portf.now = func() time.Time { return now }
+
+			trigger, err := NewTrigger(portf, ROC, USDT,
+				[]byte(`{"window":"2m","percent":"10","direction":"`+tt.direction.String()+`"}`))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var status *ExecutionStatus
+			for i, cost := range tt.costs {
+				if i > 0 {
+					if tt.every == 0 {
+						tt.every = time.Minute
+					}
+					now = now.Add(tt.every)
+				}
+				portf.dataHolder.balances.Total[USDT] = decimal.NewDecimal(cost, 0)
+				portf.totals.add(now, Balances{Total: ConvertedTo{USDT: decimal.NewDecimal(cost, 0)}})
+				if status, err = trigger.TryExecute(); !assert.NoError(t, err) {
+					return
+				}
+			}
+			assert.Equal(t, tt.ok, status.Ok)
+			assert.Equal(t, tt.ok, status.Done)
+		})
+	}
+
+	t.Run("when invalid window", func(t *testing.T) {
+		portf := NewPortfolio(0, "", nil, nil, nil, nil, Config{})
+		for _, params := range []string{
+			`{"window":"abc","percent":"10"}`,
+			`{"window":"48h","percent":"10"}`,
+			`{"window":"-1m","percent":"10"}`,
+		} {
+			_, err := NewTrigger(portf, ROC, USDT, []byte(params))
+			assert.Error(t, err, params)
+		}
+	})
+}
+
+func TestTotalsWindow(t *testing.T) {
+	now := time.Unix(1654586492, 0)
+	w := newTotalsWindow(time.Hour)
+	for i, cost := range []int64{500, 1000, 800, 900} {
+		w.add(now.Add(time.Duration(i)*30*time.Minute), Balances{
+			Total:     ConvertedTo{USDT: decimal.NewDecimal(cost, 0)},
+			Available: ConvertedTo{USDT: decimal.NewDecimal(cost/2, 0)},
+		})
+	}
+	assert.Len(t, w.samples, 3)
+
+	min, max, ok := w.extremes(now.Add(time.Hour), BasisTotal, USDT)
+	if assert.True(t, ok) {
+		assert.True(t, min.Eq(decimal.NewDecimal(800, 0)))
+		assert.True(t, max.Eq(decimal.NewDecimal(900, 0)))
+	}
+	min, max, ok = w.extremes(now.Add(time.Hour), BasisAvailable, USDT)
+	if assert.True(t, ok) {
+		assert.True(t, min.Eq(decimal.NewDecimal(400, 0)))
+		assert.True(t, max.Eq(decimal.NewDecimal(450, 0)))
+	}
+	_, _, ok = w.extremes(now.Add(2*time.Hour), BasisTotal, USDT)
+	assert.False(t, ok)
+}
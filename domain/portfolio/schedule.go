@@ -0,0 +1,208 @@
+package portfolio
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/egsam98/portfolio/pg/repo"
+)
+
+// ExpiryCheckInterval is a period expired triggers are looked up with when portfolio isn't revalued
+const ExpiryCheckInterval = time.Minute
+
+// Schedule settings are common for all trigger types. Trigger is evaluated since ActiveFrom and within ActiveHours only.
+// Trigger is removed once ExpiresAt is reached and reported with TriggerEvent of EXPIRED kind.
+// Trigger without schedule settings is always active
+type Schedule struct {
+	ActiveFrom  *int64        `json:"active_from,omitempty" format:"timestamp" example:"1654586492"`
+	ExpiresAt   *int64        `json:"expires_at,omitempty" format:"timestamp" example:"1657178492"`
+	ActiveHours []ActiveHours `json:"active_hours,omitempty"`
+}
+
+func (s *Schedule) Validate() error {
+	if s.ActiveFrom != nil && s.ExpiresAt != nil && *s.ExpiresAt <= *s.ActiveFrom {
+		return errors.New("expires_at must be greater than active_from")
+	}
+	for i, hours := range s.ActiveHours {
+		if err := hours.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid active hours #%d", i)
+		}
+	}
+	return nil
+}
+
+// active returns true if trigger is supposed to be evaluated at certain time
+func (s *Schedule) active(now time.Time) bool {
+	if s.ActiveFrom != nil && now.Unix() < *s.ActiveFrom {
+		return false
+	}
+	if s.expired(now) {
+		return false
+	}
+	if len(s.ActiveHours) == 0 {
+		return true
+	}
+	for _, hours := range s.ActiveHours {
+		if hours.contain(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schedule) expired(now time.Time) bool {
+	return s.ExpiresAt != nil && now.Unix() >= *s.ExpiresAt
+}
+
+// clone returns deep copy of schedule to be patched
+func (s Schedule) clone() Schedule {
+	if s.ActiveFrom != nil {
+		activeFrom := *s.ActiveFrom
+		s.ActiveFrom = &activeFrom
+	}
+	if s.ExpiresAt != nil {
+		expiresAt := *s.ExpiresAt
+		s.ExpiresAt = &expiresAt
+	}
+	s.ActiveHours = append([]ActiveHours(nil), s.ActiveHours...)
+	return s
+}
+
+func (s *Schedule) params() (repo.PortfolioTriggers_UpdateScheduleParams, error) {
+	var params repo.PortfolioTriggers_UpdateScheduleParams
+	if s.ActiveFrom != nil {
+		params.ActiveFrom = sql.NullTime{Time: time.Unix(*s.ActiveFrom, 0).UTC(), Valid: true}
+	}
+	if s.ExpiresAt != nil {
+		params.ExpiresAt = sql.NullTime{Time: time.Unix(*s.ExpiresAt, 0).UTC(), Valid: true}
+	}
+	if len(s.ActiveHours) > 0 {
+		data, err := json.Marshal(s.ActiveHours)
+		if err != nil {
+			return params, errors.Wrapf(err, "failed to marshal %T", s.ActiveHours)
+		}
+		params.ActiveHours = data
+	}
+	return params, nil
+}
+
+// restoreSchedule creates Schedule from columns of portfolio_triggers table
+func restoreSchedule(activeFrom, expiresAt *time.Time, activeHours json.RawMessage) (Schedule, error) {
+	var s Schedule
+	if activeFrom != nil {
+		unix := activeFrom.Unix()
+		s.ActiveFrom = &unix
+	}
+	if expiresAt != nil {
+		unix := expiresAt.Unix()
+		s.ExpiresAt = &unix
+	}
+	if len(activeHours) > 0 {
+		if err := json.Unmarshal(activeHours, &s.ActiveHours); err != nil {
+			return s, errors.Wrapf(err, "failed to unmarshal %s into %T", string(activeHours), s.ActiveHours)
+		}
+	}
+	return s, nil
+}
+
+// ActiveHours is a daily UTC window [From, To) on Days of week, every day if Days are empty.
+// Window passes midnight if From is greater than To, ex. 22:00-06:00 on FRI is active till Saturday's 06:00
+type ActiveHours struct {
+	Days []Weekday `json:"days,omitempty" swaggertype:"array,string" enums:"MON,TUE,WED,THU,FRI,SAT,SUN"`
+	From DayTime   `json:"from" swaggertype:"string" example:"09:00"`
+	To   DayTime   `json:"to" swaggertype:"string" example:"17:30"`
+}
+
+func (a ActiveHours) Validate() error {
+	if a.From == a.To {
+		return errors.New("from and to must differ")
+	}
+	return nil
+}
+
+func (a ActiveHours) contain(now time.Time) bool {
+	now = now.UTC()
+	t := DayTime(now.Hour()*60 + now.Minute())
+	day := Weekday(now.Weekday())
+	if a.From < a.To {
+		return a.on(day) && a.From <= t && t < a.To
+	}
+	yesterday := Weekday((now.Weekday() + 6) % 7)
+	return a.on(day) && a.From <= t || a.on(yesterday) && t < a.To
+}
+
+func (a ActiveHours) on(day Weekday) bool {
+	if len(a.Days) == 0 {
+		return true
+	}
+	for _, d := range a.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+type Weekday time.Weekday
+
+var (
+	weekdayKeyValues = map[Weekday]string{
+		Weekday(time.Monday):    "MON",
+		Weekday(time.Tuesday):   "TUE",
+		Weekday(time.Wednesday): "WED",
+		Weekday(time.Thursday):  "THU",
+		Weekday(time.Friday):    "FRI",
+		Weekday(time.Saturday):  "SAT",
+		Weekday(time.Sunday):    "SUN",
+	}
+	weekdayValueKeys = map[string]Weekday{
+		"MON": Weekday(time.Monday),
+		"TUE": Weekday(time.Tuesday),
+		"WED": Weekday(time.Wednesday),
+		"THU": Weekday(time.Thursday),
+		"FRI": Weekday(time.Friday),
+		"SAT": Weekday(time.Saturday),
+		"SUN": Weekday(time.Sunday),
+	}
+)
+
+func (w Weekday) String() string {
+	return weekdayKeyValues[w]
+}
+
+func (w Weekday) MarshalText() ([]byte, error) {
+	return []byte(w.String()), nil
+}
+
+func (w *Weekday) UnmarshalText(text []byte) error {
+	txt := string(text)
+	if day, ok := weekdayValueKeys[txt]; ok {
+		*w = day
+		return nil
+	}
+	return errors.Errorf("invalid weekday: %s", txt)
+}
+
+// DayTime is a time of day in minutes since midnight presented as HH:MM
+type DayTime uint16
+
+func (d DayTime) String() string {
+	return fmt.Sprintf("%02d:%02d", d/60, d%60)
+}
+
+func (d DayTime) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *DayTime) UnmarshalText(text []byte) error {
+	t, err := time.Parse("15:04", string(text))
+	if err != nil {
+		return errors.Errorf("invalid time of day %q, expected HH:MM", string(text))
+	}
+	*d = DayTime(t.Hour()*60 + t.Minute())
+	return nil
+}
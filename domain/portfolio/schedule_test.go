@@ -0,0 +1,77 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule_active(t *testing.T) {
+	var schedule Schedule
+	err := json.Unmarshal([]byte(`{
+		"active_from": 1654473600,
+		"expires_at": 1655078400,
+		"active_hours": [{"days": ["MON", "TUE"], "from": "09:00", "to": "17:30"}, {"days": ["FRI"], "from": "22:00", "to": "06:00"}]
+	}`), &schedule)
+	if !assert.NoError(t, err) || !assert.NoError(t, schedule.Validate()) {
+		return
+	}
+
+	for _, tt := range []struct {
+		now    time.Time
+		active bool
+	}{
+		{now: time.Date(2022, 6, 6, 8, 59, 0, 0, time.UTC)},                // Monday before window
+		{now: time.Date(2022, 6, 6, 9, 0, 0, 0, time.UTC), active: true},   // Monday
+		{now: time.Date(2022, 6, 7, 17, 29, 0, 0, time.UTC), active: true}, // Tuesday
+		{now: time.Date(2022, 6, 7, 17, 30, 0, 0, time.UTC)},
+		{now: time.Date(2022, 6, 8, 12, 0, 0, 0, time.UTC)},                // Wednesday
+		{now: time.Date(2022, 6, 10, 23, 0, 0, 0, time.UTC), active: true}, // Friday night
+		{now: time.Date(2022, 6, 11, 5, 59, 0, 0, time.UTC), active: true}, // Saturday morning
+		{now: time.Date(2022, 6, 11, 23, 0, 0, 0, time.UTC)},
+		{now: time.Date(2022, 6, 13, 10, 0, 0, 0, time.UTC)}, // Monday after expiration
+	} {
+		assert.Equal(t, tt.active, schedule.active(tt.now), tt.now.String())
+	}
+	assert.False(t, schedule.active(time.Date(2022, 5, 30, 10, 0, 0, 0, time.UTC)), "before active_from")
+	assert.True(t, schedule.expired(time.Unix(1655078400, 0)))
+	assert.True(t, (&Schedule{}).active(time.Now()))
+}
+
+func TestSchedule_Validate(t *testing.T) {
+	activeFrom, expiresAt := int64(1654560000), int64(1654560000)
+	for _, s := range []Schedule{
+		{ActiveFrom: &activeFrom, ExpiresAt: &expiresAt},
+		{ActiveHours: []ActiveHours{{From: 60, To: 60}}},
+	} {
+		assert.Error(t, s.Validate())
+	}
+
+	var hours ActiveHours
+	assert.Error(t, json.Unmarshal([]byte(`{"from": "24:00", "to": "01:00"}`), &hours))
+	assert.Error(t, json.Unmarshal([]byte(`{"days": ["MONDAY"], "from": "00:00", "to": "01:00"}`), &hours))
+}
+
+func TestPatchSchedule(t *testing.T) {
+	expiresAt := int64(1655164800)
+	schedule := Schedule{ExpiresAt: &expiresAt, ActiveHours: []ActiveHours{{From: 0, To: 60}}}
+
+	patched, rest, err := patchSchedule(schedule, []byte(`{"expires_at": null, "active_from": 1654560000, "limit": 1}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `{"limit": 1}`, string(rest))
+	assert.Nil(t, patched.ExpiresAt)
+	if assert.NotNil(t, patched.ActiveFrom) {
+		assert.Equal(t, int64(1654560000), *patched.ActiveFrom)
+	}
+	assert.Len(t, patched.ActiveHours, 1)
+	assert.Equal(t, int64(1655164800), *schedule.ExpiresAt, "original schedule is untouched")
+
+	patched, rest, err = patchSchedule(schedule, []byte(`{"limit": 1}`))
+	assert.NoError(t, err)
+	assert.Nil(t, patched)
+	assert.JSONEq(t, `{"limit": 1}`, string(rest))
+}
@@ -2,6 +2,7 @@ package portfolio
 
 import (
 	"context"
+	"sync"
 
 	"github.com/go-redis/redis/v9"
 	"github.com/pkg/errors"
@@ -9,17 +10,30 @@ import (
 )
 
 // dataHolder provides CRUD methods for Data stored via Redis.
-// It also holds fast-accessible data parts as totalBalance
+// It also holds fast-accessible data parts as balances and assets being an evaluation context for triggers.
+// Data is saved by portfolio's goroutine and by API on cache miss, so the parts are guarded by mu
 type dataHolder struct {
 	rdb           redis.UniversalClient
 	portfolioName string
-	totalBalance  ConvertedTo
+	mu            sync.RWMutex
+	balances      Balances // without details
+	assets        map[core.Currency]Asset
+	prices        map[core.Currency]ConvertedTo
+	saved         bool // Data is saved by this process, i.e. Data in Redis isn't left from the last run
+}
+
+// Asset is a raw balance of single currency and its quantities and values by BalanceBasis
+type Asset struct {
+	Balance core.Balance
+	AssetBalance
 }
 
 func newDataHolder(portfolioName string, rdb redis.UniversalClient) *dataHolder {
 	return &dataHolder{
 		portfolioName: portfolioName,
-		totalBalance:  make(ConvertedTo),
+		balances:      Balances{Total: make(ConvertedTo), Available: make(ConvertedTo), Locked: make(ConvertedTo)},
+		assets:        make(map[core.Currency]Asset),
+		prices:        make(map[core.Currency]ConvertedTo),
 		rdb:           rdb,
 	}
 }
@@ -32,8 +46,20 @@ func (s *dataHolder) Get(ctx context.Context) (*Data, error) {
 	return &data, nil
 }
 
-func (s *dataHolder) Save(ctx context.Context, data Data) error {
-	s.totalBalance = data.Balance.Total
+// Save data and raw balances data has been calculated of. Assets are replaced by ones of balances
+func (s *dataHolder) Save(ctx context.Context, data Data, balances map[core.Currency]core.Balance) error {
+	assets := make(map[core.Currency]Asset, len(balances))
+	for cur, bal := range balances {
+		assets[cur] = Asset{Balance: bal, AssetBalance: data.Balance.Details[cur]}
+	}
+	s.mu.Lock()
+	s.balances = data.Balance
+	s.balances.Details = nil
+	s.prices = data.Prices
+	s.assets = assets
+	s.saved = true
+	s.mu.Unlock()
+
 	err := s.rdb.Set(ctx, s.redisKey(), data, 0).Err()
 	return errors.Wrapf(err, "failed to save data for %s", s.portfolioName)
 }
@@ -43,8 +69,47 @@ func (s *dataHolder) Delete(ctx context.Context) error {
 	return errors.Wrapf(err, "failed to save data for %s", s.portfolioName)
 }
 
-func (s *dataHolder) TotalBalance(currency Currency) core.Amount {
-	return s.totalBalance[currency]
+// TotalBalance returns portfolio's value by balance basis in currency
+func (s *dataHolder) TotalBalance(basis BalanceBasis, currency Currency) core.Amount {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.balances.basis(basis)[currency]
+}
+
+// Balances returns portfolio's values by balance basis without details
+func (s *dataHolder) Balances() Balances {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.balances
+}
+
+// Asset returns zero Asset if currency isn't held
+func (s *dataHolder) Asset(currency core.Currency) Asset {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.assets[currency]
+}
+
+// Assets returns held assets. Map is replaced on every save, so it mustn't be modified
+func (s *dataHolder) Assets() map[core.Currency]Asset {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.assets
+}
+
+// Saved reports whether Data has been saved since process start
+func (s *dataHolder) Saved() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.saved
+}
+
+// Price returns price of currency converted to Currency types. Ok is false if price is unknown
+func (s *dataHolder) Price(currency core.Currency) (ConvertedTo, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	price, ok := s.prices[currency]
+	return price, ok
 }
 
 func (s *dataHolder) redisKey() string {
@@ -0,0 +1,55 @@
+package portfolio
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/test/mocks"
+)
+
+// TestDataHolder_Save saves Data concurrently with readers as API does on cache miss (run with -race)
+func TestDataHolder_Save(t *testing.T) {
+	rdbMock := mocks.NewRedisClient(t)
+	rdbMock.
+		On("Set", context.Background(), "portfolio:test", mock.Anything, time.Duration(0)).
+		Return(&redis.StatusCmd{})
+	holder := newDataHolder("test", rdbMock)
+
+	balances := map[core.Currency]core.Balance{"ETH": {Available: decimal.NewDecimal(1, 0)}}
+	data := Data{
+		Balance: Balances{
+			Total: ConvertedTo{USDT: decimal.NewDecimal(100, 0)},
+			Details: map[core.Currency]AssetBalance{
+				"ETH": newAssetBalance(balances["ETH"], ConvertedTo{USDT: decimal.NewDecimal(100, 0)}),
+			},
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, holder.Save(context.Background(), data, balances))
+		}()
+		go func() {
+			defer wg.Done()
+			for range holder.Assets() {
+			}
+			holder.TotalBalance(BasisTotal, USDT)
+			holder.Price("ETH")
+		}()
+	}
+	wg.Wait()
+
+	assert.True(t, decimal.NewDecimal(100, 0).Eq(holder.TotalBalance(BasisTotal, USDT)))
+	assert.True(t, decimal.NewDecimal(1, 0).Eq(holder.Asset("ETH").Balance.Available))
+}
@@ -0,0 +1,55 @@
+package portfolio
+
+import (
+	"time"
+
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+// totalsWindow is a rolling window of portfolio's total costs kept within retention period
+type totalsWindow struct {
+	retention time.Duration
+	samples   []totalsSample // ordered by time
+}
+
+type totalsSample struct {
+	at       time.Time
+	balances Balances
+}
+
+func newTotalsWindow(retention time.Duration) *totalsWindow {
+	return &totalsWindow{retention: retention}
+}
+
+// add total costs sample and drop samples older than retention period. Details of balances aren't kept
+func (w *totalsWindow) add(at time.Time, balances Balances) {
+	balances.Details = nil
+	w.samples = append(w.samples, totalsSample{at: at, balances: balances})
+
+	cutoff := at.Add(-w.retention)
+	i := 0
+	for i < len(w.samples) && w.samples[i].at.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		w.samples = append(w.samples[:0], w.samples[i:]...)
+	}
+}
+
+// extremes returns min and max total cost by balance basis in currency since certain time. Ok is false if there're no samples
+func (w *totalsWindow) extremes(since time.Time, basis BalanceBasis, currency Currency) (min, max decimal.Decimal, ok bool) {
+	for _, sample := range w.samples {
+		if sample.at.Before(since) {
+			continue
+		}
+		value := sample.balances.basis(basis)[currency]
+		if !ok || value.LessThan(min) {
+			min = value
+		}
+		if !ok || max.LessThan(value) {
+			max = value
+		}
+		ok = true
+	}
+	return
+}
@@ -1,33 +1,375 @@
 package portfolio
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/pg/repo"
 )
 
 type Trigger interface {
 	ID() uuid.UUID
 	TryExecute() (*ExecutionStatus, error)
 	Settings() TriggerSettings
+	// Update returns copy of trigger with params patched by JSON object. Original trigger stays untouched
+	Update(patch json.RawMessage) (Trigger, error)
+	Paused() bool
+	SetPaused(paused bool)
+	base() *triggerBase
+}
+
+// statefulTrigger is implemented by triggers changing their own params on execution (ex. trailing alert).
+// State isn't changed by TryExecute: params of accepted execution (i.e. one not suppressed by Rearm) are persisted
+// in transaction of its TriggerEvent and applied only after the transaction is committed
+type statefulTrigger interface {
+	Trigger
+	// next returns params of trigger after execution with status, nil if they aren't changed
+	next(status *ExecutionStatus) TriggerParams
+	// setParams applies persisted params returned by next
+	setParams(params TriggerParams)
+}
+
+// trackingTrigger is implemented by triggers tracking portfolio's values between evaluations (ex. running peak).
+// Tracking isn't suppressed by pause, Schedule or Rearm, so trigger's state isn't stale when it's evaluated again
+type trackingTrigger interface {
+	Trigger
+	// track updates in-memory state by the latest Data
+	track()
+}
+
+// repeatableTrigger is implemented by triggers which may stay after execution (ex. trailing alert).
+// Rearm settings are supported by such triggers only, the other ones are removed on the first execution
+type repeatableTrigger interface {
+	Trigger
+	// repeatable is true if ExecutionStatus.Done may be false for executed trigger
+	repeatable() bool
+}
+
+// supportsRearm checks whether Rearm settings of trigger have any effect
+func supportsRearm(t Trigger) bool {
+	r, ok := t.(repeatableTrigger)
+	return ok && r.repeatable()
 }
 
 // ExecutionStatus
 // Ok is true if trigger is executed
 // Done is true if trigger is supposed to be removed
 // CurrentValue is an executed value (ex. portfolio's total cost)
+// Details are optional type-specific details of execution reported in TriggerEvent
 type ExecutionStatus struct {
 	Ok           bool            `json:"ok"`
 	Done         bool            `json:"done"`
 	CurrentValue decimal.Decimal `json:"current_value"`
+	Details      interface{}     `json:"details,omitempty"`
 }
 
+// TriggerSettings are settings common for all trigger types + type-specific Params.
+// Params are inlined into JSON presentation of settings.
+// FiredAt and FiredValue are set while trigger is disarmed after execution (see Rearm)
 type TriggerSettings struct {
-	ID             uuid.UUID        `json:"id" format:"UUID" validate:"required" example:"e1c6c253-00cd-4562-ae5c-ce065f8530c6"`
-	Type           TriggerType      `json:"type" validate:"required" swaggertype:"string" enums:"COST_REACHED_LIMIT,COST_CHANGED_BY_PERCENT"`
-	CreatedAt      int64            `json:"created_at" validate:"required" format:"timestamp"`
-	Currency       Currency         `json:"currency" validate:"required" swaggertype:"string" enums:"USDT,BTC"`
-	Limit          *decimal.Decimal `json:"limit,omitempty"`
-	Percent        *decimal.Decimal `json:"percent,omitempty"`
-	StartTotalCost *decimal.Decimal `json:"start_total_cost,omitempty"`
-	TrailingAlert  bool             `json:"trailing_alert"`
+	Rearm
+	Schedule
+	QualityGate
+	ID         uuid.UUID        `json:"id" format:"UUID" validate:"required" example:"e1c6c253-00cd-4562-ae5c-ce065f8530c6"`
+	Type       TriggerType      `json:"type" validate:"required" swaggertype:"string"`
+	CreatedAt  int64            `json:"created_at" validate:"required" format:"timestamp" example:"1654586492"`
+	Currency   Currency         `json:"currency" validate:"required" swaggertype:"string"`
+	Basis      BalanceBasis     `json:"balance_basis,omitempty" swaggertype:"string" enums:"TOTAL,AVAILABLE,LOCKED"`
+	Paused     bool             `json:"paused"`
+	FiredAt    *int64           `json:"fired_at,omitempty" format:"timestamp" example:"1654586492"`
+	FiredValue *decimal.Decimal `json:"fired_value,omitempty" swaggertype:"number"`
+	Params     TriggerParams    `json:"-"`
+}
+
+func (s TriggerSettings) MarshalJSON() ([]byte, error) {
+	type settings TriggerSettings
+	data, err := json.Marshal(settings(s))
+	if err != nil {
+		return nil, err
+	}
+	return inlineParams(data, s.Params)
+}
+
+func (s *TriggerSettings) UnmarshalJSON(data []byte) error {
+	type settings TriggerSettings
+	if err := json.Unmarshal(data, (*settings)(s)); err != nil {
+		return err
+	}
+	kind, err := s.Type.kind()
+	if err != nil {
+		return err
+	}
+	s.Params, err = kind.Decode(data)
+	return errors.Wrapf(err, "failed to decode params of %q trigger type", s.Type)
+}
+
+// inlineParams merges JSON object of params into JSON object data: {...data, ...params}
+func inlineParams(data []byte, params TriggerParams) ([]byte, error) {
+	if params == nil {
+		return data, nil
+	}
+	paramsData, err := json.Marshal(params)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal %T", params)
+	}
+	if bytes.Equal(paramsData, []byte("{}")) {
+		return data, nil
+	}
+	data[len(data)-1] = ','
+	return append(data, paramsData[1:]...), nil
+}
+
+// Rearm settings suppress repeated executions of trigger that stays after execution:
+// executed trigger is disarmed until CooldownSecs pass or its value retreats by Hysteresis,
+// i.e. trigger's condition isn't met and its current value differs from executed one by Hysteresis at least.
+// Trigger without re-arm settings is always armed. One-shot triggers (i.e. removed on execution) don't support re-arm settings
+type Rearm struct {
+	// CooldownSecs is supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert)
+	CooldownSecs int64 `json:"cooldown_secs,omitempty" example:"900"`
+	// Hysteresis is supported only by triggers staying after execution (COST_CHANGED_BY_PERCENT with trailing_alert)
+	Hysteresis *decimal.Decimal `json:"hysteresis,omitempty" swaggertype:"number"`
+}
+
+func (r *Rearm) Validate() error {
+	if r.CooldownSecs < 0 {
+		return errors.New("cooldown_secs must be non-negative")
+	}
+	if r.Hysteresis != nil && !decimal.NewDecimal(0, 0).LessThan(*r.Hysteresis) {
+		return errors.New("hysteresis must be positive")
+	}
+	return nil
+}
+
+func (r *Rearm) enabled() bool {
+	return r.CooldownSecs > 0 || r.Hysteresis != nil
+}
+
+// TriggerState is a state common for all trigger types that is persisted in database.
+// FiredAt and FiredValue are set while trigger is disarmed
+type TriggerState struct {
+	ID          uuid.UUID
+	Currency    Currency
+	Basis       BalanceBasis
+	Paused      bool
+	CreatedAt   time.Time
+	Rearm       Rearm
+	Schedule    Schedule
+	QualityGate QualityGate
+	FiredAt     *time.Time
+	FiredValue  *decimal.Decimal
+}
+
+// rearm applies Rearm settings to execution status of trigger at certain time.
+// It returns false if execution must be suppressed since trigger is disarmed, otherwise state after execution
+func (s TriggerState) rearm(now time.Time, status *ExecutionStatus) (TriggerState, bool) {
+	if s.FiredAt != nil {
+		retreated := s.Rearm.Hysteresis != nil && s.FiredValue != nil && !status.Ok &&
+			!status.CurrentValue.Sub(*s.FiredValue).Abs().LessThan(*s.Rearm.Hysteresis)
+		if !s.cooledDown(now) && !retreated {
+			return s, false
+		}
+		s.FiredAt, s.FiredValue = nil, nil
+	}
+	if status.Ok && !status.Done && s.Rearm.enabled() {
+		firedValue := status.CurrentValue
+		s.FiredAt, s.FiredValue = &now, &firedValue
+	}
+	return s, true
+}
+
+// disarmed reports whether execution of trigger is suppressed regardless of its value: trigger hasn't cooled down
+// and can't be re-armed by Hysteresis. Such trigger isn't evaluated, so stateful trigger keeps its state
+func (s TriggerState) disarmed(now time.Time) bool {
+	return s.FiredAt != nil && !s.cooledDown(now) && (s.Rearm.Hysteresis == nil || s.FiredValue == nil)
+}
+
+func (s TriggerState) cooledDown(now time.Time) bool {
+	return s.Rearm.CooldownSecs > 0 && !now.Before(s.FiredAt.Add(time.Duration(s.Rearm.CooldownSecs)*time.Second))
+}
+
+func (s TriggerState) rearmParams() repo.PortfolioTriggers_UpdateRearmParams {
+	params := repo.PortfolioTriggers_UpdateRearmParams{
+		CooldownSecs: s.Rearm.CooldownSecs,
+		Hysteresis:   s.Rearm.Hysteresis,
+		FiredValue:   s.FiredValue,
+		ID:           s.ID,
+	}
+	if s.FiredAt != nil {
+		params.FiredAt = sql.NullTime{Time: *s.FiredAt, Valid: true}
+	}
+	return params
+}
+
+// triggerBase is embedded into triggers to share common state and methods
+type triggerBase struct {
+	portf *Portfolio
+	state TriggerState
+}
+
+func newTriggerBase(portf *Portfolio, currency Currency, basis BalanceBasis) triggerBase {
+	return triggerBase{
+		portf: portf,
+		state: TriggerState{
+			ID:        uuid.New(),
+			Currency:  currency,
+			Basis:     basis,
+			CreatedAt: time.Now().UTC(),
+		},
+	}
+}
+
+func (b *triggerBase) ID() uuid.UUID {
+	return b.state.ID
+}
+
+func (b *triggerBase) Paused() bool {
+	return b.state.Paused
+}
+
+func (b *triggerBase) SetPaused(paused bool) {
+	b.state.Paused = paused
+}
+
+func (b *triggerBase) settings(typ TriggerType, params TriggerParams) TriggerSettings {
+	settings := TriggerSettings{
+		Rearm:       b.state.Rearm,
+		Schedule:    b.state.Schedule,
+		QualityGate: b.state.QualityGate,
+		ID:          b.state.ID,
+		Type:        typ,
+		CreatedAt:   b.state.CreatedAt.Unix(),
+		Currency:    b.state.Currency,
+		Basis:       b.state.Basis,
+		Paused:      b.state.Paused,
+		FiredValue:  b.state.FiredValue,
+		Params:      params,
+	}
+	if b.state.FiredAt != nil {
+		firedAt := b.state.FiredAt.Unix()
+		settings.FiredAt = &firedAt
+	}
+	return settings
+}
+
+func (b *triggerBase) base() *triggerBase {
+	return b
+}
+
+// totalBalance returns portfolio's total cost in trigger's currency by its balance basis
+func (b *triggerBase) totalBalance() decimal.Decimal {
+	return b.portf.dataHolder.TotalBalance(b.state.Basis, b.state.Currency)
+}
+
+// saveParams persists params changed by trigger itself (ex. state of trailing trigger)
+func (b *triggerBase) saveParams(q repo.Querier, params TriggerParams) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %T", params)
+	}
+	err = q.PortfolioTriggers_Update(context.Background(), repo.PortfolioTriggers_UpdateParams{
+		Params: data,
+		ID:     b.state.ID,
+	})
+	return errors.Wrapf(err, "failed to update params of portfolio trigger %q", b.state.ID)
+}
+
+// patchRearm extracts Rearm settings from patch of trigger and overlays them onto copy of rearm.
+// It returns nil Rearm if patch doesn't contain them and the rest of patch
+func patchRearm(rearm Rearm, patch json.RawMessage) (*Rearm, json.RawMessage, error) {
+	rearmPatch, patch, err := splitPatch(patch, "cooldown_secs", "hysteresis")
+	if err != nil || rearmPatch == nil {
+		return nil, patch, err
+	}
+
+	if rearm.Hysteresis != nil {
+		hysteresis := *rearm.Hysteresis
+		rearm.Hysteresis = &hysteresis
+	}
+	if err := json.Unmarshal(rearmPatch, &rearm); err != nil {
+		return nil, nil, errors.Wrap(ErrInvalidTriggerUpdate, err.Error())
+	}
+	if err := rearm.Validate(); err != nil {
+		return nil, nil, errors.Wrap(ErrInvalidTriggerUpdate, err.Error())
+	}
+	return &rearm, patch, nil
+}
+
+// patchSchedule extracts Schedule settings from patch of trigger and overlays them onto copy of schedule.
+// Null removes setting. It returns nil Schedule if patch doesn't contain them and the rest of patch
+func patchSchedule(schedule Schedule, patch json.RawMessage) (*Schedule, json.RawMessage, error) {
+	schedulePatch, patch, err := splitPatch(patch, "active_from", "expires_at", "active_hours")
+	if err != nil || schedulePatch == nil {
+		return nil, patch, err
+	}
+
+	schedule = schedule.clone()
+	if err := json.Unmarshal(schedulePatch, &schedule); err != nil {
+		return nil, nil, errors.Wrap(ErrInvalidTriggerUpdate, err.Error())
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, nil, errors.Wrap(ErrInvalidTriggerUpdate, err.Error())
+	}
+	return &schedule, patch, nil
+}
+
+// splitPatch moves fields of keys from JSON object patch into separate JSON object.
+// It returns nil object if patch doesn't contain any of keys and the rest of patch
+func splitPatch(patch json.RawMessage, keys ...string) (json.RawMessage, json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		return nil, nil, errors.Wrap(ErrInvalidTriggerUpdate, err.Error())
+	}
+	extracted := make(map[string]json.RawMessage)
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			extracted[key] = value
+			delete(fields, key)
+		}
+	}
+	if len(extracted) == 0 {
+		return nil, patch, nil
+	}
+
+	data, err := json.Marshal(extracted)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	if patch, err = json.Marshal(fields); err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	return data, patch, nil
+}
+
+// patchTriggerParams returns copy of params of TriggerType overlaid by JSON object patch and validated.
+// Fields absent in patch are left as is, unknown fields are rejected
+func patchTriggerParams(typ TriggerType, params TriggerParams, patch json.RawMessage) (TriggerParams, error) {
+	kind, err := typ.kind()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal %T", params)
+	}
+	patched, err := kind.Decode(data) // deep copy
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode params of %q trigger type", typ)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(patch))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(patched); err != nil {
+		return nil, errors.Wrap(ErrInvalidTriggerUpdate, err.Error())
+	}
+	if err := patched.Validate(); err != nil {
+		return nil, ErrInvalidTriggerUpdate.Wrap(err)
+	}
+	return patched, nil
 }
@@ -0,0 +1,140 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/egsam98/portfolio/pg/repo"
+)
+
+// TriggerEventKind is a reason of TriggerEvent: trigger is executed or expired (see Schedule)
+type TriggerEventKind uint8
+
+const (
+	EventExecuted TriggerEventKind = iota + 1
+	EventExpired
+)
+
+var (
+	triggerEventKindKeyValues = map[TriggerEventKind]string{
+		EventExecuted: "EXECUTED",
+		EventExpired:  "EXPIRED",
+	}
+	triggerEventKindValueKeys = map[string]TriggerEventKind{
+		"EXECUTED": EventExecuted,
+		"EXPIRED":  EventExpired,
+	}
+)
+
+func (k TriggerEventKind) String() string {
+	return triggerEventKindKeyValues[k]
+}
+
+func (k TriggerEventKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *TriggerEventKind) UnmarshalText(text []byte) error {
+	txt := string(text)
+	if kind, ok := triggerEventKindValueKeys[txt]; ok {
+		*k = kind
+		return nil
+	}
+	return errors.Errorf("invalid trigger event kind: %s", txt)
+}
+
+// TriggerEventsFilter filters portfolio's TriggerEvent-s history. Nil fields are not filtered by
+type TriggerEventsFilter struct {
+	TriggerID *uuid.UUID
+	Type      *TriggerType
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+// TriggerEvents returns the latest executed or expired triggers' events saved into database according to filter
+func (p *Portfolio) TriggerEvents(ctx context.Context, filter TriggerEventsFilter) ([]TriggerEvent, error) {
+	var triggerType *string
+	if filter.Type != nil {
+		typ := filter.Type.String()
+		triggerType = &typ
+	}
+
+	rows, err := p.db.Queries.PortfolioTriggerEvents_Select(ctx, repo.PortfolioTriggerEvents_SelectParams{
+		PortfolioID: p.id,
+		TriggerID:   filter.TriggerID,
+		TriggerType: triggerType,
+		FromTime:    filter.From.UTC(),
+		ToTime:      filter.To.UTC(),
+		Limit:       int32(filter.Limit),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to select trigger events of portfolio %q", p.name)
+	}
+
+	events := make([]TriggerEvent, len(rows))
+	for i, row := range rows {
+		events[i] = TriggerEvent{
+			Portfolio:    p.name,
+			Group:        p.group,
+			Timestamp:    row.CreatedAt.Unix(),
+			CurrentValue: row.CurrentValue,
+			Done:         row.Done,
+			Details:      row.Details,
+		}
+		if err := events[i].Kind.UnmarshalText([]byte(row.Kind)); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(row.TriggerSettings, &events[i].TriggerSettings); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal %s into %T", string(row.TriggerSettings),
+				events[i].TriggerSettings)
+		}
+		if err := json.Unmarshal(row.Total, &events[i].Total); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal %s into %T", string(row.Total), events[i].Total)
+		}
+	}
+	return events, nil
+}
+
+// saveTriggerEvent saves TriggerEvent into history and outbox using q (possibly bound to transaction)
+func (p *Portfolio) saveTriggerEvent(ctx context.Context, q repo.Querier, event TriggerEvent) error {
+	settings, err := json.Marshal(event.TriggerSettings)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %T", event.TriggerSettings)
+	}
+	total, err := json.Marshal(event.Total)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %T", event.Total)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %T", event)
+	}
+
+	createdAt := time.Unix(event.Timestamp, 0).UTC()
+	if err := q.PortfolioTriggerEvents_Create(ctx, repo.PortfolioTriggerEvents_CreateParams{
+		PortfolioID:     p.id,
+		TriggerID:       event.TriggerSettings.ID,
+		TriggerType:     event.TriggerSettings.Type.String(),
+		TriggerSettings: settings,
+		Done:            event.Done,
+		CurrentValue:    event.CurrentValue,
+		Total:           total,
+		Details:         event.Details,
+		Kind:            event.Kind.String(),
+		CreatedAt:       createdAt,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to save event of trigger %q", event.TriggerSettings.ID)
+	}
+
+	err = q.PortfolioTriggerEventsOutbox_Create(ctx, repo.PortfolioTriggerEventsOutbox_CreateParams{
+		Payload:   payload,
+		CreatedAt: createdAt,
+	})
+	return errors.Wrapf(err, "failed to save event of trigger %q into outbox", event.TriggerSettings.ID)
+}
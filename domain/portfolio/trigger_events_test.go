@@ -0,0 +1,370 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gitlab.com/moderntoken/gateways/core"
+	"gitlab.com/moderntoken/gateways/decimal"
+
+	"github.com/egsam98/portfolio/pg"
+	"github.com/egsam98/portfolio/pg/repo"
+	"github.com/egsam98/portfolio/test/mocks"
+)
+
+func TestPortfolio_TriggerEvents(t *testing.T) {
+	ctx := context.Background()
+	qMock := mocks.NewQuerier(t)
+	db := &pg.DB{Queries: qMock}
+	portf := NewPortfolio(1, "test", db, nil, nil, nil, Config{})
+
+	limit := decimal.NewDecimal(100, 0)
+	settings := TriggerSettings{
+		ID:       uuid.New(),
+		Type:     CRL,
+		Currency: USDT,
+		Params:   &CostReachedLimitParams{LimitCondition{Limit: &limit}},
+	}
+	settingsJSON, _ := json.Marshal(settings)
+
+	triggerType := CRL
+	triggerTypeStr := CRL.String()
+	from := time.Unix(1654586400, 0)
+	to := from.Add(time.Hour)
+	qMock.
+		On("PortfolioTriggerEvents_Select", ctx, repo.PortfolioTriggerEvents_SelectParams{
+			PortfolioID: 1,
+			TriggerID:   &settings.ID,
+			TriggerType: &triggerTypeStr,
+			FromTime:    from.UTC(),
+			ToTime:      to.UTC(),
+			Limit:       10,
+		}).
+		Return([]repo.PortfolioTriggerEvent{
+			{
+				PortfolioID:     1,
+				TriggerID:       settings.ID,
+				TriggerType:     CRL.String(),
+				TriggerSettings: settingsJSON,
+				Done:            true,
+				CurrentValue:    limit,
+				Total:           []byte(`{"USDT":100}`),
+				Kind:            EventExecuted.String(),
+				CreatedAt:       from,
+			},
+		}, nil)
+
+	events, err := portf.TriggerEvents(ctx, TriggerEventsFilter{
+		TriggerID: &settings.ID,
+		Type:      &triggerType,
+		From:      from,
+		To:        to,
+		Limit:     10,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "test", events[0].Portfolio)
+		assert.Equal(t, from.Unix(), events[0].Timestamp)
+		assert.True(t, events[0].Done)
+		assert.Equal(t, EventExecuted, events[0].Kind)
+		assert.Equal(t, settings.ID, events[0].TriggerSettings.ID)
+		assert.Equal(t, CRL, events[0].TriggerSettings.Type)
+		if params, ok := events[0].TriggerSettings.Params.(*CostReachedLimitParams); assert.True(t, ok) {
+			assert.True(t, limit.Eq(*params.Limit))
+		}
+		assert.True(t, limit.Eq(events[0].Total[USDT]))
+	}
+}
+
+func TestPortfolio_handleBalanceUpdate(t *testing.T) {
+	ctx := context.Background()
+	qMock := mocks.NewQuerier(t)
+	db := &pg.DB{Queries: qMock}
+
+	rdbMock := mocks.NewRedisClient(t)
+	rdbMock.
+		On("Set", ctx, mock.Anything, mock.Anything, time.Duration(0)).
+		Return(&redis.StatusCmd{})
+
+	portf := NewPortfolio(1, "test", db, rdbMock, nil, nil, Config{})
+	trigger := NewCostReachedLimit(portf, USDT, decimal.Decimal{})
+	portf.addTriggers([]Trigger{trigger})
+
+	qMock.
+		On("PortfolioTriggerEvents_Create", ctx, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			params := args.Get(1).(repo.PortfolioTriggerEvents_CreateParams)
+			assert.Equal(t, trigger.ID(), params.TriggerID)
+			assert.Equal(t, CRL.String(), params.TriggerType)
+			assert.True(t, params.Done)
+		}).
+		Once()
+	qMock.
+		On("PortfolioTriggerEventsOutbox_Create", ctx, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			params := args.Get(1).(repo.PortfolioTriggerEventsOutbox_CreateParams)
+			var event TriggerEvent
+			if assert.NoError(t, json.Unmarshal(params.Payload, &event)) {
+				assert.Equal(t, trigger.ID(), event.TriggerSettings.ID)
+				assert.True(t, event.Done)
+			}
+		}).
+		Once()
+	qMock.
+		On("PortfolioTriggers_Delete", ctx, trigger.ID()).
+		Return(nil).
+		Once()
+
+	assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+	assert.Empty(t, portf.triggers)
+
+	t.Run("when transaction fails trigger is kept", func(t *testing.T) {
+		trigger := NewCostReachedLimit(portf, USDT, decimal.Decimal{})
+		portf.addTriggers([]Trigger{trigger})
+
+		qMock.
+			On("PortfolioTriggerEvents_Create", ctx, mock.Anything).
+			Return(errors.New("test")).
+			Once()
+
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+		assert.Contains(t, portf.triggers, trigger.ID().String())
+	})
+
+	t.Run("disarmed trigger is suppressed until cooldown passes", func(t *testing.T) {
+		portf := NewPortfolio(1, "test", db, rdbMock, nil, nil, Config{})
+		now := time.Unix(1654586492, 0)
+		portf.now = func() time.Time { return now }
+		trigger := &stubTrigger{
+			triggerBase: newTriggerBase(portf, USDT, BasisTotal),
+			status:      ExecutionStatus{Ok: true},
+		}
+		trigger.state.Rearm.CooldownSecs = 60
+		portf.addTriggers([]Trigger{trigger})
+
+		expectFired := func(firedAt time.Time) {
+			qMock.On("PortfolioTriggerEvents_Create", ctx, mock.Anything).Return(nil).Once()
+			qMock.On("PortfolioTriggerEventsOutbox_Create", ctx, mock.Anything).Return(nil).Once()
+			qMock.
+				On("PortfolioTriggers_UpdateRearm", ctx, mock.Anything).
+				Return(nil).
+				Run(func(args mock.Arguments) {
+					params := args.Get(1).(repo.PortfolioTriggers_UpdateRearmParams)
+					assert.Equal(t, trigger.ID(), params.ID)
+					assert.Equal(t, firedAt, params.FiredAt.Time)
+				}).
+				Once()
+		}
+
+		expectFired(now)
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+		if assert.NotNil(t, trigger.Settings().FiredAt) {
+			assert.Equal(t, now.Unix(), *trigger.Settings().FiredAt)
+		}
+
+		now = now.Add(30 * time.Second)
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+		assert.Equal(t, 1, trigger.executions, "disarmed trigger must not be evaluated")
+
+		now = now.Add(30 * time.Second)
+		expectFired(now)
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+		assert.Contains(t, portf.triggers, trigger.ID().String())
+	})
+
+	t.Run("disarmed trailing trigger keeps start total cost", func(t *testing.T) {
+		portf := NewPortfolio(1, "test", db, rdbMock, nil, nil, Config{})
+		startTotalCost := decimal.NewDecimal(100, 0)
+		trigger := &CostChangedByPercent{
+			triggerBase: newTriggerBase(portf, USDT, BasisTotal),
+			params: CostChangedByPercentParams{
+				Percent:        decimal.NewDecimal(5, 0),
+				TrailingAlert:  true,
+				StartTotalCost: &startTotalCost,
+			},
+		}
+		// Total cost drops to zero, i.e. it's still changed by 100% as on the last execution
+		firedAt, firedValue, hysteresis := time.Now(), decimal.NewDecimal(100, 0), decimal.NewDecimal(1, 0)
+		trigger.state.Rearm.Hysteresis = &hysteresis
+		trigger.state.FiredAt, trigger.state.FiredValue = &firedAt, &firedValue
+		portf.addTriggers([]Trigger{trigger})
+
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+		assert.True(t, startTotalCost.Eq(*trigger.params.StartTotalCost))
+	})
+
+	t.Run("trailing trigger moves start total cost together with event", func(t *testing.T) {
+		portf := NewPortfolio(1, "test", db, rdbMock, nil, nil, Config{})
+		startTotalCost := decimal.NewDecimal(100, 0)
+		trigger := &CostChangedByPercent{
+			triggerBase: newTriggerBase(portf, USDT, BasisTotal),
+			params: CostChangedByPercentParams{
+				Percent:        decimal.NewDecimal(5, 0),
+				TrailingAlert:  true,
+				StartTotalCost: &startTotalCost,
+			},
+		}
+		portf.addTriggers([]Trigger{trigger})
+
+		// Total cost drops to zero
+		qMock.
+			On("PortfolioTriggerEvents_Create", ctx, mock.Anything).
+			Return(errors.New("test")).
+			Once()
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+		assert.True(t, startTotalCost.Eq(*trigger.params.StartTotalCost), "start total cost is moved by failed transaction")
+
+		qMock.On("PortfolioTriggerEvents_Create", ctx, mock.Anything).Return(nil).Once()
+		qMock.On("PortfolioTriggerEventsOutbox_Create", ctx, mock.Anything).Return(nil).Once()
+		qMock.
+			On("PortfolioTriggers_Update", ctx, mock.Anything).
+			Return(nil).
+			Run(func(args mock.Arguments) {
+				params := args.Get(1).(repo.PortfolioTriggers_UpdateParams)
+				assert.Equal(t, trigger.ID(), params.ID)
+				assert.JSONEq(t, `{"percent":"5","trailing_alert":true,"start_total_cost":"0"}`, string(params.Params))
+			}).
+			Once()
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+		assert.True(t, trigger.params.StartTotalCost.IsZero())
+	})
+
+	t.Run("trailing condition keeps start total cost if composite trigger isn't executed", func(t *testing.T) {
+		portf := NewPortfolio(1, "test", db, rdbMock, nil, nil, Config{})
+		trigger, err := restoreTrigger(portf, COMPOSITE, TriggerState{ID: uuid.New(), Currency: USDT}, json.RawMessage(`{
+			"operator":"AND",
+			"conditions":[
+				{"type":"COST_CHANGED_BY_PERCENT","percent":"5","trailing_alert":true,"start_total_cost":"100"},
+				{"type":"COST_REACHED_LIMIT","limit":"5000"}
+			]
+		}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+		portf.addTriggers([]Trigger{trigger})
+
+		// Total cost drops to zero: trailing condition is matched, but composite trigger isn't executed
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+		params := trigger.Settings().Params.(*CompositeParams)
+		startTotalCost := params.Conditions[0].Params.(*CostChangedByPercentParams).StartTotalCost
+		assert.True(t, decimal.NewDecimal(100, 0).Eq(*startTotalCost), "start total cost is moved by non-executed composite trigger")
+	})
+
+	t.Run("trigger outside of active hours isn't executed and expired one is removed", func(t *testing.T) {
+		portf := NewPortfolio(1, "test", db, rdbMock, nil, nil, Config{})
+		now := time.Date(2022, 6, 7, 8, 0, 0, 0, time.UTC) // Tuesday
+		portf.now = func() time.Time { return now }
+		trigger := &stubTrigger{
+			triggerBase: newTriggerBase(portf, USDT, BasisTotal),
+			status:      ExecutionStatus{Ok: true},
+		}
+		expiresAt := now.Add(time.Hour).Unix()
+		trigger.state.Schedule = Schedule{
+			ExpiresAt:   &expiresAt,
+			ActiveHours: []ActiveHours{{From: 9 * 60, To: 17 * 60}},
+		}
+		portf.addTriggers([]Trigger{trigger})
+
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+		assert.Contains(t, portf.triggers, trigger.ID().String())
+
+		now = now.Add(time.Hour)
+		qMock.
+			On("PortfolioTriggerEvents_Create", ctx, mock.Anything).
+			Return(nil).
+			Run(func(args mock.Arguments) {
+				params := args.Get(1).(repo.PortfolioTriggerEvents_CreateParams)
+				assert.Equal(t, trigger.ID(), params.TriggerID)
+				assert.Equal(t, EventExpired.String(), params.Kind)
+				assert.True(t, params.Done)
+			}).
+			Once()
+		qMock.On("PortfolioTriggerEventsOutbox_Create", ctx, mock.Anything).Return(nil).Once()
+		qMock.On("PortfolioTriggers_Delete", ctx, trigger.ID()).Return(nil).Once()
+
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+		assert.Empty(t, portf.triggers)
+	})
+
+	t.Run("paused max drawdown trigger tracks peak", func(t *testing.T) {
+		gwMock := mocks.NewGateway(t)
+		gwMock.
+			On("AllSymbols").
+			Return([]core.Symbol{})
+		portf := NewPortfolio(1, "test", db, rdbMock, gwMock, nil, Config{})
+		trigger, err := restoreTrigger(portf, MDD, TriggerState{ID: uuid.New(), Currency: USDT, Paused: true},
+			json.RawMessage(`{"percent":"10","peak":"1000"}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+		portf.addTriggers([]Trigger{trigger})
+
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{"USDT": {Available: decimal.NewDecimal(2000, 0)}}))
+
+		trigger.SetPaused(false)
+		qMock.
+			On("PortfolioTriggers_Update", ctx, mock.Anything).
+			Return(nil).
+			Run(func(args mock.Arguments) {
+				params := args.Get(1).(repo.PortfolioTriggers_UpdateParams)
+				assert.Equal(t, trigger.ID(), params.ID)
+				assert.JSONEq(t, `{"percent":"10","peak":"2000"}`, string(params.Params))
+			}).
+			Once()
+		// Drawdown is measured from peak reached while trigger was paused
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{"USDT": {Available: decimal.NewDecimal(1900, 0)}}))
+		assert.Contains(t, portf.triggers, trigger.ID().String())
+	})
+
+	t.Run("trigger isn't executed while data is incomplete", func(t *testing.T) {
+		gwMock := mocks.NewGateway(t)
+		gwMock.
+			On("AllSymbols").
+			Return([]core.Symbol{})
+		portf := NewPortfolio(1, "test", db, rdbMock, gwMock, nil, Config{})
+		trigger := &stubTrigger{
+			triggerBase: newTriggerBase(portf, USDT, BasisTotal),
+			status:      ExecutionStatus{Ok: true},
+		}
+		minCompleteness := decimal.NewDecimal(50, 0)
+		trigger.state.QualityGate.MinCompleteness = &minCompleteness
+		portf.addTriggers([]Trigger{trigger})
+
+		// ETH isn't priced
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{"ETH": {Available: decimal.NewDecimal(1, 0)}}))
+
+		qMock.On("PortfolioTriggerEvents_Create", ctx, mock.Anything).Return(nil).Once()
+		qMock.On("PortfolioTriggerEventsOutbox_Create", ctx, mock.Anything).Return(nil).Once()
+		assert.NoError(t, portf.handleBalanceUpdate(map[core.Currency]core.Balance{}))
+	})
+}
+
+// stubTrigger is a trigger returning predefined ExecutionStatus
+type stubTrigger struct {
+	triggerBase
+	status     ExecutionStatus
+	executions int
+}
+
+func (s *stubTrigger) TryExecute() (*ExecutionStatus, error) {
+	s.executions++
+	status := s.status
+	return &status, nil
+}
+
+func (s *stubTrigger) Settings() TriggerSettings {
+	return s.settings(CRL, &CostReachedLimitParams{})
+}
+
+func (s *stubTrigger) Update(json.RawMessage) (Trigger, error) {
+	return s, nil
+}
@@ -0,0 +1,91 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+func TestTriggerState_rearm(t *testing.T) {
+	firedAt := time.Unix(1654586492, 0)
+	firedValue := decimal.NewDecimal(5, 0)
+	hysteresis := decimal.NewDecimal(2, 0)
+
+	for _, tt := range []struct {
+		name     string
+		rearm    Rearm
+		disarmed bool
+		elapsed  time.Duration
+		status   ExecutionStatus
+		armed    bool
+		fired    bool
+	}{
+		{name: "when no re-arm settings", status: ExecutionStatus{Ok: true}, armed: true},
+		{name: "when fired", rearm: Rearm{CooldownSecs: 60}, status: ExecutionStatus{Ok: true}, armed: true, fired: true},
+		{name: "when done", rearm: Rearm{CooldownSecs: 60}, status: ExecutionStatus{Ok: true, Done: true}, armed: true},
+		{
+			name:     "when cooldown isn't passed",
+			rearm:    Rearm{CooldownSecs: 60},
+			disarmed: true,
+			elapsed:  59 * time.Second,
+			status:   ExecutionStatus{Ok: true},
+		},
+		{
+			name:     "when cooldown is passed",
+			rearm:    Rearm{CooldownSecs: 60},
+			disarmed: true,
+			elapsed:  time.Minute,
+			status:   ExecutionStatus{Ok: true},
+			armed:    true,
+			fired:    true,
+		},
+		{
+			name:     "when value hasn't retreated by hysteresis",
+			rearm:    Rearm{Hysteresis: &hysteresis},
+			disarmed: true,
+			elapsed:  time.Hour,
+			status:   ExecutionStatus{CurrentValue: decimal.NewDecimal(4, 0)},
+		},
+		{
+			name:     "when condition is still met",
+			rearm:    Rearm{Hysteresis: &hysteresis},
+			disarmed: true,
+			status:   ExecutionStatus{Ok: true, CurrentValue: decimal.NewDecimal(10, 0)},
+		},
+		{
+			name:     "when value has retreated by hysteresis",
+			rearm:    Rearm{Hysteresis: &hysteresis},
+			disarmed: true,
+			status:   ExecutionStatus{CurrentValue: decimal.NewDecimal(3, 0)},
+			armed:    true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			state := TriggerState{Rearm: tt.rearm}
+			if tt.disarmed {
+				state.FiredAt, state.FiredValue = &firedAt, &firedValue
+			}
+			now := firedAt.Add(tt.elapsed)
+
+			disarmed := state.disarmed(now)
+			state, armed := state.rearm(now, &tt.status)
+			assert.Equal(t, tt.armed, armed)
+			// Trigger re-armed by hysteresis only is evaluated
+			assert.Equal(t, !armed && tt.rearm.Hysteresis == nil, disarmed)
+			if !armed {
+				return
+			}
+			if tt.fired {
+				if assert.NotNil(t, state.FiredAt) {
+					assert.Equal(t, now, *state.FiredAt)
+				}
+				assert.NotNil(t, state.FiredValue)
+			} else {
+				assert.Nil(t, state.FiredAt)
+				assert.Nil(t, state.FiredValue)
+			}
+		})
+	}
+}
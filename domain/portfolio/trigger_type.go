@@ -1,29 +1,179 @@
 package portfolio
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/pkg/errors"
 )
 
+// TriggerType is a type of trigger registered with RegisterTriggerType
 type TriggerType uint8
 
-const (
-	CRL TriggerType = iota + 1
-	CCBP
-)
+// TriggerParams are type-specific trigger params decoded from JSON (REST request or database)
+type TriggerParams interface {
+	// Validate checks params of trigger to be created or updated
+	Validate() error
+}
+
+// TriggerKind plugs trigger type into registry. Validator of trigger type is TriggerParams.Validate of decoded params
+type TriggerKind struct {
+	// Name is a presentation of TriggerType in API and database
+	Name string
+	// Decode decodes type-specific params from JSON object. Unknown fields must be ignored
+	Decode func(data []byte) (TriggerParams, error)
+	// New creates trigger with validated params evaluating portfolio's values in currency by balance basis
+	New func(portf *Portfolio, currency Currency, basis BalanceBasis, params TriggerParams) (Trigger, error)
+	// Restore creates trigger from state and params persisted in database
+	Restore func(portf *Portfolio, state TriggerState, params TriggerParams) (Trigger, error)
+	// Gateway is true if trigger evaluates instruments of portfolio's gateway. Such triggers aren't supported by Group-s
+	Gateway bool
+}
 
 var (
-	triggerTypeKeyValues = map[TriggerType]string{
-		CRL:  "COST_REACHED_LIMIT",
-		CCBP: "COST_CHANGED_BY_PERCENT",
+	triggerKinds         = make(map[TriggerType]TriggerKind)
+	triggerTypeValueKeys = make(map[string]TriggerType)
+)
+
+// RegisterTriggerType adds TriggerKind into registry and returns its new TriggerType.
+// It's supposed to be called on package initialization, panics if kind is already registered
+func RegisterTriggerType(kind TriggerKind) TriggerType {
+	if kind.Name == "" || kind.Decode == nil || kind.New == nil || kind.Restore == nil {
+		panic(fmt.Sprintf("incomplete trigger kind: %+v", kind))
 	}
-	triggerTypeValueKeys = map[string]TriggerType{
-		"COST_REACHED_LIMIT":      CRL,
-		"COST_CHANGED_BY_PERCENT": CCBP,
+	if _, ok := triggerTypeValueKeys[kind.Name]; ok {
+		panic(fmt.Sprintf("trigger type %q is already registered", kind.Name))
 	}
-)
+
+	typ := TriggerType(len(triggerKinds) + 1)
+	triggerKinds[typ] = kind
+	triggerTypeValueKeys[kind.Name] = typ
+	return typ
+}
+
+// DecodeTriggerParams decodes and validates params of TriggerType from JSON object
+func DecodeTriggerParams(typ TriggerType, data []byte) (TriggerParams, error) {
+	kind, err := typ.kind()
+	if err != nil {
+		return nil, err
+	}
+	params, err := kind.Decode(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode params of %q trigger type", typ)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid params of %q trigger type", typ)
+	}
+	return params, nil
+}
+
+// NewTrigger creates trigger of TriggerType with params, Rearm, Schedule settings and balance basis (TOTAL by default) decoded from JSON object.
+// Currency must be one of portfolio's currencies. Rearm settings are rejected for one-shot triggers
+func NewTrigger(portf *Portfolio, typ TriggerType, currency Currency, data []byte) (Trigger, error) {
+	if !containsCurrency(portf.currencies, currency) {
+		return nil, errors.Wrap(ErrUnsupportedCurrency, currency.String())
+	}
+	params, err := DecodeTriggerParams(typ, data)
+	if err != nil {
+		return nil, err
+	}
+	var rearm Rearm
+	if err := json.Unmarshal(data, &rearm); err != nil {
+		return nil, errors.Wrap(err, "failed to decode re-arm settings")
+	}
+	if err := rearm.Validate(); err != nil {
+		return nil, err
+	}
+	var schedule Schedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, errors.Wrap(err, "failed to decode schedule settings")
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+	var gate QualityGate
+	if err := json.Unmarshal(data, &gate); err != nil {
+		return nil, errors.Wrap(err, "failed to decode quality gate settings")
+	}
+	if err := gate.Validate(); err != nil {
+		return nil, err
+	}
+	var basis struct {
+		Basis BalanceBasis `json:"balance_basis"`
+	}
+	if err := json.Unmarshal(data, &basis); err != nil {
+		return nil, errors.Wrap(err, "failed to decode balance basis")
+	}
+	if basis.Basis == 0 {
+		basis.Basis = BasisTotal
+	}
+
+	trigger, err := triggerKinds[typ].new(portf, currency, basis.Basis, params)
+	if err != nil {
+		return nil, err
+	}
+	if rearm.enabled() && !supportsRearm(trigger) {
+		return nil, errors.Wrap(ErrUnsupportedRearm, typ.String())
+	}
+	trigger.base().state.Rearm = rearm
+	trigger.base().state.Schedule = schedule
+	trigger.base().state.QualityGate = gate
+	return trigger, nil
+}
+
+// restoreTrigger creates trigger from state and params persisted in database
+func restoreTrigger(portf *Portfolio, typ TriggerType, state TriggerState, data json.RawMessage) (Trigger, error) {
+	kind, err := typ.kind()
+	if err != nil {
+		return nil, err
+	}
+	params, err := kind.Decode(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode params of %q trigger type", typ)
+	}
+	return kind.restore(portf, state, params)
+}
+
+// new creates trigger if it's supported by portfolio
+func (k TriggerKind) new(portf *Portfolio, currency Currency, basis BalanceBasis, params TriggerParams) (Trigger, error) {
+	if k.Gateway && portf.gw == nil {
+		return nil, errors.Wrap(ErrUnsupportedTrigger, k.Name)
+	}
+	return k.New(portf, currency, basis, params)
+}
+
+// restore restores trigger if it's supported by portfolio
+func (k TriggerKind) restore(portf *Portfolio, state TriggerState, params TriggerParams) (Trigger, error) {
+	if k.Gateway && portf.gw == nil {
+		return nil, errors.Wrap(ErrUnsupportedTrigger, k.Name)
+	}
+	return k.Restore(portf, state, params)
+}
+
+// TriggerTypes returns registered trigger types in order of registration
+func TriggerTypes() []TriggerType {
+	types := make([]TriggerType, len(triggerKinds))
+	for i := range types {
+		types[i] = TriggerType(i + 1)
+	}
+	return types
+}
+
+// Gateway reports whether trigger type evaluates instruments of portfolio's gateway (see TriggerKind.Gateway)
+func (t TriggerType) Gateway() bool {
+	return triggerKinds[t].Gateway
+}
+
+func (t TriggerType) kind() (TriggerKind, error) {
+	kind, ok := triggerKinds[t]
+	if !ok {
+		return TriggerKind{}, errors.Errorf("unknown trigger type: %d", t)
+	}
+	return kind, nil
+}
 
 func (t TriggerType) String() string {
-	return triggerTypeKeyValues[t]
+	return triggerKinds[t].Name
 }
 
 func (t TriggerType) MarshalText() ([]byte, error) {
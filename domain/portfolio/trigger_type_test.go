@@ -0,0 +1,120 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/moderntoken/gateways/decimal"
+)
+
+func TestNewTrigger(t *testing.T) {
+	portf := NewPortfolio(0, "", nil, nil, nil, nil, Config{})
+
+	trigger, err := NewTrigger(portf, CRL, USDT, []byte(`{"type":"COST_REACHED_LIMIT","currency":"USDT","limit":"100"}`))
+	assert.NoError(t, err)
+	if crl, ok := trigger.(*CostReachedLimit); assert.True(t, ok) {
+		assert.True(t, decimal.NewDecimal(100, 0).Eq(*crl.params.Limit))
+	}
+
+	t.Run("when invalid params", func(t *testing.T) {
+		_, err := NewTrigger(portf, CCBP, USDT, []byte(`{"limit":"100"}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("when unknown type", func(t *testing.T) {
+		_, err := NewTrigger(portf, TriggerType(0), USDT, []byte(`{}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("when currency isn't configured", func(t *testing.T) {
+		_, err := NewTrigger(portf, CRL, "ETH", []byte(`{"limit":"100"}`))
+		assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+
+		portf := NewPortfolio(0, "eth", nil, nil, nil, nil, Config{
+			PortfolioCurrencies: map[string][]Currency{"eth": {"ETH"}},
+		})
+		_, err = NewTrigger(portf, CRL, "ETH", []byte(`{"limit":"100"}`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("when re-arm settings aren't supported", func(t *testing.T) {
+		_, err := NewTrigger(portf, CRL, USDT, []byte(`{"limit":"100","cooldown_secs":60}`))
+		assert.ErrorIs(t, err, ErrUnsupportedRearm)
+
+		_, err = NewTrigger(portf, CCBP, USDT, []byte(`{"percent":"5","hysteresis":"1"}`))
+		assert.ErrorIs(t, err, ErrUnsupportedRearm)
+
+		trigger, err := NewTrigger(portf, CCBP, USDT, []byte(`{"percent":"5","trailing_alert":true,"cooldown_secs":60}`))
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(60), trigger.Settings().CooldownSecs)
+		}
+	})
+
+	t.Run("when type isn't supported by group", func(t *testing.T) {
+		group := NewGroup(0, "", nil, nil, Config{})
+		_, err := NewTrigger(group.Portfolio, PRL, USDT, []byte(`{"symbol":"ETHUSDT","limit":"100"}`))
+		assert.ErrorIs(t, err, ErrUnsupportedTrigger)
+
+		_, err = NewTrigger(group.Portfolio, COMPOSITE, USDT, []byte(`{"operator":"AND","conditions":[
+			{"type":"COST_REACHED_LIMIT","limit":"100"},
+			{"type":"PRICE_REACHED_LIMIT","symbol":"ETHUSDT","limit":"100"}
+		]}`))
+		assert.ErrorIs(t, err, ErrUnsupportedTrigger)
+
+		_, err = restoreTrigger(group.Portfolio, PRL, TriggerState{ID: uuid.New(), Currency: USDT},
+			json.RawMessage(`{"symbol":"ETHUSDT","limit":"100"}`))
+		assert.ErrorIs(t, err, ErrUnsupportedTrigger)
+
+		_, err = NewTrigger(group.Portfolio, CRL, USDT, []byte(`{"limit":"100"}`))
+		assert.NoError(t, err)
+		assert.Nil(t, group.graph, "group has no conversion graph")
+	})
+}
+
+func TestTriggerTypes(t *testing.T) {
+	types := TriggerTypes()
+	assert.Len(t, types, len(triggerKinds))
+	assert.Contains(t, types, CRL)
+	assert.Contains(t, types, PRL)
+	assert.True(t, PRL.Gateway())
+	assert.False(t, CRL.Gateway())
+}
+
+func TestTriggerSettings_JSON(t *testing.T) {
+	startTotalCost := decimal.NewDecimal(1000, 0)
+	settings := TriggerSettings{
+		ID:        uuid.New(),
+		Type:      CCBP,
+		CreatedAt: 1654586492,
+		Currency:  BTC,
+		Params: &CostChangedByPercentParams{
+			Percent:        decimal.NewDecimal(5, 0),
+			TrailingAlert:  true,
+			StartTotalCost: &startTotalCost,
+		},
+	}
+
+	data, err := json.Marshal(settings)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, "COST_CHANGED_BY_PERCENT", fields["type"])
+	assert.Equal(t, true, fields["trailing_alert"])
+	assert.Contains(t, fields, "percent")
+	assert.Contains(t, fields, "start_total_cost")
+
+	var unmarshaled TriggerSettings
+	assert.NoError(t, json.Unmarshal(data, &unmarshaled))
+	assert.Equal(t, settings.ID, unmarshaled.ID)
+	assert.Equal(t, settings.Type, unmarshaled.Type)
+	if params, ok := unmarshaled.Params.(*CostChangedByPercentParams); assert.True(t, ok) {
+		assert.True(t, params.Percent.Eq(decimal.NewDecimal(5, 0)))
+		assert.True(t, params.TrailingAlert)
+		assert.True(t, params.StartTotalCost.Eq(startTotalCost))
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/egsam98/portfolio/api/rest"
 	_ "github.com/egsam98/portfolio/api/rest/docs"
 	"github.com/egsam98/portfolio/config"
+	"github.com/egsam98/portfolio/domain/fx"
 	"github.com/egsam98/portfolio/domain/gateways"
 	"github.com/egsam98/portfolio/domain/portfolio"
 	"github.com/egsam98/portfolio/pg"
@@ -24,6 +25,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/rs/zerolog/pkgerrors"
+	"gitlab.com/moderntoken/gateways/core"
 )
 
 // TODO:
@@ -126,8 +128,6 @@ func run() error {
 	defer gwsMngr.Stop()
 	gwsMngr.Start(ctx)
 
-	triggerEventPublisher := mq.TriggerEventPublisher(rabbitPool)
-
 	// Redis
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     cfg.Redis.Host,
@@ -145,7 +145,55 @@ func run() error {
 		}
 	}()
 
-	pm := portfolio.NewManager(db, rdb, gwsMngr, triggerEventPublisher)
+	portfCfg := portfolio.Config{
+		PriceDebounce:       time.Second * time.Duration(cfg.Portfolio.PriceDebounceSecs),
+		PriceMaxAge:         time.Second * time.Duration(cfg.Portfolio.PriceMaxAgeSecs),
+		HistoryEnabled:      cfg.Portfolio.History.Enabled,
+		HistoryInterval:     time.Second * time.Duration(cfg.Portfolio.History.IntervalSecs),
+		PortfolioCurrencies: make(map[string][]portfolio.Currency),
+		PortfolioPricing:    make(map[string]portfolio.PricingPolicy),
+	}
+	if portfCfg.TakerFees, err = takerFees(cfg, gwsMngr); err != nil {
+		return err
+	}
+	if portfCfg.Normalization, err = parseNormalization(cfg); err != nil {
+		return err
+	}
+	if portfCfg.Currencies, err = parseCurrencies(cfg.Portfolio.Currencies); err != nil {
+		return err
+	}
+	if portfCfg.Pricing, err = parsePricing(cfg.Portfolio.Pricing); err != nil {
+		return err
+	}
+	for _, portf := range cfg.Portfolio.Portfolios {
+		if portfCfg.PortfolioCurrencies[portf.Name], err = parseCurrencies(portf.Currencies); err != nil {
+			return errors.Wrapf(err, "portfolio %q", portf.Name)
+		}
+		if portfCfg.PortfolioPricing[portf.Name], err = parsePricing(portf.Pricing); err != nil {
+			return errors.Wrapf(err, "portfolio %q", portf.Name)
+		}
+	}
+	if portfCfg.Fiat, err = parseCurrencies(cfg.Portfolio.FX.Fiat); err != nil {
+		return err
+	}
+	var fxProvider fx.Provider
+	switch {
+	case cfg.Portfolio.FX.URL != "":
+		fxProvider = fx.NewHTTPProvider(cfg.Portfolio.FX.URL)
+	case cfg.Portfolio.FX.File != "":
+		fxProvider = fx.NewFileProvider(cfg.Portfolio.FX.File)
+	}
+	if fxProvider != nil {
+		fxPoller := fx.NewPoller(
+			fxProvider,
+			time.Second*time.Duration(cfg.Portfolio.FX.IntervalSecs),
+			time.Second*time.Duration(cfg.Portfolio.FX.MaxAgeSecs),
+		)
+		fxPoller.Start(ctx)
+		portfCfg.FX = fxPoller
+	}
+
+	pm := portfolio.NewManager(db, rdb, gwsMngr, portfCfg)
 	if err := pm.Start(ctx); err != nil {
 		return err
 	}
@@ -156,8 +204,9 @@ func run() error {
 		rabbitPool.Close()
 	}()
 
-	// MQ consumers
+	// MQ consumers and trigger events relay
 	mq.NewEventConsumer(cfg.ServerName, rabbitPool, pm).Start(ctx)
+	portfolio.NewOutboxRelay(db, mq.TriggerEventPublisher(rabbitPool), portfolio.DefaultOutboxInterval).Start(ctx)
 
 	// Health server
 	httpErrs := make(chan error)
@@ -171,9 +220,13 @@ func run() error {
 		}
 	}()
 
+	handler, err := rest.InitHandler(secret, pm)
+	if err != nil {
+		return err
+	}
 	srv := &http.Server{
 		Addr:    fmt.Sprintf("0.0.0.0:%d", RESTPort),
-		Handler: rest.InitHandler(secret, pm),
+		Handler: handler,
 	}
 	go func() {
 		log.Info().Msgf("Starting HTTP server on port %d...", RESTPort)
@@ -215,3 +268,62 @@ func run() error {
 
 	return nil
 }
+
+// parseCurrencies validates currencies from config
+func parseCurrencies(txts []string) ([]portfolio.Currency, error) {
+	currencies := make([]portfolio.Currency, len(txts))
+	for i, txt := range txts {
+		if err := currencies[i].Set(txt); err != nil {
+			return nil, errors.Wrap(err, "invalid config")
+		}
+	}
+	return currencies, nil
+}
+
+// parsePricing parses pricing policy, zero policy is returned if txt is empty
+func parsePricing(txt string) (portfolio.PricingPolicy, error) {
+	var pricing portfolio.PricingPolicy
+	if txt == "" {
+		return pricing, nil
+	}
+	if err := pricing.UnmarshalText([]byte(txt)); err != nil {
+		return pricing, errors.Wrap(err, "invalid config")
+	}
+	return pricing, nil
+}
+
+// parseNormalization validates currency normalization of gateways from config
+func parseNormalization(cfg *config.Config) (map[string]portfolio.CurrencyNormalization, error) {
+	norms := make(map[string]portfolio.CurrencyNormalization, len(cfg.Portfolio.Normalization))
+	for _, n := range cfg.Portfolio.Normalization {
+		if n.Gateway == "" {
+			return nil, errors.New("invalid config: gateway of currency normalization is required")
+		}
+		norm := portfolio.CurrencyNormalization{
+			Aliases:  make(map[core.Currency]core.Currency),
+			Prefixes: n.Prefixes,
+		}
+		for _, alias := range n.Aliases {
+			if alias.To == "" {
+				return nil, errors.Errorf("invalid config: canonical currency of %v aliases of gateway %q is required", alias.From, n.Gateway)
+			}
+			for _, from := range alias.From {
+				norm.Aliases[core.Currency(from)] = core.Currency(alias.To)
+			}
+		}
+		norms[n.Gateway] = norm
+	}
+	return norms, nil
+}
+
+// takerFees maps configured taker fees to gateway names. Fees of unknown gateways are rejected
+func takerFees(cfg *config.Config, gwsMngr gateways.Manager) (map[string]float64, error) {
+	fees := make(map[string]float64, len(cfg.Fees))
+	for name, fee := range cfg.Fees {
+		if _, ok := gwsMngr.Gateway(name); !ok {
+			return nil, errors.Errorf("invalid config: fees of unknown gateway %q", name)
+		}
+		fees[name] = fee.Taker
+	}
+	return fees, nil
+}
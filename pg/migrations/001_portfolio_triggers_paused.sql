@@ -0,0 +1,2 @@
+-- Triggers can be paused and resumed
+alter table portfolio_triggers add column paused bool not null default false;
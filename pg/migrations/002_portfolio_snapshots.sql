@@ -0,0 +1,9 @@
+-- Snapshots of portfolio values for history range queries
+create table portfolio_snapshots (
+    portfolio_id bigint not null,
+    created_at timestamp not null default now(),
+    total jsonb not null,
+    details jsonb not null
+);
+
+create index portfolio_snapshots_portfolio_id_created_at_idx on portfolio_snapshots (portfolio_id, created_at);
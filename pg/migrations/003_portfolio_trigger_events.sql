@@ -0,0 +1,14 @@
+-- History of executed triggers
+create table portfolio_trigger_events (
+    id bigserial primary key,
+    portfolio_id bigint not null,
+    trigger_id uuid not null,
+    trigger_type text not null,
+    trigger_settings jsonb not null,
+    done bool not null,
+    current_value numeric not null,
+    total jsonb not null,
+    created_at timestamp not null default now()
+);
+
+create index portfolio_trigger_events_portfolio_id_created_at_idx on portfolio_trigger_events (portfolio_id, created_at);
@@ -0,0 +1,11 @@
+-- Outbox of trigger events relayed to RabbitMQ
+create table portfolio_trigger_events_outbox (
+    id bigserial primary key,
+    payload jsonb not null,
+    attempts int not null default 0,
+    last_error text,
+    created_at timestamp not null default now(),
+    sent_at timestamp
+);
+
+create index portfolio_trigger_events_outbox_unsent_idx on portfolio_trigger_events_outbox (id) where sent_at is null;
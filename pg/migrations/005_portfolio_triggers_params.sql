@@ -0,0 +1,25 @@
+-- Moves type-specific columns of portfolio_triggers into params (see schema.sql).
+-- Decimals are kept as JSON strings the way they're marshalled by application
+begin;
+
+alter table portfolio_triggers add column params jsonb not null default '{}';
+
+update portfolio_triggers
+set params = jsonb_build_object('limit', "limit"::text)
+where type = 'COST_REACHED_LIMIT';
+
+update portfolio_triggers
+set params = jsonb_strip_nulls(jsonb_build_object(
+    'percent', percent::text,
+    'trailing_alert', trailing_alert,
+    'start_total_cost', start_total_cost::text
+))
+where type = 'COST_CHANGED_BY_PERCENT';
+
+alter table portfolio_triggers
+    drop column "limit",
+    drop column percent,
+    drop column trailing_alert,
+    drop column start_total_cost;
+
+commit;
@@ -0,0 +1,2 @@
+-- Type-specific details of trigger execution (ex. offending weights of allocation drift)
+alter table portfolio_trigger_events add column details jsonb;
@@ -0,0 +1,6 @@
+-- Re-arm settings and state of triggers
+alter table portfolio_triggers
+    add column cooldown_secs bigint not null default 0,
+    add column hysteresis numeric,
+    add column fired_at timestamp,
+    add column fired_value numeric;
@@ -0,0 +1,7 @@
+-- Activation windows and expiry of triggers reported by EXPIRED events
+alter table portfolio_triggers
+    add column active_from timestamp,
+    add column expires_at timestamp,
+    add column active_hours jsonb;
+
+alter table portfolio_trigger_events add column kind text not null default 'EXECUTED';
@@ -0,0 +1,2 @@
+-- Balance basis (TOTAL, AVAILABLE or LOCKED) triggers are evaluated by
+alter table portfolio_triggers add column balance_basis text not null default 'TOTAL';
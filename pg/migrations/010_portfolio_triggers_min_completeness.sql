@@ -0,0 +1,2 @@
+-- Quality gate of triggers
+alter table portfolio_triggers add column min_completeness numeric;
@@ -0,0 +1,5 @@
+-- Groups consolidate portfolios of accounts sharing alias. IDs are shared with accounts
+create table portfolio_groups (
+    id bigint primary key default nextval('accounts_id_seq'),
+    name text not null unique
+);
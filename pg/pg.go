@@ -52,6 +52,18 @@ func (c *Config) URL() *url.URL {
 	}
 }
 
+// Tx executes fn within database transaction providing repo.Querier bound to it.
+// Transaction is committed if fn returns nil error, otherwise it's rolled back.
+// DB without connection pool (i.e. with mocked Queries) executes fn with DB.Queries
+func (db *DB) Tx(ctx context.Context, fn func(q repo.Querier) error) error {
+	if db.Pool == nil {
+		return fn(db.Queries)
+	}
+	return db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		return fn(repo.New(tx))
+	})
+}
+
 func NewDB(cfg *Config) (*DB, error) {
 	dsn := cfg.URL().String()
 	poolCfg, err := pgxpool.ParseConfig(dsn)
@@ -33,10 +33,14 @@ func (r iteratorForPortfolioTriggers_Create) Values() ([]interface{}, error) {
 		r.rows[0].PortfolioID,
 		r.rows[0].Type,
 		r.rows[0].Currency,
-		r.rows[0].Limit,
-		r.rows[0].Percent,
-		r.rows[0].TrailingAlert,
-		r.rows[0].StartTotalCost,
+		r.rows[0].Params,
+		r.rows[0].CooldownSecs,
+		r.rows[0].Hysteresis,
+		r.rows[0].ActiveFrom,
+		r.rows[0].ExpiresAt,
+		r.rows[0].ActiveHours,
+		r.rows[0].BalanceBasis,
+		r.rows[0].MinCompleteness,
 		r.rows[0].CreatedAt,
 	}, nil
 }
@@ -46,5 +50,5 @@ func (r iteratorForPortfolioTriggers_Create) Err() error {
 }
 
 func (q *Queries) PortfolioTriggers_Create(ctx context.Context, arg []PortfolioTriggers_CreateParams) (int64, error) {
-	return q.db.CopyFrom(ctx, []string{"portfolio_triggers"}, []string{"id", "portfolio_id", "type", "currency", "limit", "percent", "trailing_alert", "start_total_cost", "created_at"}, &iteratorForPortfolioTriggers_Create{rows: arg})
+	return q.db.CopyFrom(ctx, []string{"portfolio_triggers"}, []string{"id", "portfolio_id", "type", "currency", "params", "cooldown_secs", "hysteresis", "active_from", "expires_at", "active_hours", "balance_basis", "min_completeness", "created_at"}, &iteratorForPortfolioTriggers_Create{rows: arg})
 }
@@ -5,6 +5,8 @@
 package repo
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,14 +23,56 @@ type Account struct {
 	Aliases      []string
 }
 
+type PortfolioGroup struct {
+	ID   int64
+	Name string
+}
+
+type PortfolioSnapshot struct {
+	PortfolioID int64
+	CreatedAt   time.Time
+	Total       json.RawMessage
+	Details     json.RawMessage
+}
+
+type PortfolioTriggerEvent struct {
+	ID              int64
+	PortfolioID     int64
+	TriggerID       uuid.UUID
+	TriggerType     string
+	TriggerSettings json.RawMessage
+	Done            bool
+	CurrentValue    decimal.Decimal
+	Total           json.RawMessage
+	CreatedAt       time.Time
+	Details         json.RawMessage
+	Kind            string
+}
+
+type PortfolioTriggerEventsOutbox struct {
+	ID        int64
+	Payload   json.RawMessage
+	Attempts  int32
+	LastError *string
+	CreatedAt time.Time
+	SentAt    sql.NullTime
+}
+
 type PortfolioTrigger struct {
-	ID             uuid.UUID
-	PortfolioID    int64
-	Type           string
-	Currency       string
-	CreatedAt      time.Time
-	Limit          *decimal.Decimal
-	Percent        *decimal.Decimal
-	TrailingAlert  bool
-	StartTotalCost *decimal.Decimal
+	ID              uuid.UUID
+	PortfolioID     int64
+	Type            string
+	Currency        string
+	CreatedAt       time.Time
+	Paused          bool
+	Params          json.RawMessage
+	CooldownSecs    int64
+	Hysteresis      *decimal.Decimal
+	FiredAt         sql.NullTime
+	FiredValue      *decimal.Decimal
+	ActiveFrom      sql.NullTime
+	ExpiresAt       sql.NullTime
+	ActiveHours     json.RawMessage
+	BalanceBasis    string
+	MinCompleteness *decimal.Decimal
 }
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
@@ -9,8 +10,24 @@ import (
 type Querier interface {
 	Accounts_GetByName(ctx context.Context, name string) (Account, error)
 	Accounts_SelectWithPortfolioTriggers(ctx context.Context) ([]Accounts_SelectWithPortfolioTriggersRow, error)
+	PortfolioGroups_Upsert(ctx context.Context, name string) (int64, error)
+	PortfolioSnapshots_Create(ctx context.Context, arg PortfolioSnapshots_CreateParams) error
+	PortfolioSnapshots_DeleteByPortfolioID(ctx context.Context, portfolioID int64) error
+	PortfolioSnapshots_SelectDownsampled(ctx context.Context, arg PortfolioSnapshots_SelectDownsampledParams) ([]PortfolioSnapshots_SelectDownsampledRow, error)
+	PortfolioTriggerEvents_Create(ctx context.Context, arg PortfolioTriggerEvents_CreateParams) error
+	PortfolioTriggerEvents_Select(ctx context.Context, arg PortfolioTriggerEvents_SelectParams) ([]PortfolioTriggerEvent, error)
+	PortfolioTriggerEventsOutbox_Create(ctx context.Context, arg PortfolioTriggerEventsOutbox_CreateParams) error
+	PortfolioTriggerEventsOutbox_DeleteSentBefore(ctx context.Context, sentAt sql.NullTime) error
+	PortfolioTriggerEventsOutbox_MarkFailed(ctx context.Context, arg PortfolioTriggerEventsOutbox_MarkFailedParams) error
+	PortfolioTriggerEventsOutbox_MarkSent(ctx context.Context, arg PortfolioTriggerEventsOutbox_MarkSentParams) error
+	PortfolioTriggerEventsOutbox_SelectUnsent(ctx context.Context, limit int32) ([]PortfolioTriggerEventsOutbox_SelectUnsentRow, error)
 	PortfolioTriggers_Create(ctx context.Context, arg []PortfolioTriggers_CreateParams) (int64, error)
 	PortfolioTriggers_Delete(ctx context.Context, id uuid.UUID) error
 	PortfolioTriggers_DeleteByPortfolioID(ctx context.Context, portfolioID int64) error
-	PortfolioTriggers_UpdateStartTotalCost(ctx context.Context, arg PortfolioTriggers_UpdateStartTotalCostParams) error
+	PortfolioTriggers_SelectByPortfolioID(ctx context.Context, portfolioID int64) ([]PortfolioTriggerRow, error)
+	PortfolioTriggers_Update(ctx context.Context, arg PortfolioTriggers_UpdateParams) error
+	PortfolioTriggers_UpdateMinCompleteness(ctx context.Context, arg PortfolioTriggers_UpdateMinCompletenessParams) error
+	PortfolioTriggers_UpdatePaused(ctx context.Context, arg PortfolioTriggers_UpdatePausedParams) error
+	PortfolioTriggers_UpdateRearm(ctx context.Context, arg PortfolioTriggers_UpdateRearmParams) error
+	PortfolioTriggers_UpdateSchedule(ctx context.Context, arg PortfolioTriggers_UpdateScheduleParams) error
 }
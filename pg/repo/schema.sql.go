@@ -7,6 +7,8 @@ package repo
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -32,16 +34,233 @@ func (q *Queries) Accounts_GetByName(ctx context.Context, name string) (Account,
 	return i, err
 }
 
+const portfolioGroups_Upsert = `-- name: PortfolioGroups_Upsert :one
+insert into portfolio_groups (name) values ($1)
+on conflict (name) do update set name = excluded.name
+returning id
+`
+
+func (q *Queries) PortfolioGroups_Upsert(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRow(ctx, portfolioGroups_Upsert, name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const portfolioSnapshots_Create = `-- name: PortfolioSnapshots_Create :exec
+insert into portfolio_snapshots (portfolio_id, total, details, created_at) values ($1, $2, $3, $4)
+`
+
+type PortfolioSnapshots_CreateParams struct {
+	PortfolioID int64
+	Total       json.RawMessage
+	Details     json.RawMessage
+	CreatedAt   time.Time
+}
+
+func (q *Queries) PortfolioSnapshots_Create(ctx context.Context, arg PortfolioSnapshots_CreateParams) error {
+	_, err := q.db.Exec(ctx, portfolioSnapshots_Create,
+		arg.PortfolioID,
+		arg.Total,
+		arg.Details,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const portfolioSnapshots_DeleteByPortfolioID = `-- name: PortfolioSnapshots_DeleteByPortfolioID :exec
+delete from portfolio_snapshots where portfolio_id = $1
+`
+
+func (q *Queries) PortfolioSnapshots_DeleteByPortfolioID(ctx context.Context, portfolioID int64) error {
+	_, err := q.db.Exec(ctx, portfolioSnapshots_DeleteByPortfolioID, portfolioID)
+	return err
+}
+
+const portfolioSnapshots_SelectDownsampled = `-- name: PortfolioSnapshots_SelectDownsampled :many
+select distinct on (bucket)
+    date_bin($1::bigint * interval '1 second', created_at, timestamp 'epoch')::timestamp bucket,
+    total,
+    details
+from portfolio_snapshots
+where portfolio_id = $2 and created_at >= $3 and created_at < $4
+order by bucket, created_at desc
+`
+
+type PortfolioSnapshots_SelectDownsampledParams struct {
+	Resolution  int64
+	PortfolioID int64
+	FromTime    time.Time
+	ToTime      time.Time
+}
+
+type PortfolioSnapshots_SelectDownsampledRow struct {
+	Bucket  time.Time
+	Total   json.RawMessage
+	Details json.RawMessage
+}
+
+func (q *Queries) PortfolioSnapshots_SelectDownsampled(ctx context.Context, arg PortfolioSnapshots_SelectDownsampledParams) ([]PortfolioSnapshots_SelectDownsampledRow, error) {
+	rows, err := q.db.Query(ctx, portfolioSnapshots_SelectDownsampled,
+		arg.Resolution,
+		arg.PortfolioID,
+		arg.FromTime,
+		arg.ToTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PortfolioSnapshots_SelectDownsampledRow
+	for rows.Next() {
+		var i PortfolioSnapshots_SelectDownsampledRow
+		if err := rows.Scan(&i.Bucket, &i.Total, &i.Details); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const portfolioTriggerEvents_Create = `-- name: PortfolioTriggerEvents_Create :exec
+insert into portfolio_trigger_events
+    (portfolio_id, trigger_id, trigger_type, trigger_settings, done, current_value, total, details, kind, created_at)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+`
+
+type PortfolioTriggerEvents_CreateParams struct {
+	PortfolioID     int64
+	TriggerID       uuid.UUID
+	TriggerType     string
+	TriggerSettings json.RawMessage
+	Done            bool
+	CurrentValue    decimal.Decimal
+	Total           json.RawMessage
+	Details         json.RawMessage
+	Kind            string
+	CreatedAt       time.Time
+}
+
+func (q *Queries) PortfolioTriggerEvents_Create(ctx context.Context, arg PortfolioTriggerEvents_CreateParams) error {
+	_, err := q.db.Exec(ctx, portfolioTriggerEvents_Create,
+		arg.PortfolioID,
+		arg.TriggerID,
+		arg.TriggerType,
+		arg.TriggerSettings,
+		arg.Done,
+		arg.CurrentValue,
+		arg.Total,
+		arg.Details,
+		arg.Kind,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const portfolioTriggerEventsOutbox_Create = `-- name: PortfolioTriggerEventsOutbox_Create :exec
+insert into portfolio_trigger_events_outbox (payload, created_at) values ($1, $2)
+`
+
+type PortfolioTriggerEventsOutbox_CreateParams struct {
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
+
+func (q *Queries) PortfolioTriggerEventsOutbox_Create(ctx context.Context, arg PortfolioTriggerEventsOutbox_CreateParams) error {
+	_, err := q.db.Exec(ctx, portfolioTriggerEventsOutbox_Create, arg.Payload, arg.CreatedAt)
+	return err
+}
+
+const portfolioTriggerEventsOutbox_DeleteSentBefore = `-- name: PortfolioTriggerEventsOutbox_DeleteSentBefore :exec
+delete from portfolio_trigger_events_outbox where sent_at < $1
+`
+
+func (q *Queries) PortfolioTriggerEventsOutbox_DeleteSentBefore(ctx context.Context, sentAt sql.NullTime) error {
+	_, err := q.db.Exec(ctx, portfolioTriggerEventsOutbox_DeleteSentBefore, sentAt)
+	return err
+}
+
+const portfolioTriggerEventsOutbox_MarkFailed = `-- name: PortfolioTriggerEventsOutbox_MarkFailed :exec
+update portfolio_trigger_events_outbox
+set attempts = attempts + 1, last_error = $1 where id = $2
+`
+
+type PortfolioTriggerEventsOutbox_MarkFailedParams struct {
+	LastError *string
+	ID        int64
+}
+
+func (q *Queries) PortfolioTriggerEventsOutbox_MarkFailed(ctx context.Context, arg PortfolioTriggerEventsOutbox_MarkFailedParams) error {
+	_, err := q.db.Exec(ctx, portfolioTriggerEventsOutbox_MarkFailed, arg.LastError, arg.ID)
+	return err
+}
+
+const portfolioTriggerEventsOutbox_MarkSent = `-- name: PortfolioTriggerEventsOutbox_MarkSent :exec
+update portfolio_trigger_events_outbox
+set sent_at = $1, attempts = attempts + 1, last_error = null where id = $2
+`
+
+type PortfolioTriggerEventsOutbox_MarkSentParams struct {
+	SentAt sql.NullTime
+	ID     int64
+}
+
+func (q *Queries) PortfolioTriggerEventsOutbox_MarkSent(ctx context.Context, arg PortfolioTriggerEventsOutbox_MarkSentParams) error {
+	_, err := q.db.Exec(ctx, portfolioTriggerEventsOutbox_MarkSent, arg.SentAt, arg.ID)
+	return err
+}
+
+const portfolioTriggerEventsOutbox_SelectUnsent = `-- name: PortfolioTriggerEventsOutbox_SelectUnsent :many
+select id, payload, attempts from portfolio_trigger_events_outbox
+where sent_at is null
+order by id
+limit $1
+for update skip locked
+`
+
+type PortfolioTriggerEventsOutbox_SelectUnsentRow struct {
+	ID       int64
+	Payload  json.RawMessage
+	Attempts int32
+}
+
+func (q *Queries) PortfolioTriggerEventsOutbox_SelectUnsent(ctx context.Context, limit int32) ([]PortfolioTriggerEventsOutbox_SelectUnsentRow, error) {
+	rows, err := q.db.Query(ctx, portfolioTriggerEventsOutbox_SelectUnsent, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PortfolioTriggerEventsOutbox_SelectUnsentRow
+	for rows.Next() {
+		var i PortfolioTriggerEventsOutbox_SelectUnsentRow
+		if err := rows.Scan(&i.ID, &i.Payload, &i.Attempts); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 type PortfolioTriggers_CreateParams struct {
-	ID             uuid.UUID
-	PortfolioID    int64
-	Type           string
-	Currency       string
-	Limit          *decimal.Decimal
-	Percent        *decimal.Decimal
-	TrailingAlert  bool
-	StartTotalCost *decimal.Decimal
-	CreatedAt      time.Time
+	ID              uuid.UUID
+	PortfolioID     int64
+	Type            string
+	Currency        string
+	Params          json.RawMessage
+	CooldownSecs    int64
+	Hysteresis      *decimal.Decimal
+	ActiveFrom      sql.NullTime
+	ExpiresAt       sql.NullTime
+	ActiveHours     json.RawMessage
+	BalanceBasis    string
+	MinCompleteness *decimal.Decimal
+	CreatedAt       time.Time
 }
 
 const portfolioTriggers_Delete = `-- name: PortfolioTriggers_Delete :exec
@@ -62,17 +281,93 @@ func (q *Queries) PortfolioTriggers_DeleteByPortfolioID(ctx context.Context, por
 	return err
 }
 
-const portfolioTriggers_UpdateStartTotalCost = `-- name: PortfolioTriggers_UpdateStartTotalCost :exec
+const portfolioTriggers_Update = `-- name: PortfolioTriggers_Update :exec
+update portfolio_triggers
+set params = $1 where id = $2
+`
+
+type PortfolioTriggers_UpdateParams struct {
+	Params json.RawMessage
+	ID     uuid.UUID
+}
+
+func (q *Queries) PortfolioTriggers_Update(ctx context.Context, arg PortfolioTriggers_UpdateParams) error {
+	_, err := q.db.Exec(ctx, portfolioTriggers_Update, arg.Params, arg.ID)
+	return err
+}
+
+const portfolioTriggers_UpdateRearm = `-- name: PortfolioTriggers_UpdateRearm :exec
+update portfolio_triggers
+set cooldown_secs = $1, hysteresis = $2, fired_at = $3, fired_value = $4 where id = $5
+`
+
+type PortfolioTriggers_UpdateRearmParams struct {
+	CooldownSecs int64
+	Hysteresis   *decimal.Decimal
+	FiredAt      sql.NullTime
+	FiredValue   *decimal.Decimal
+	ID           uuid.UUID
+}
+
+func (q *Queries) PortfolioTriggers_UpdateRearm(ctx context.Context, arg PortfolioTriggers_UpdateRearmParams) error {
+	_, err := q.db.Exec(ctx, portfolioTriggers_UpdateRearm,
+		arg.CooldownSecs,
+		arg.Hysteresis,
+		arg.FiredAt,
+		arg.FiredValue,
+		arg.ID,
+	)
+	return err
+}
+
+const portfolioTriggers_UpdateSchedule = `-- name: PortfolioTriggers_UpdateSchedule :exec
+update portfolio_triggers
+set active_from = $1, expires_at = $2, active_hours = $3 where id = $4
+`
+
+type PortfolioTriggers_UpdateScheduleParams struct {
+	ActiveFrom  sql.NullTime
+	ExpiresAt   sql.NullTime
+	ActiveHours json.RawMessage
+	ID          uuid.UUID
+}
+
+func (q *Queries) PortfolioTriggers_UpdateSchedule(ctx context.Context, arg PortfolioTriggers_UpdateScheduleParams) error {
+	_, err := q.db.Exec(ctx, portfolioTriggers_UpdateSchedule,
+		arg.ActiveFrom,
+		arg.ExpiresAt,
+		arg.ActiveHours,
+		arg.ID,
+	)
+	return err
+}
+
+const portfolioTriggers_UpdateMinCompleteness = `-- name: PortfolioTriggers_UpdateMinCompleteness :exec
+update portfolio_triggers
+set min_completeness = $1 where id = $2
+`
+
+type PortfolioTriggers_UpdateMinCompletenessParams struct {
+	MinCompleteness *decimal.Decimal
+	ID              uuid.UUID
+}
+
+func (q *Queries) PortfolioTriggers_UpdateMinCompleteness(ctx context.Context, arg PortfolioTriggers_UpdateMinCompletenessParams) error {
+	_, err := q.db.Exec(ctx, portfolioTriggers_UpdateMinCompleteness, arg.MinCompleteness, arg.ID)
+	return err
+}
+
+const portfolioTriggers_UpdatePaused = `-- name: PortfolioTriggers_UpdatePaused :exec
 update portfolio_triggers
-set start_total_cost = $1 where id = $2 and type = 'COST_CHANGED_BY_PERCENT'
+set paused = $1 where id = $2
 `
 
-type PortfolioTriggers_UpdateStartTotalCostParams struct {
-	StartTotalCost *decimal.Decimal
-	ID             uuid.UUID
+type PortfolioTriggers_UpdatePausedParams struct {
+	Paused bool
+	ID     uuid.UUID
 }
 
-func (q *Queries) PortfolioTriggers_UpdateStartTotalCost(ctx context.Context, arg PortfolioTriggers_UpdateStartTotalCostParams) error {
-	_, err := q.db.Exec(ctx, portfolioTriggers_UpdateStartTotalCost, arg.StartTotalCost, arg.ID)
+func (q *Queries) PortfolioTriggers_UpdatePaused(ctx context.Context, arg PortfolioTriggers_UpdatePausedParams) error {
+	_, err := q.db.Exec(ctx, portfolioTriggers_UpdatePaused, arg.Paused, arg.ID)
 	return err
 }
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,6 +10,25 @@ import (
 	"gitlab.com/moderntoken/gateways/decimal"
 )
 
+// PortfolioTriggerRow is a portfolio trigger with nullable columns as pointers
+type PortfolioTriggerRow struct {
+	ID              uuid.UUID
+	Type            string
+	Currency        string
+	CreatedAt       time.Time
+	Paused          bool
+	Params          json.RawMessage
+	CooldownSecs    int64
+	Hysteresis      *decimal.Decimal
+	FiredAt         *time.Time
+	FiredValue      *decimal.Decimal
+	ActiveFrom      *time.Time
+	ExpiresAt       *time.Time
+	ActiveHours     json.RawMessage
+	BalanceBasis    string
+	MinCompleteness *decimal.Decimal
+}
+
 type Accounts_SelectWithPortfolioTriggersRow struct {
 	ID           int64
 	Name         string
@@ -16,21 +36,15 @@ type Accounts_SelectWithPortfolioTriggersRow struct {
 	Key          string
 	Secret       string
 	Passphrase   *string
-	Triggers     []struct {
-		ID             uuid.UUID
-		Type           string
-		Currency       string
-		CreatedAt      time.Time
-		Limit          *decimal.Decimal
-		Percent        *decimal.Decimal
-		StartTotalCost *decimal.Decimal
-		TrailingAlert  bool
-	} `json:"-"`
+	Aliases      []string
+	Triggers     []PortfolioTriggerRow `json:"-"`
 }
 
 func (q *Queries) Accounts_SelectWithPortfolioTriggers(ctx context.Context) ([]Accounts_SelectWithPortfolioTriggersRow, error) {
-	query := `select a.id a_id, a.name, a.exchange_name, a.key, a.secret, a.passphrase,
-		pt.id pt_id, pt.type, pt.currency, pt.created_at, pt.limit::numeric, pt.percent, pt.start_total_cost, pt.trailing_alert
+	query := `select a.id a_id, a.name, a.exchange_name, a.key, a.secret, a.passphrase, a.aliases,
+		pt.id pt_id, pt.type, pt.currency, pt.created_at, pt.paused, pt.params,
+		pt.cooldown_secs, pt.hysteresis, pt.fired_at, pt.fired_value, pt.active_from, pt.expires_at, pt.active_hours,
+		pt.balance_basis, pt.min_completeness
 		from accounts a
 		left join portfolio_triggers pt on pt.portfolio_id = a.id;`
 	rows, err := q.db.Query(ctx, query)
@@ -47,15 +61,23 @@ func (q *Queries) Accounts_SelectWithPortfolioTriggers(ctx context.Context) ([]A
 		Key          string
 		Secret       string
 		Passphrase   *string
+		Aliases      []string
 		// Left join
-		PortfolioID    *uuid.UUID
-		Type           *string
-		Currency       *string
-		Limit          *decimal.Decimal
-		Percent        *decimal.Decimal
-		StartTotalCost *decimal.Decimal
-		TrailingAlert  *bool
-		CreatedAt      *time.Time
+		PortfolioID     *uuid.UUID
+		Type            *string
+		Currency        *string
+		CreatedAt       *time.Time
+		Paused          *bool
+		Params          json.RawMessage
+		CooldownSecs    *int64
+		Hysteresis      *decimal.Decimal
+		FiredAt         *time.Time
+		FiredValue      *decimal.Decimal
+		ActiveFrom      *time.Time
+		ExpiresAt       *time.Time
+		ActiveHours     json.RawMessage
+		BalanceBasis    *string
+		MinCompleteness *decimal.Decimal
 	}
 
 	var accs []Accounts_SelectWithPortfolioTriggersRow
@@ -70,14 +92,22 @@ func (q *Queries) Accounts_SelectWithPortfolioTriggers(ctx context.Context) ([]A
 			&res.Key,
 			&res.Secret,
 			&res.Passphrase,
+			&res.Aliases,
 			&res.PortfolioID,
 			&res.Type,
 			&res.Currency,
 			&res.CreatedAt,
-			&res.Limit,
-			&res.Percent,
-			&res.StartTotalCost,
-			&res.TrailingAlert,
+			&res.Paused,
+			&res.Params,
+			&res.CooldownSecs,
+			&res.Hysteresis,
+			&res.FiredAt,
+			&res.FiredValue,
+			&res.ActiveFrom,
+			&res.ExpiresAt,
+			&res.ActiveHours,
+			&res.BalanceBasis,
+			&res.MinCompleteness,
 		); err != nil {
 			return nil, errors.Wrapf(err, "failed to scan row of %q into %T", query, res)
 		}
@@ -91,30 +121,29 @@ func (q *Queries) Accounts_SelectWithPortfolioTriggers(ctx context.Context) ([]A
 				Key:          res.Key,
 				Secret:       res.Secret,
 				Passphrase:   res.Passphrase,
+				Aliases:      res.Aliases,
 			})
 			acc = &accs[len(accs)-1]
 			m[res.ID] = acc
 		}
 
 		if res.PortfolioID != nil {
-			acc.Triggers = append(acc.Triggers, struct {
-				ID             uuid.UUID
-				Type           string
-				Currency       string
-				CreatedAt      time.Time
-				Limit          *decimal.Decimal
-				Percent        *decimal.Decimal
-				StartTotalCost *decimal.Decimal
-				TrailingAlert  bool
-			}{
-				ID:             *res.PortfolioID,
-				Type:           *res.Type,
-				Currency:       *res.Currency,
-				Limit:          res.Limit,
-				Percent:        res.Percent,
-				TrailingAlert:  *res.TrailingAlert,
-				StartTotalCost: res.StartTotalCost,
-				CreatedAt:      *res.CreatedAt,
+			acc.Triggers = append(acc.Triggers, PortfolioTriggerRow{
+				ID:              *res.PortfolioID,
+				Type:            *res.Type,
+				Currency:        *res.Currency,
+				CreatedAt:       *res.CreatedAt,
+				Paused:          *res.Paused,
+				Params:          res.Params,
+				CooldownSecs:    *res.CooldownSecs,
+				Hysteresis:      res.Hysteresis,
+				FiredAt:         res.FiredAt,
+				FiredValue:      res.FiredValue,
+				ActiveFrom:      res.ActiveFrom,
+				ExpiresAt:       res.ExpiresAt,
+				ActiveHours:     res.ActiveHours,
+				BalanceBasis:    *res.BalanceBasis,
+				MinCompleteness: res.MinCompleteness,
 			})
 		}
 	}
@@ -124,3 +153,110 @@ func (q *Queries) Accounts_SelectWithPortfolioTriggers(ctx context.Context) ([]A
 	}
 	return accs, nil
 }
+
+// PortfolioTriggers_SelectByPortfolioID selects triggers of portfolio (or group of portfolios)
+func (q *Queries) PortfolioTriggers_SelectByPortfolioID(ctx context.Context, portfolioID int64) ([]PortfolioTriggerRow, error) {
+	query := `select id, type, currency, created_at, paused, params,
+		cooldown_secs, hysteresis, fired_at, fired_value, active_from, expires_at, active_hours,
+		balance_basis, min_completeness
+		from portfolio_triggers
+		where portfolio_id = $1;`
+	rows, err := q.db.Query(ctx, query, portfolioID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query portfolio triggers")
+	}
+
+	defer rows.Close()
+
+	var triggers []PortfolioTriggerRow
+	for rows.Next() {
+		var t PortfolioTriggerRow
+		if err := rows.Scan(
+			&t.ID,
+			&t.Type,
+			&t.Currency,
+			&t.CreatedAt,
+			&t.Paused,
+			&t.Params,
+			&t.CooldownSecs,
+			&t.Hysteresis,
+			&t.FiredAt,
+			&t.FiredValue,
+			&t.ActiveFrom,
+			&t.ExpiresAt,
+			&t.ActiveHours,
+			&t.BalanceBasis,
+			&t.MinCompleteness,
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to scan row of %q into %T", query, t)
+		}
+		triggers = append(triggers, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "error of query %q", query)
+	}
+	return triggers, nil
+}
+
+type PortfolioTriggerEvents_SelectParams struct {
+	PortfolioID int64
+	TriggerID   *uuid.UUID
+	TriggerType *string
+	FromTime    time.Time
+	ToTime      time.Time
+	Limit       int32
+}
+
+// PortfolioTriggerEvents_Select selects the latest portfolio trigger events within [FromTime, ToTime) range.
+// Nil TriggerID and TriggerType are not filtered by
+func (q *Queries) PortfolioTriggerEvents_Select(ctx context.Context, arg PortfolioTriggerEvents_SelectParams) ([]PortfolioTriggerEvent, error) {
+	query := `select id, portfolio_id, trigger_id, trigger_type, trigger_settings, done, current_value, total, created_at,
+			details, kind
+		from portfolio_trigger_events
+		where portfolio_id = $1
+			and ($2::uuid is null or trigger_id = $2)
+			and ($3::text is null or trigger_type = $3)
+			and created_at >= $4 and created_at < $5
+		order by created_at desc, id desc
+		limit $6;`
+	rows, err := q.db.Query(ctx, query,
+		arg.PortfolioID,
+		arg.TriggerID,
+		arg.TriggerType,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query portfolio trigger events")
+	}
+
+	defer rows.Close()
+
+	var events []PortfolioTriggerEvent
+	for rows.Next() {
+		var e PortfolioTriggerEvent
+		if err := rows.Scan(
+			&e.ID,
+			&e.PortfolioID,
+			&e.TriggerID,
+			&e.TriggerType,
+			&e.TriggerSettings,
+			&e.Done,
+			&e.CurrentValue,
+			&e.Total,
+			&e.CreatedAt,
+			&e.Details,
+			&e.Kind,
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to scan row of %q into %T", query, e)
+		}
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "error of query %q", query)
+	}
+	return events, nil
+}
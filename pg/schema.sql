@@ -1,3 +1,5 @@
+-- Schema of the latest version. Existing databases are upgraded by migrations/*.sql applied in order of their numbers
+
 create table accounts (
     id bigserial primary key,
     name text not null unique,
@@ -8,32 +10,145 @@ create table accounts (
     aliases text[]
 );
 
+-- Groups consolidate portfolios of accounts sharing alias. IDs are shared with accounts,
+-- so triggers, snapshots and trigger events of groups are referenced by portfolio_id as well
+create table portfolio_groups (
+    id bigint primary key default nextval('accounts_id_seq'),
+    name text not null unique
+);
+
 create table portfolio_triggers (
     id uuid primary key,
     portfolio_id bigint not null,
     type text not null,
     currency text not null,
     created_at timestamp not null default now(),
-    "limit" numeric,
-    percent numeric,
-    trailing_alert bool not null,
-    start_total_cost numeric
+    paused bool not null default false,
+    params jsonb not null default '{}',
+    cooldown_secs bigint not null default 0,
+    hysteresis numeric,
+    fired_at timestamp,
+    fired_value numeric,
+    active_from timestamp,
+    expires_at timestamp,
+    active_hours jsonb,
+    balance_basis text not null default 'TOTAL',
+    min_completeness numeric
+);
+
+create table portfolio_snapshots (
+    portfolio_id bigint not null,
+    created_at timestamp not null default now(),
+    total jsonb not null,
+    details jsonb not null
 );
 
+create index portfolio_snapshots_portfolio_id_created_at_idx on portfolio_snapshots (portfolio_id, created_at);
+
+-- Trigger events outlive their portfolios: they aren't deleted on portfolio destroy
+create table portfolio_trigger_events (
+    id bigserial primary key,
+    portfolio_id bigint not null,
+    trigger_id uuid not null,
+    trigger_type text not null,
+    trigger_settings jsonb not null,
+    done bool not null,
+    current_value numeric not null,
+    total jsonb not null,
+    created_at timestamp not null default now(),
+    details jsonb,
+    kind text not null default 'EXECUTED'
+);
+
+create index portfolio_trigger_events_portfolio_id_created_at_idx on portfolio_trigger_events (portfolio_id, created_at);
+
+create table portfolio_trigger_events_outbox (
+    id bigserial primary key,
+    payload jsonb not null,
+    attempts int not null default 0,
+    last_error text,
+    created_at timestamp not null default now(),
+    sent_at timestamp
+);
+
+create index portfolio_trigger_events_outbox_unsent_idx on portfolio_trigger_events_outbox (id) where sent_at is null;
+
 -- name: Accounts_GetByName :one
 select * from accounts where name = $1;
 
+-- name: PortfolioGroups_Upsert :one
+insert into portfolio_groups (name) values ($1)
+on conflict (name) do update set name = excluded.name
+returning id;
+
 -- name: PortfolioTriggers_Create :copyfrom
 insert into portfolio_triggers
-    (id, portfolio_id, type, currency, "limit", percent, trailing_alert, start_total_cost, created_at) values
-    ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+    (id, portfolio_id, type, currency, params, cooldown_secs, hysteresis, active_from, expires_at, active_hours, balance_basis, min_completeness, created_at)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13);
+
+-- name: PortfolioTriggers_Update :exec
+update portfolio_triggers
+set params = $1 where id = $2;
+
+-- name: PortfolioTriggers_UpdateRearm :exec
+update portfolio_triggers
+set cooldown_secs = $1, hysteresis = $2, fired_at = $3, fired_value = $4 where id = $5;
+
+-- name: PortfolioTriggers_UpdateSchedule :exec
+update portfolio_triggers
+set active_from = $1, expires_at = $2, active_hours = $3 where id = $4;
+
+-- name: PortfolioTriggers_UpdateMinCompleteness :exec
+update portfolio_triggers
+set min_completeness = $1 where id = $2;
 
--- name: PortfolioTriggers_UpdateStartTotalCost :exec
+-- name: PortfolioTriggers_UpdatePaused :exec
 update portfolio_triggers
-set start_total_cost = $1 where id = $2 and type = 'COST_CHANGED_BY_PERCENT';
+set paused = $1 where id = $2;
 
 -- name: PortfolioTriggers_Delete :exec
 delete from portfolio_triggers where id = $1;
 
 -- name: PortfolioTriggers_DeleteByPortfolioID :exec
 delete from portfolio_triggers where portfolio_id = $1;
+
+-- name: PortfolioSnapshots_Create :exec
+insert into portfolio_snapshots (portfolio_id, total, details, created_at) values ($1, $2, $3, $4);
+
+-- name: PortfolioSnapshots_SelectDownsampled :many
+select distinct on (bucket)
+    date_bin(@resolution::bigint * interval '1 second', created_at, timestamp 'epoch')::timestamp bucket,
+    total,
+    details
+from portfolio_snapshots
+where portfolio_id = @portfolio_id and created_at >= @from_time and created_at < @to_time
+order by bucket, created_at desc;
+
+-- name: PortfolioSnapshots_DeleteByPortfolioID :exec
+delete from portfolio_snapshots where portfolio_id = $1;
+
+-- name: PortfolioTriggerEvents_Create :exec
+insert into portfolio_trigger_events
+    (portfolio_id, trigger_id, trigger_type, trigger_settings, done, current_value, total, details, kind, created_at)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: PortfolioTriggerEventsOutbox_Create :exec
+insert into portfolio_trigger_events_outbox (payload, created_at) values ($1, $2);
+
+-- name: PortfolioTriggerEventsOutbox_SelectUnsent :many
+select id, payload, attempts from portfolio_trigger_events_outbox
+where sent_at is null
+order by id
+limit $1
+for update skip locked;
+
+-- name: PortfolioTriggerEventsOutbox_MarkSent :exec
+update portfolio_trigger_events_outbox
+set sent_at = $1, attempts = attempts + 1, last_error = null where id = $2;
+
+-- name: PortfolioTriggerEventsOutbox_MarkFailed :exec
+update portfolio_trigger_events_outbox
+set attempts = attempts + 1, last_error = $1 where id = $2;
+
+-- name: PortfolioTriggerEventsOutbox_DeleteSentBefore :exec
+delete from portfolio_trigger_events_outbox where sent_at < $1;
@@ -8,6 +8,7 @@ import (
 	repo "github.com/egsam98/portfolio/pg/repo"
 	mock "github.com/stretchr/testify/mock"
 
+	sql "database/sql"
 	uuid "github.com/google/uuid"
 )
 
@@ -60,6 +61,194 @@ func (_m *Querier) Accounts_SelectWithPortfolioTriggers(ctx context.Context) ([]
 	return r0, r1
 }
 
+// PortfolioGroups_Upsert provides a mock function with given fields: ctx, name
+func (_m *Querier) PortfolioGroups_Upsert(ctx context.Context, name string) (int64, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PortfolioSnapshots_Create provides a mock function with given fields: ctx, arg
+func (_m *Querier) PortfolioSnapshots_Create(ctx context.Context, arg repo.PortfolioSnapshots_CreateParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioSnapshots_CreateParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PortfolioSnapshots_DeleteByPortfolioID provides a mock function with given fields: ctx, portfolioID
+func (_m *Querier) PortfolioSnapshots_DeleteByPortfolioID(ctx context.Context, portfolioID int64) error {
+	ret := _m.Called(ctx, portfolioID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, portfolioID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PortfolioSnapshots_SelectDownsampled provides a mock function with given fields: ctx, arg
+func (_m *Querier) PortfolioSnapshots_SelectDownsampled(ctx context.Context, arg repo.PortfolioSnapshots_SelectDownsampledParams) ([]repo.PortfolioSnapshots_SelectDownsampledRow, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []repo.PortfolioSnapshots_SelectDownsampledRow
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioSnapshots_SelectDownsampledParams) []repo.PortfolioSnapshots_SelectDownsampledRow); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.PortfolioSnapshots_SelectDownsampledRow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, repo.PortfolioSnapshots_SelectDownsampledParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PortfolioTriggerEventsOutbox_Create provides a mock function with given fields: ctx, arg
+func (_m *Querier) PortfolioTriggerEventsOutbox_Create(ctx context.Context, arg repo.PortfolioTriggerEventsOutbox_CreateParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioTriggerEventsOutbox_CreateParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PortfolioTriggerEventsOutbox_DeleteSentBefore provides a mock function with given fields: ctx, sentAt
+func (_m *Querier) PortfolioTriggerEventsOutbox_DeleteSentBefore(ctx context.Context, sentAt sql.NullTime) error {
+	ret := _m.Called(ctx, sentAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, sql.NullTime) error); ok {
+		r0 = rf(ctx, sentAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PortfolioTriggerEventsOutbox_MarkFailed provides a mock function with given fields: ctx, arg
+func (_m *Querier) PortfolioTriggerEventsOutbox_MarkFailed(ctx context.Context, arg repo.PortfolioTriggerEventsOutbox_MarkFailedParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioTriggerEventsOutbox_MarkFailedParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PortfolioTriggerEventsOutbox_MarkSent provides a mock function with given fields: ctx, arg
+func (_m *Querier) PortfolioTriggerEventsOutbox_MarkSent(ctx context.Context, arg repo.PortfolioTriggerEventsOutbox_MarkSentParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioTriggerEventsOutbox_MarkSentParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PortfolioTriggerEventsOutbox_SelectUnsent provides a mock function with given fields: ctx, limit
+func (_m *Querier) PortfolioTriggerEventsOutbox_SelectUnsent(ctx context.Context, limit int32) ([]repo.PortfolioTriggerEventsOutbox_SelectUnsentRow, error) {
+	ret := _m.Called(ctx, limit)
+
+	var r0 []repo.PortfolioTriggerEventsOutbox_SelectUnsentRow
+	if rf, ok := ret.Get(0).(func(context.Context, int32) []repo.PortfolioTriggerEventsOutbox_SelectUnsentRow); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.PortfolioTriggerEventsOutbox_SelectUnsentRow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PortfolioTriggerEvents_Create provides a mock function with given fields: ctx, arg
+func (_m *Querier) PortfolioTriggerEvents_Create(ctx context.Context, arg repo.PortfolioTriggerEvents_CreateParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioTriggerEvents_CreateParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PortfolioTriggerEvents_Select provides a mock function with given fields: ctx, arg
+func (_m *Querier) PortfolioTriggerEvents_Select(ctx context.Context, arg repo.PortfolioTriggerEvents_SelectParams) ([]repo.PortfolioTriggerEvent, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []repo.PortfolioTriggerEvent
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioTriggerEvents_SelectParams) []repo.PortfolioTriggerEvent); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.PortfolioTriggerEvent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, repo.PortfolioTriggerEvents_SelectParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PortfolioTriggers_Create provides a mock function with given fields: ctx, arg
 func (_m *Querier) PortfolioTriggers_Create(ctx context.Context, arg []repo.PortfolioTriggers_CreateParams) (int64, error) {
 	ret := _m.Called(ctx, arg)
@@ -109,12 +298,91 @@ func (_m *Querier) PortfolioTriggers_DeleteByPortfolioID(ctx context.Context, po
 	return r0
 }
 
-// PortfolioTriggers_UpdateStartTotalCost provides a mock function with given fields: ctx, arg
-func (_m *Querier) PortfolioTriggers_UpdateStartTotalCost(ctx context.Context, arg repo.PortfolioTriggers_UpdateStartTotalCostParams) error {
+// PortfolioTriggers_SelectByPortfolioID provides a mock function with given fields: ctx, portfolioID
+func (_m *Querier) PortfolioTriggers_SelectByPortfolioID(ctx context.Context, portfolioID int64) ([]repo.PortfolioTriggerRow, error) {
+	ret := _m.Called(ctx, portfolioID)
+
+	var r0 []repo.PortfolioTriggerRow
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []repo.PortfolioTriggerRow); ok {
+		r0 = rf(ctx, portfolioID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.PortfolioTriggerRow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, portfolioID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PortfolioTriggers_Update provides a mock function with given fields: ctx, arg
+func (_m *Querier) PortfolioTriggers_Update(ctx context.Context, arg repo.PortfolioTriggers_UpdateParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioTriggers_UpdateParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PortfolioTriggers_UpdateMinCompleteness provides a mock function with given fields: ctx, arg
+func (_m *Querier) PortfolioTriggers_UpdateMinCompleteness(ctx context.Context, arg repo.PortfolioTriggers_UpdateMinCompletenessParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioTriggers_UpdateMinCompletenessParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PortfolioTriggers_UpdatePaused provides a mock function with given fields: ctx, arg
+func (_m *Querier) PortfolioTriggers_UpdatePaused(ctx context.Context, arg repo.PortfolioTriggers_UpdatePausedParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioTriggers_UpdatePausedParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PortfolioTriggers_UpdateRearm provides a mock function with given fields: ctx, arg
+func (_m *Querier) PortfolioTriggers_UpdateRearm(ctx context.Context, arg repo.PortfolioTriggers_UpdateRearmParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioTriggers_UpdateRearmParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PortfolioTriggers_UpdateSchedule provides a mock function with given fields: ctx, arg
+func (_m *Querier) PortfolioTriggers_UpdateSchedule(ctx context.Context, arg repo.PortfolioTriggers_UpdateScheduleParams) error {
 	ret := _m.Called(ctx, arg)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioTriggers_UpdateStartTotalCostParams) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, repo.PortfolioTriggers_UpdateScheduleParams) error); ok {
 		r0 = rf(ctx, arg)
 	} else {
 		r0 = ret.Error(0)